@@ -0,0 +1,339 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package comparecomplyv1
+
+import (
+	"encoding/csv"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Constants associated with the TableGridCell.Kind property.
+const (
+	TableCellKindTableHeaderConst  = "table_header"
+	TableCellKindColumnHeaderConst = "column_header"
+	TableCellKindRowHeaderConst    = "row_header"
+	TableCellKindBodyConst         = "body"
+)
+
+// TableGridCell : A cell of a table, which may span several rows and columns.
+type TableGridCell struct {
+	CellID string
+
+	// TableCellKindTableHeaderConst, TableCellKindColumnHeaderConst, TableCellKindRowHeaderConst or
+	// TableCellKindBodyConst.
+	Kind string
+
+	Text string
+
+	// The rows and columns covered by the cell, inclusive.
+	RowIndexBegin    int
+	RowIndexEnd      int
+	ColumnIndexBegin int
+	ColumnIndexEnd   int
+
+	// The texts of the headers of a body cell.
+	RowHeaders    []string
+	ColumnHeaders []string
+}
+
+// RowSpan returns the number of rows covered by the cell.
+func (cell *TableGridCell) RowSpan() int {
+	return cell.RowIndexEnd - cell.RowIndexBegin + 1
+}
+
+// ColumnSpan returns the number of columns covered by the cell.
+func (cell *TableGridCell) ColumnSpan() int {
+	return cell.ColumnIndexEnd - cell.ColumnIndexBegin + 1
+}
+
+// TableGridKeyValue : A key and its values, identified in a table.
+type TableGridKeyValue struct {
+	Key    string
+	Values []string
+}
+
+// TableGrid : A table as a rectangular grid. A cell that spans several rows or columns is found at every position it
+// covers, and positions that no cell covers are nil.
+type TableGrid struct {
+	Title string
+	Rows  [][]*TableGridCell
+
+	KeyValuePairs []TableGridKeyValue
+}
+
+// tableGridCell returns a cell with the indexes of the service, which are nil for an empty table.
+func tableGridCell(kind string, cellID *string, text *string, rowBegin *int64, rowEnd *int64, columnBegin *int64, columnEnd *int64) *TableGridCell {
+	cell := &TableGridCell{
+		CellID:           stringValue(cellID),
+		Kind:             kind,
+		Text:             stringValue(text),
+		RowIndexBegin:    int(int64Value(rowBegin)),
+		ColumnIndexBegin: int(int64Value(columnBegin)),
+	}
+	cell.RowIndexEnd = int(int64Value(rowEnd))
+	if rowEnd == nil || cell.RowIndexEnd < cell.RowIndexBegin {
+		cell.RowIndexEnd = cell.RowIndexBegin
+	}
+	cell.ColumnIndexEnd = int(int64Value(columnEnd))
+	if columnEnd == nil || cell.ColumnIndexEnd < cell.ColumnIndexBegin {
+		cell.ColumnIndexEnd = cell.ColumnIndexBegin
+	}
+	return cell
+}
+
+// NewTableGrid reconstructs the grid of a table. The headers of a body cell are the header texts returned by the
+// service; when there are none they are looked up by header ID, and otherwise taken from the row headers in the rows
+// of the cell and the column headers in its columns.
+func NewTableGrid(table *Tables) *TableGrid {
+	grid := &TableGrid{}
+	if table.Title != nil {
+		grid.Title = stringValue(table.Title.Text)
+	}
+
+	var cells []*TableGridCell
+	headerTexts := make(map[string]string)
+	var rowHeaders, columnHeaders []*TableGridCell
+	for _, header := range table.TableHeaders {
+		cells = append(cells, tableGridCell(TableCellKindTableHeaderConst, header.CellID, header.Text,
+			header.RowIndexBegin, header.RowIndexEnd, header.ColumnIndexBegin, header.ColumnIndexEnd))
+	}
+	for _, header := range table.ColumnHeaders {
+		cell := tableGridCell(TableCellKindColumnHeaderConst, header.CellID, header.Text,
+			header.RowIndexBegin, header.RowIndexEnd, header.ColumnIndexBegin, header.ColumnIndexEnd)
+		headerTexts[cell.CellID] = cell.Text
+		columnHeaders = append(columnHeaders, cell)
+		cells = append(cells, cell)
+	}
+	for _, header := range table.RowHeaders {
+		cell := tableGridCell(TableCellKindRowHeaderConst, header.CellID, header.Text,
+			header.RowIndexBegin, header.RowIndexEnd, header.ColumnIndexBegin, header.ColumnIndexEnd)
+		headerTexts[cell.CellID] = cell.Text
+		rowHeaders = append(rowHeaders, cell)
+		cells = append(cells, cell)
+	}
+	for _, body := range table.BodyCells {
+		cell := tableGridCell(TableCellKindBodyConst, body.CellID, body.Text,
+			body.RowIndexBegin, body.RowIndexEnd, body.ColumnIndexBegin, body.ColumnIndexEnd)
+		cell.RowHeaders = append(cell.RowHeaders, body.RowHeaderTexts...)
+		if len(cell.RowHeaders) == 0 {
+			for _, id := range body.RowHeaderIds {
+				if text, ok := headerTexts[id]; ok {
+					cell.RowHeaders = append(cell.RowHeaders, text)
+				}
+			}
+		}
+		if len(cell.RowHeaders) == 0 && len(body.RowHeaderIds) == 0 {
+			cell.RowHeaders = overlappingHeaders(rowHeaders, func(header *TableGridCell) bool {
+				return header.RowIndexBegin <= cell.RowIndexEnd && cell.RowIndexBegin <= header.RowIndexEnd
+			})
+		}
+		cell.ColumnHeaders = append(cell.ColumnHeaders, body.ColumnHeaderTexts...)
+		if len(cell.ColumnHeaders) == 0 {
+			for _, id := range body.ColumnHeaderIds {
+				if text, ok := headerTexts[id]; ok {
+					cell.ColumnHeaders = append(cell.ColumnHeaders, text)
+				}
+			}
+		}
+		if len(cell.ColumnHeaders) == 0 && len(body.ColumnHeaderIds) == 0 {
+			cell.ColumnHeaders = overlappingHeaders(columnHeaders, func(header *TableGridCell) bool {
+				return header.ColumnIndexBegin <= cell.ColumnIndexEnd && cell.ColumnIndexBegin <= header.ColumnIndexEnd
+			})
+		}
+		cells = append(cells, cell)
+	}
+	grid.Rows = layoutTableGrid(cells)
+
+	for _, pair := range table.KeyValuePairs {
+		keyValue := TableGridKeyValue{}
+		if pair.Key != nil {
+			keyValue.Key = stringValue(pair.Key.Text)
+		}
+		for _, value := range pair.Value {
+			keyValue.Values = append(keyValue.Values, stringValue(value.Text))
+		}
+		grid.KeyValuePairs = append(grid.KeyValuePairs, keyValue)
+	}
+	return grid
+}
+
+// Grids returns the grids of the tables.
+func (tableReturn *TableReturn) Grids() []*TableGrid {
+	grids := make([]*TableGrid, len(tableReturn.Tables))
+	for i := range tableReturn.Tables {
+		grids[i] = NewTableGrid(&tableReturn.Tables[i])
+	}
+	return grids
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func overlappingHeaders(headers []*TableGridCell, overlaps func(*TableGridCell) bool) []string {
+	var texts []string
+	for _, header := range headers {
+		if overlaps(header) {
+			texts = append(texts, header.Text)
+		}
+	}
+	return texts
+}
+
+// layoutTableGrid places cells on a grid large enough for all of them. When cells overlap, the first one keeps the
+// position.
+func layoutTableGrid(cells []*TableGridCell) [][]*TableGridCell {
+	rows, columns := 0, 0
+	for _, cell := range cells {
+		if cell.RowIndexBegin < 0 || cell.ColumnIndexBegin < 0 {
+			continue
+		}
+		if cell.RowIndexEnd >= rows {
+			rows = cell.RowIndexEnd + 1
+		}
+		if cell.ColumnIndexEnd >= columns {
+			columns = cell.ColumnIndexEnd + 1
+		}
+	}
+	grid := make([][]*TableGridCell, rows)
+	for i := range grid {
+		grid[i] = make([]*TableGridCell, columns)
+	}
+	for _, cell := range cells {
+		if cell.RowIndexBegin < 0 || cell.ColumnIndexBegin < 0 {
+			continue
+		}
+		for row := cell.RowIndexBegin; row <= cell.RowIndexEnd; row++ {
+			for column := cell.ColumnIndexBegin; column <= cell.ColumnIndexEnd; column++ {
+				if grid[row][column] == nil {
+					grid[row][column] = cell
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// Strings returns the texts of the grid. The text of a spanned cell is repeated at every position it covers.
+func (grid *TableGrid) Strings() [][]string {
+	texts := make([][]string, len(grid.Rows))
+	for i, row := range grid.Rows {
+		texts[i] = make([]string, len(row))
+		for j, cell := range row {
+			if cell != nil {
+				texts[i][j] = cell.Text
+			}
+		}
+	}
+	return texts
+}
+
+// WriteCSV writes the texts of the grid as CSV.
+func (grid *TableGrid) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(grid.Strings()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// HTML renders the grid as an HTML table with escaped texts. Spanned cells are rendered once with rowspan and colspan,
+// and headers are rendered as th elements. A cell that overlaps another is rendered at the first position it occupies
+// and spans the rectangle of positions it occupies from there; the other positions it occupies are left empty, so that
+// every row has one column per position.
+func (grid *TableGrid) HTML() string {
+	var builder strings.Builder
+	builder.WriteString("<table>\n")
+	if grid.Title != "" {
+		builder.WriteString("<caption>" + html.EscapeString(grid.Title) + "</caption>\n")
+	}
+	rendered := make([][]bool, len(grid.Rows))
+	for i, row := range grid.Rows {
+		rendered[i] = make([]bool, len(row))
+	}
+	emitted := make(map[*TableGridCell]bool)
+	for i, row := range grid.Rows {
+		builder.WriteString("<tr>")
+		for j, cell := range row {
+			if rendered[i][j] {
+				continue
+			}
+			if cell == nil || emitted[cell] {
+				builder.WriteString("<td></td>")
+				continue
+			}
+			emitted[cell] = true
+			rowSpan, columnSpan := grid.occupiedSpans(cell, i, j)
+			for k := i; k < i+rowSpan; k++ {
+				for l := j; l < j+columnSpan; l++ {
+					rendered[k][l] = true
+				}
+			}
+			tag, attributes := "td", ""
+			switch cell.Kind {
+			case TableCellKindColumnHeaderConst:
+				tag, attributes = "th", ` scope="col"`
+			case TableCellKindRowHeaderConst:
+				tag, attributes = "th", ` scope="row"`
+			case TableCellKindTableHeaderConst:
+				tag = "th"
+			}
+			if rowSpan > 1 {
+				attributes += ` rowspan="` + strconv.Itoa(rowSpan) + `"`
+			}
+			if columnSpan > 1 {
+				attributes += ` colspan="` + strconv.Itoa(columnSpan) + `"`
+			}
+			builder.WriteString("<" + tag + attributes + ">" + html.EscapeString(cell.Text) + "</" + tag + ">")
+		}
+		builder.WriteString("</tr>\n")
+	}
+	builder.WriteString("</table>\n")
+	return builder.String()
+}
+
+// occupiedSpans returns the rows and columns of the largest rectangle of positions occupied by a cell whose top-left
+// position is row, column.
+func (grid *TableGrid) occupiedSpans(cell *TableGridCell, row int, column int) (int, int) {
+	columnSpan := 0
+	for column+columnSpan < len(grid.Rows[row]) && grid.Rows[row][column+columnSpan] == cell {
+		columnSpan++
+	}
+	rowSpan := 1
+	for row+rowSpan < len(grid.Rows) {
+		for l := column; l < column+columnSpan; l++ {
+			if grid.Rows[row+rowSpan][l] != cell {
+				return rowSpan, columnSpan
+			}
+		}
+		rowSpan++
+	}
+	return rowSpan, columnSpan
+}
@@ -0,0 +1,100 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GetVariable returns a context variable and whether it is set.
+func (o *Context) GetVariable(name string) (interface{}, bool) {
+	value, ok := o.additionalProperties[name]
+	return value, ok
+}
+
+// DecodeVariable decodes a context variable into target, which is typically a pointer to a struct with json tags.
+func (o *Context) DecodeVariable(name string, target interface{}) error {
+	value, ok := o.GetVariable(name)
+	if !ok {
+		return fmt.Errorf("context variable %s is not set", name)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// DecodeVariables decodes all context variables into target, typically a pointer to a struct whose json tags name the
+// variables.
+func (o *Context) DecodeVariables(target interface{}) error {
+	data, err := json.Marshal(o.additionalProperties)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// SetVariables sets context variables from a struct with json tags or a map. Variables that are not part of source
+// are left unchanged.
+func (o *Context) SetVariables(source interface{}) error {
+	data, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+	var variables map[string]interface{}
+	err = json.Unmarshal(data, &variables)
+	if err != nil {
+		return fmt.Errorf("variables must encode to a JSON object: %s", err.Error())
+	}
+	for name, value := range variables {
+		o.SetProperty(name, value)
+	}
+	return nil
+}
+
+// DeleteVariable removes a context variable.
+func (o *Context) DeleteVariable(name string) {
+	delete(o.additionalProperties, name)
+}
+
+// GetSystemVariable returns a variable of the system context, or nil when it is not set.
+func (o *Context) GetSystemVariable(name string) interface{} {
+	return o.System[name]
+}
+
+// Merge applies a context returned by Message to this context. Context variables are merged one by one, so variables
+// set locally and not returned by the service are kept; the conversation ID, system context and metadata are replaced
+// when the update includes them.
+func (o *Context) Merge(update *Context) {
+	if update == nil {
+		return
+	}
+	if update.ConversationID != nil {
+		o.ConversationID = update.ConversationID
+	}
+	if update.System != nil {
+		o.System = update.System
+	}
+	if update.Metadata != nil {
+		o.Metadata = update.Metadata
+	}
+	for name, value := range update.additionalProperties {
+		o.SetProperty(name, value)
+	}
+}
@@ -0,0 +1,76 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`Context variables`, func() {
+	type order struct {
+		Size     string   `json:"size"`
+		Toppings []string `json:"toppings,omitempty"`
+	}
+	unmarshalContext := func(data string) *assistantv1.Context {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+		var messageContext *assistantv1.Context
+		Expect(assistantv1.UnmarshalContext(raw, &messageContext)).To(Succeed())
+		return messageContext
+	}
+
+	It(`Decodes and sets variables`, func() {
+		messageContext := unmarshalContext(`{"conversation_id": "c1", "system": {"dialog_turn_counter": 2},
+			"order": {"size": "large", "toppings": ["ham"]}, "count": 3}`)
+
+		var decoded order
+		Expect(messageContext.DecodeVariable("order", &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(order{Size: "large", Toppings: []string{"ham"}}))
+		Expect(messageContext.DecodeVariable("missing", &decoded)).ToNot(Succeed())
+		Expect(messageContext.GetSystemVariable("dialog_turn_counter")).To(BeNumerically("==", 2))
+
+		var all struct {
+			Count int `json:"count"`
+		}
+		Expect(messageContext.DecodeVariables(&all)).To(Succeed())
+		Expect(all.Count).To(Equal(3))
+
+		Expect(messageContext.SetVariables(map[string]interface{}{"order": order{Size: "small"}})).To(Succeed())
+		value, ok := messageContext.GetVariable("order")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(map[string]interface{}{"size": "small"}))
+		Expect(messageContext.SetVariables("not an object")).ToNot(Succeed())
+
+		messageContext.DeleteVariable("count")
+		_, ok = messageContext.GetVariable("count")
+		Expect(ok).To(BeFalse())
+	})
+	It(`Merges context updates`, func() {
+		local := &assistantv1.Context{}
+		local.SetProperty("local_only", true)
+		local.SetProperty("size", "small")
+		local.Merge(unmarshalContext(`{"conversation_id": "c2", "size": "large"}`))
+		Expect(local.ConversationID).To(Equal(core.StringPtr("c2")))
+		Expect(local.GetProperty("size")).To(Equal("large"))
+		Expect(local.GetProperty("local_only")).To(Equal(true))
+	})
+})
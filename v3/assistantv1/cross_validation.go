@@ -0,0 +1,250 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// CrossValidation : Runs k-fold cross-validation of the intents of a workspace. The examples of every intent are split
+// into folds; for each fold a temporary workspace is trained on the other folds and classifies the examples of the
+// fold. Temporary workspaces are always deleted, also when a fold fails or the context is cancelled.
+type CrossValidation struct {
+	Assistant *AssistantV1
+
+	// Classifies the held-out examples. Defaults to Assistant; set it to an AssistantV2 to classify with the v2
+	// BulkClassify method.
+	Classifier IntentClassifier
+
+	// The number of folds. Defaults to 5.
+	Folds int
+
+	// The maximum number of temporary workspaces that exist at the same time. Defaults to 2.
+	Concurrency int
+
+	// Seeds the random assignment of examples to folds.
+	Seed int64
+
+	// The prefix of the names of the temporary workspaces. Defaults to "cross-validation".
+	NamePrefix string
+
+	// How often the training status is polled. Defaults to 5 seconds.
+	PollInterval time.Duration
+
+	// How long deleting a temporary workspace may take once the run is over. Defaults to 1 minute.
+	CleanupTimeout time.Duration
+}
+
+// NewCrossValidation : Instantiate CrossValidation
+func (assistant *AssistantV1) NewCrossValidation(folds int) *CrossValidation {
+	return &CrossValidation{
+		Assistant:      assistant,
+		Classifier:     assistant,
+		Folds:          folds,
+		Concurrency:    2,
+		NamePrefix:     "cross-validation",
+		PollInterval:   5 * time.Second,
+		CleanupTimeout: time.Minute,
+	}
+}
+
+// CrossValidationFold : The result of one fold.
+type CrossValidationFold struct {
+	Fold        int                   `json:"fold"`
+	WorkspaceID string                `json:"workspace_id"`
+	Training    int                   `json:"training_examples"`
+	Report      *ClassificationReport `json:"report"`
+}
+
+// CrossValidationResult : The results of all folds, and the report of all held-out examples together.
+type CrossValidationResult struct {
+	Folds  []CrossValidationFold `json:"folds"`
+	Report *ClassificationReport `json:"report"`
+}
+
+// WriteJSON writes the result as indented JSON.
+func (result *CrossValidationResult) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// AssignFolds splits the examples of every intent over the folds at random, so that each fold holds a similar share
+// of every intent.
+func AssignFolds(workspace *Workspace, folds int, seed int64) [][]LabeledExample {
+	random := rand.New(rand.NewSource(seed))
+	assigned := make([][]LabeledExample, folds)
+	next := 0
+	for _, intent := range workspace.Intents {
+		for _, i := range random.Perm(len(intent.Examples)) {
+			assigned[next] = append(assigned[next], LabeledExample{Intent: stringValue(intent.Intent), Text: stringValue(intent.Examples[i].Text)})
+			next = (next + 1) % folds
+		}
+	}
+	return assigned
+}
+
+// trainingOptions returns the options to create a workspace with the intents, entities and counterexamples of the
+// workspace, without the held-out examples. The dialog is left out because it does not affect classification.
+func (crossValidation *CrossValidation) trainingOptions(workspace *Workspace, fold int, heldOut []LabeledExample) (*CreateWorkspaceOptions, int) {
+	excluded := make(map[LabeledExample]bool)
+	for _, example := range heldOut {
+		excluded[example] = true
+	}
+
+	options := crossValidation.Assistant.NewCreateWorkspaceOptions()
+	options.SetName(fmt.Sprintf("%s-fold-%d", crossValidation.NamePrefix, fold+1))
+	options.Language = workspace.Language
+	options.LearningOptOut = workspace.LearningOptOut
+	options.SystemSettings = workspace.SystemSettings
+	options.Counterexamples = workspace.Counterexamples
+	training := 0
+	for _, intent := range workspace.Intents {
+		createIntent := CreateIntent{Intent: intent.Intent, Description: intent.Description}
+		for _, example := range intent.Examples {
+			if !excluded[LabeledExample{Intent: stringValue(intent.Intent), Text: stringValue(example.Text)}] {
+				createIntent.Examples = append(createIntent.Examples, example)
+			}
+		}
+		training += len(createIntent.Examples)
+		options.Intents = append(options.Intents, createIntent)
+	}
+	for _, entity := range workspace.Entities {
+		options.Entities = append(options.Entities, CreateEntity{
+			Entity:      entity.Entity,
+			Description: entity.Description,
+			Metadata:    entity.Metadata,
+			FuzzyMatch:  entity.FuzzyMatch,
+			Values:      createValues(entity.Values),
+		})
+	}
+	return options, training
+}
+
+// Run cross-validates the intents of the workspace, for example one loaded with LoadWorkspaceFiles or exported with
+// GetWorkspace. The first fold that fails stops the others and its error is returned.
+func (crossValidation *CrossValidation) Run(ctx context.Context, workspace *Workspace) (*CrossValidationResult, error) {
+	folds := crossValidation.Folds
+	if folds <= 0 {
+		folds = 5
+	}
+	concurrency := crossValidation.Concurrency
+	if concurrency <= 0 {
+		concurrency = 2
+	}
+	assigned := AssignFolds(workspace, folds, crossValidation.Seed)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &CrossValidationResult{Folds: make([]CrossValidationFold, folds)}
+	matrices := make([]*ConfusionMatrix, folds)
+	var firstErr error
+	var failed sync.Once
+	fail := func(err error) {
+		failed.Do(func() { firstErr = err })
+		cancel()
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for fold := 0; fold < folds; fold++ {
+		wg.Add(1)
+		go func(fold int) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			if ctx.Err() != nil {
+				fail(ctx.Err())
+				return
+			}
+			var err error
+			result.Folds[fold], matrices[fold], err = crossValidation.runFold(ctx, workspace, fold, assigned[fold])
+			if err != nil {
+				fail(err)
+			}
+		}(fold)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return result, firstErr
+	}
+
+	matrix := NewConfusionMatrix()
+	for _, foldMatrix := range matrices {
+		matrix.Merge(foldMatrix)
+	}
+	result.Report = matrix.Report()
+	return result, nil
+}
+
+func (crossValidation *CrossValidation) runFold(ctx context.Context, workspace *Workspace, fold int, heldOut []LabeledExample) (result CrossValidationFold, matrix *ConfusionMatrix, err error) {
+	result.Fold = fold + 1
+	options, training := crossValidation.trainingOptions(workspace, fold, heldOut)
+	result.Training = training
+
+	// A workspace created by a request that is cancelled midway would be left behind, so the workspace is created
+	// with a context of its own and the run context is checked once its ID is known.
+	created, _, err := crossValidation.Assistant.CreateWorkspaceWithContext(context.Background(), options)
+	if err != nil {
+		return result, nil, fmt.Errorf("fold %d: %s", fold+1, err.Error())
+	}
+	result.WorkspaceID = stringValue(created.WorkspaceID)
+	defer func() {
+		// The run context may already be cancelled, so cleanup gets a context of its own.
+		timeout := crossValidation.CleanupTimeout
+		if timeout <= 0 {
+			timeout = time.Minute
+		}
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		options := crossValidation.Assistant.NewDeleteWorkspaceOptions(result.WorkspaceID)
+		if deleteErr := ignoreNotFound(crossValidation.Assistant.DeleteWorkspaceWithContext(cleanupCtx, options)); deleteErr != nil && err == nil {
+			err = fmt.Errorf("fold %d: deleting workspace %s: %s", fold+1, result.WorkspaceID, deleteErr.Error())
+		}
+	}()
+	if ctx.Err() != nil {
+		return result, nil, ctx.Err()
+	}
+
+	_, err = crossValidation.Assistant.WaitForWorkspaceAvailable(ctx, result.WorkspaceID, crossValidation.PollInterval)
+	if err != nil {
+		return result, nil, fmt.Errorf("fold %d: %s", fold+1, err.Error())
+	}
+
+	classifier := crossValidation.Classifier
+	if classifier == nil {
+		classifier = crossValidation.Assistant
+	}
+	matrix, err = EvaluateIntents(ctx, classifier, result.WorkspaceID, heldOut)
+	if err != nil {
+		return result, nil, fmt.Errorf("fold %d: %s", fold+1, err.Error())
+	}
+	result.Report = matrix.Report()
+	return result, matrix, nil
+}
@@ -0,0 +1,210 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`Cross-validation`, func() {
+	var testServer *httptest.Server
+	var lock sync.Mutex
+	var live map[string]int
+	var created, deleted, maxLive int
+	var failWorkspace string
+	var onCreate func()
+
+	BeforeEach(func() {
+		live = make(map[string]int)
+		created, deleted, maxLive = 0, 0, 0
+		failWorkspace = ""
+		onCreate = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			lock.Lock()
+			defer lock.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+			switch {
+			case req.Method == http.MethodPost && req.URL.Path == "/v1/workspaces":
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				Expect(body).ToNot(HaveKey("dialog_nodes"))
+				created++
+				if onCreate != nil {
+					onCreate()
+				}
+				id := fmt.Sprintf("cv%d", created)
+				live[id] = 0
+				if len(live) > maxLive {
+					maxLive = len(live)
+				}
+				res.WriteHeader(201)
+				fmt.Fprintf(res, `{"workspace_id": %q, "name": %q, "language": "en", "learning_opt_out": false}`, id, body["name"])
+			case req.Method == http.MethodGet && len(path) == 3 && path[1] == "workspaces":
+				id := path[2]
+				live[id]++
+				status := "Training"
+				if id == failWorkspace {
+					status = "Failed"
+				} else if live[id] > 1 {
+					status = "Available"
+				}
+				fmt.Fprintf(res, `{"workspace_id": %q, "name": "x", "language": "en", "learning_opt_out": false, "status": %q}`, id, status)
+			case req.Method == http.MethodDelete && len(path) == 3 && path[1] == "workspaces":
+				delete(live, path[2])
+				deleted++
+				fmt.Fprint(res, `{}`)
+			case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/bulk_classify"):
+				var body struct {
+					Input []struct {
+						Text string `json:"text"`
+					} `json:"input"`
+				}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				var outputs []string
+				for _, input := range body.Input {
+					intent := strings.Fields(input.Text)[0]
+					outputs = append(outputs, fmt.Sprintf(`{"input": {"text": %q}, "intents": [{"intent": %q, "confidence": 0.9}]}`, input.Text, intent))
+				}
+				fmt.Fprintf(res, `{"output": [%s]}`, strings.Join(outputs, ","))
+			default:
+				res.WriteHeader(404)
+			}
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	workspace := func() *assistantv1.Workspace {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(`{"name": "pizza", "language": "en", "learning_opt_out": false,
+			"intents": [
+				{"intent": "order", "examples": [{"text": "order 1"}, {"text": "order 2"}, {"text": "order 3"}, {"text": "cancel 4"}]},
+				{"intent": "cancel", "examples": [{"text": "cancel 1"}, {"text": "cancel 2"}, {"text": "cancel 3"}]}
+			],
+			"entities": [{"entity": "size", "values": [{"value": "large", "type": "synonyms", "synonyms": ["big"]}]}],
+			"dialog_nodes": [{"dialog_node": "welcome"}]
+		}`), &raw)).To(Succeed())
+		var workspace *assistantv1.Workspace
+		Expect(assistantv1.UnmarshalWorkspace(raw, &workspace)).To(Succeed())
+		return workspace
+	}
+	newCrossValidation := func() *assistantv1.CrossValidation {
+		assistantService, err := assistantv1.NewAssistantV1(&assistantv1.AssistantV1Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(err).To(BeNil())
+		crossValidation := assistantService.NewCrossValidation(3)
+		crossValidation.PollInterval = time.Millisecond
+		return crossValidation
+	}
+
+	It(`Runs all folds within the concurrency limit and cleans up`, func() {
+		crossValidation := newCrossValidation()
+		assistantV2, err := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(err).To(BeNil())
+		crossValidation.Classifier = assistantV2
+
+		result, err := crossValidation.Run(context.Background(), workspace())
+		Expect(err).To(BeNil())
+		Expect(created).To(Equal(3))
+		Expect(deleted).To(Equal(3))
+		Expect(live).To(BeEmpty())
+		Expect(maxLive).To(BeNumerically("<=", 2))
+
+		Expect(result.Folds).To(HaveLen(3))
+		training := 0
+		for _, fold := range result.Folds {
+			training += fold.Training
+			Expect(fold.WorkspaceID).ToNot(BeEmpty())
+		}
+		Expect(training).To(Equal(2 * 7))
+		Expect(result.Report.Total).To(Equal(7))
+		Expect(result.Report.Correct).To(Equal(6))
+		Expect(result.Report.Labels).To(Equal([]string{"cancel", "order"}))
+		Expect(result.Report.Matrix).To(Equal([][]int{{3, 0}, {1, 3}}))
+
+		var buffer bytes.Buffer
+		Expect(result.WriteJSON(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring(`"accuracy"`))
+		buffer.Reset()
+		Expect(result.Report.WriteIntentsCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(HavePrefix("intent,support,predicted,true_positives,precision,recall,f1\ncancel,3,4,3,0.7500,1.0000,0.8571\n"))
+	})
+	It(`Deletes temporary workspaces when a fold fails`, func() {
+		failWorkspace = "cv1"
+		crossValidation := newCrossValidation()
+		crossValidation.Concurrency = 1
+		_, err := crossValidation.Run(context.Background(), workspace())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("workspace cv1 failed to train"))
+		Expect(created).To(Equal(1))
+		Expect(deleted).To(Equal(1))
+		Expect(live).To(BeEmpty())
+	})
+	It(`Deletes temporary workspaces when cancelled`, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		crossValidation := newCrossValidation()
+		crossValidation.PollInterval = time.Hour
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return created
+			}).Should(Equal(2))
+			cancel()
+		}()
+		_, err := crossValidation.Run(ctx, workspace())
+		Expect(err).ToNot(BeNil())
+		Expect(created).To(Equal(2))
+		Expect(deleted).To(Equal(2))
+		Expect(live).To(BeEmpty())
+	})
+	It(`Deletes a temporary workspace whose creation was cancelled`, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		onCreate = cancel
+		_, err := newCrossValidation().Run(ctx, workspace())
+		Expect(err).To(Equal(context.Canceled))
+		Expect(created).ToNot(BeZero())
+		Expect(deleted).To(Equal(created))
+		Expect(live).To(BeEmpty())
+	})
+})
@@ -0,0 +1,436 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Constants associated with the DialogIssue.Code property.
+const (
+	DialogIssueMissingIDConst                = "missing_id"
+	DialogIssueDuplicateNodeConst            = "duplicate_node"
+	DialogIssueDanglingParentConst           = "dangling_parent"
+	DialogIssueDanglingPreviousSiblingConst  = "dangling_previous_sibling"
+	DialogIssueSiblingParentMismatchConst    = "sibling_parent_mismatch"
+	DialogIssueDuplicatePreviousSiblingConst = "duplicate_previous_sibling"
+	DialogIssueSiblingCycleConst             = "sibling_cycle"
+	DialogIssueParentCycleConst              = "parent_cycle"
+	DialogIssueUnreachableNodeConst          = "unreachable_node"
+	DialogIssueSlotWithoutVariableConst      = "slot_without_variable"
+	DialogIssueJumpWithoutTargetConst        = "jump_to_without_target"
+	DialogIssueMissingJumpTargetConst        = "missing_jump_to_target"
+)
+
+// DialogIssue : A structural problem found in a dialog.
+type DialogIssue struct {
+	// The kind of problem.
+	Code string `json:"code"`
+
+	// The ID of the dialog node with the problem.
+	DialogNode string `json:"dialog_node"`
+
+	// The ID of the other dialog node involved, such as a missing parent or jump target.
+	Related string `json:"related,omitempty"`
+
+	// A description of the problem.
+	Message string `json:"message"`
+}
+
+func (issue DialogIssue) String() string {
+	return fmt.Sprintf("%s: %s", issue.DialogNode, issue.Message)
+}
+
+// DialogTreeNode : A dialog node with its position in the dialog tree.
+type DialogTreeNode struct {
+	Node *DialogNode
+
+	// The parent node, or nil for root nodes and nodes whose parent does not exist.
+	Parent *DialogTreeNode
+
+	// The child nodes in sibling order.
+	Children []*DialogTreeNode
+
+	// Whether the node can be reached from the root of the dialog.
+	Reachable bool
+}
+
+// ID returns the dialog node ID.
+func (treeNode *DialogTreeNode) ID() string {
+	return stringValue(treeNode.Node.DialogNode)
+}
+
+// Label returns the title of the node, falling back to its ID.
+func (treeNode *DialogTreeNode) Label() string {
+	if title := stringValue(treeNode.Node.Title); title != "" {
+		return title
+	}
+	return treeNode.ID()
+}
+
+// DialogTree : The dialog of a workspace, built from the Parent, PreviousSibling and NextStep references of its dialog
+// nodes, together with the structural problems found while building it.
+type DialogTree struct {
+	// The root nodes in sibling order.
+	Roots []*DialogTreeNode
+
+	// All nodes in the order they were given, keyed by ID in Nodes.
+	Ordered []*DialogTreeNode
+	Nodes   map[string]*DialogTreeNode
+
+	Issues []DialogIssue
+}
+
+// HasIssues reports whether any structural problems were found.
+func (tree *DialogTree) HasIssues() bool {
+	return len(tree.Issues) > 0
+}
+
+// IssuesFor returns the problems found for a dialog node.
+func (tree *DialogTree) IssuesFor(dialogNode string) []DialogIssue {
+	var issues []DialogIssue
+	for _, issue := range tree.Issues {
+		if issue.DialogNode == dialogNode {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (tree *DialogTree) addIssue(code string, dialogNode string, related string, format string, args ...interface{}) {
+	tree.Issues = append(tree.Issues, DialogIssue{
+		Code:       code,
+		DialogNode: dialogNode,
+		Related:    related,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// AnalyzeDialogNodes builds the dialog tree of a workspace offline and reports dangling references, sibling and
+// parent cycles, unreachable nodes, slots without a variable and jump_to steps whose target does not exist.
+func AnalyzeDialogNodes(dialogNodes []DialogNode) *DialogTree {
+	tree := &DialogTree{Nodes: make(map[string]*DialogTreeNode)}
+	for i := range dialogNodes {
+		treeNode := &DialogTreeNode{Node: &dialogNodes[i]}
+		id := treeNode.ID()
+		if id == "" {
+			tree.addIssue(DialogIssueMissingIDConst, "", "", "dialog node at index %d has no ID", i)
+			continue
+		}
+		if _, exists := tree.Nodes[id]; exists {
+			tree.addIssue(DialogIssueDuplicateNodeConst, id, "", "dialog node ID is used more than once")
+			continue
+		}
+		tree.Nodes[id] = treeNode
+		tree.Ordered = append(tree.Ordered, treeNode)
+	}
+
+	tree.linkParents()
+	tree.orderSiblings()
+	tree.checkNodes()
+	tree.markReachable()
+	return tree
+}
+
+func (tree *DialogTree) linkParents() {
+	for _, treeNode := range tree.Ordered {
+		parent := stringValue(treeNode.Node.Parent)
+		if parent == "" {
+			continue
+		}
+		if parentNode, ok := tree.Nodes[parent]; ok {
+			treeNode.Parent = parentNode
+		} else {
+			tree.addIssue(DialogIssueDanglingParentConst, treeNode.ID(), parent, "parent %q does not exist", parent)
+		}
+	}
+
+	// A node whose ancestors loop back to it is never attached to the root.
+	reported := make(map[*DialogTreeNode]bool)
+	for _, treeNode := range tree.Ordered {
+		visited := make(map[*DialogTreeNode]bool)
+		for ancestor := treeNode; ancestor != nil; ancestor = ancestor.Parent {
+			if visited[ancestor] {
+				if !reported[ancestor] {
+					cycle := []string{}
+					for member := ancestor; !reported[member]; member = member.Parent {
+						reported[member] = true
+						cycle = append(cycle, member.ID())
+					}
+					tree.addIssue(DialogIssueParentCycleConst, ancestor.ID(), "", "parent references form a cycle: %s", strings.Join(cycle, " -> "))
+				}
+				break
+			}
+			visited[ancestor] = true
+		}
+	}
+	for _, treeNode := range tree.Ordered {
+		if reported[treeNode] {
+			treeNode.Parent = nil
+		}
+	}
+}
+
+func (tree *DialogTree) orderSiblings() {
+	groups := make(map[*DialogTreeNode][]*DialogTreeNode)
+	var parents []*DialogTreeNode
+	for _, treeNode := range tree.Ordered {
+		if stringValue(treeNode.Node.Parent) != "" && treeNode.Parent == nil {
+			continue
+		}
+		if _, ok := groups[treeNode.Parent]; !ok {
+			parents = append(parents, treeNode.Parent)
+		}
+		groups[treeNode.Parent] = append(groups[treeNode.Parent], treeNode)
+	}
+
+	for _, parent := range parents {
+		siblings := groups[parent]
+		next := make(map[string]*DialogTreeNode)
+		var first *DialogTreeNode
+		for _, sibling := range siblings {
+			previous := stringValue(sibling.Node.PreviousSibling)
+			if previous == "" {
+				if first == nil {
+					first = sibling
+				} else {
+					tree.addIssue(DialogIssueDuplicatePreviousSiblingConst, sibling.ID(), first.ID(), "both %q and %q are the first sibling", first.ID(), sibling.ID())
+				}
+				continue
+			}
+			previousNode, ok := tree.Nodes[previous]
+			if !ok {
+				tree.addIssue(DialogIssueDanglingPreviousSiblingConst, sibling.ID(), previous, "previous sibling %q does not exist", previous)
+				continue
+			}
+			if previousNode.Parent != parent || stringValue(previousNode.Node.Parent) != stringValue(sibling.Node.Parent) {
+				tree.addIssue(DialogIssueSiblingParentMismatchConst, sibling.ID(), previous, "previous sibling %q has a different parent", previous)
+				continue
+			}
+			if other, ok := next[previous]; ok {
+				tree.addIssue(DialogIssueDuplicatePreviousSiblingConst, sibling.ID(), previous, "%q and %q have the same previous sibling %q", other.ID(), sibling.ID(), previous)
+				continue
+			}
+			next[previous] = sibling
+		}
+
+		var ordered []*DialogTreeNode
+		placed := make(map[*DialogTreeNode]bool)
+		for sibling := first; sibling != nil && !placed[sibling]; sibling = next[sibling.ID()] {
+			placed[sibling] = true
+			ordered = append(ordered, sibling)
+		}
+
+		// Siblings left over are either after a broken reference or part of a chain that loops back on itself.
+		for _, sibling := range siblings {
+			if placed[sibling] {
+				continue
+			}
+			chain := []*DialogTreeNode{}
+			onChain := make(map[*DialogTreeNode]bool)
+			member := sibling
+			for member != nil && !placed[member] && !onChain[member] {
+				onChain[member] = true
+				chain = append(chain, member)
+				member = next[member.ID()]
+			}
+			if member != nil && onChain[member] {
+				var cycle []string
+				for _, m := range chain {
+					cycle = append(cycle, m.ID())
+				}
+				tree.addIssue(DialogIssueSiblingCycleConst, sibling.ID(), "", "previous_sibling references form a cycle: %s", strings.Join(cycle, " -> "))
+			}
+			for _, m := range chain {
+				placed[m] = true
+				ordered = append(ordered, m)
+			}
+		}
+
+		if parent == nil {
+			tree.Roots = ordered
+		} else {
+			parent.Children = ordered
+		}
+	}
+}
+
+func (tree *DialogTree) checkNodes() {
+	for _, treeNode := range tree.Ordered {
+		node := treeNode.Node
+		if stringValue(node.Type) == DialogNodeTypeSlotConst && stringValue(node.Variable) == "" {
+			tree.addIssue(DialogIssueSlotWithoutVariableConst, treeNode.ID(), "", "slot has no variable")
+		}
+		if node.NextStep != nil && stringValue(node.NextStep.Behavior) == DialogNodeNextStepBehaviorJumpToConst {
+			target := stringValue(node.NextStep.DialogNode)
+			if target == "" {
+				tree.addIssue(DialogIssueJumpWithoutTargetConst, treeNode.ID(), "", "jump_to has no target dialog node")
+			} else if _, ok := tree.Nodes[target]; !ok {
+				tree.addIssue(DialogIssueMissingJumpTargetConst, treeNode.ID(), target, "jump_to target %q does not exist", target)
+			}
+		}
+	}
+}
+
+// markReachable walks the dialog the way it is evaluated: root nodes are always evaluated, the children of a node are
+// evaluated unless the node jumps elsewhere (frames, slots and folders always process their children), and jump_to
+// targets are evaluated when the jumping node is.
+func (tree *DialogTree) markReachable() {
+	queue := append([]*DialogTreeNode{}, tree.Roots...)
+	for len(queue) > 0 {
+		treeNode := queue[0]
+		queue = queue[1:]
+		if treeNode.Reachable {
+			continue
+		}
+		treeNode.Reachable = true
+
+		node := treeNode.Node
+		jumps := node.NextStep != nil && stringValue(node.NextStep.Behavior) == DialogNodeNextStepBehaviorJumpToConst
+		switch stringValue(node.Type) {
+		case DialogNodeTypeFrameConst, DialogNodeTypeSlotConst, DialogNodeTypeFolderConst:
+			jumps = false
+		}
+		if !jumps {
+			queue = append(queue, treeNode.Children...)
+		}
+		if node.NextStep != nil && node.NextStep.DialogNode != nil {
+			if target, ok := tree.Nodes[*node.NextStep.DialogNode]; ok {
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	for _, treeNode := range tree.Ordered {
+		if !treeNode.Reachable {
+			tree.addIssue(DialogIssueUnreachableNodeConst, treeNode.ID(), "", "dialog node can never be reached")
+		}
+	}
+}
+
+func (tree *DialogTree) jumpTargets() []*DialogTreeNode {
+	var jumping []*DialogTreeNode
+	for _, treeNode := range tree.Ordered {
+		if next := treeNode.Node.NextStep; next != nil && next.DialogNode != nil {
+			if _, ok := tree.Nodes[*next.DialogNode]; ok {
+				jumping = append(jumping, treeNode)
+			}
+		}
+	}
+	return jumping
+}
+
+func (tree *DialogTree) walk(visit func(treeNode *DialogTreeNode)) {
+	var walkNodes func(nodes []*DialogTreeNode)
+	walkNodes = func(nodes []*DialogTreeNode) {
+		for _, treeNode := range nodes {
+			visit(treeNode)
+			walkNodes(treeNode.Children)
+		}
+	}
+	walkNodes(tree.Roots)
+
+	// Nodes detached from the tree by dangling or cyclic parents are still drawn.
+	var detached []*DialogTreeNode
+	for _, treeNode := range tree.Ordered {
+		if treeNode.Parent == nil && stringValue(treeNode.Node.Parent) != "" {
+			detached = append(detached, treeNode)
+		}
+	}
+	sort.SliceStable(detached, func(i, j int) bool { return detached[i].ID() < detached[j].ID() })
+	walkNodes(detached)
+}
+
+func (tree *DialogTree) diagramLabel(treeNode *DialogTreeNode) string {
+	label := treeNode.Label()
+	if conditions := stringValue(treeNode.Node.Conditions); conditions != "" {
+		label += "\n" + conditions
+	}
+	return label
+}
+
+// WriteDOT writes the dialog as a Graphviz DOT graph. Solid edges lead from parents to children, dashed edges show
+// jump_to steps, and unreachable nodes are drawn dashed.
+func (tree *DialogTree) WriteDOT(w io.Writer) error {
+	quote := func(s string) string {
+		s = strings.Replace(s, `\`, `\\`, -1)
+		s = strings.Replace(s, `"`, `\"`, -1)
+		return `"` + strings.Replace(s, "\n", `\n`, -1) + `"`
+	}
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "digraph dialog {")
+	fmt.Fprintln(writer, "  node [shape=box];")
+	tree.walk(func(treeNode *DialogTreeNode) {
+		attributes := "label=" + quote(tree.diagramLabel(treeNode))
+		if !treeNode.Reachable {
+			attributes += ", style=dashed"
+		}
+		fmt.Fprintf(writer, "  %s [%s];\n", quote(treeNode.ID()), attributes)
+	})
+	tree.walk(func(treeNode *DialogTreeNode) {
+		for _, child := range treeNode.Children {
+			fmt.Fprintf(writer, "  %s -> %s;\n", quote(treeNode.ID()), quote(child.ID()))
+		}
+	})
+	for _, treeNode := range tree.jumpTargets() {
+		fmt.Fprintf(writer, "  %s -> %s [style=dashed, label=%s];\n",
+			quote(treeNode.ID()), quote(*treeNode.Node.NextStep.DialogNode), quote(stringValue(treeNode.Node.NextStep.Behavior)))
+	}
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
+
+// WriteMermaid writes the dialog as a Mermaid flowchart, with the same conventions as WriteDOT.
+func (tree *DialogTree) WriteMermaid(w io.Writer) error {
+	ids := make(map[string]string)
+	for i, treeNode := range tree.Ordered {
+		ids[treeNode.ID()] = fmt.Sprintf("n%d", i)
+	}
+	quote := func(s string) string {
+		s = strings.Replace(s, `"`, "#quot;", -1)
+		return `"` + strings.Replace(s, "\n", "<br/>", -1) + `"`
+	}
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "flowchart TD")
+	var unreachable []string
+	tree.walk(func(treeNode *DialogTreeNode) {
+		fmt.Fprintf(writer, "  %s[%s]\n", ids[treeNode.ID()], quote(tree.diagramLabel(treeNode)))
+		if !treeNode.Reachable {
+			unreachable = append(unreachable, ids[treeNode.ID()])
+		}
+	})
+	tree.walk(func(treeNode *DialogTreeNode) {
+		for _, child := range treeNode.Children {
+			fmt.Fprintf(writer, "  %s --> %s\n", ids[treeNode.ID()], ids[child.ID()])
+		}
+	})
+	for _, treeNode := range tree.jumpTargets() {
+		fmt.Fprintf(writer, "  %s -. %s .-> %s\n",
+			ids[treeNode.ID()], stringValue(treeNode.Node.NextStep.Behavior), ids[*treeNode.Node.NextStep.DialogNode])
+	}
+	if len(unreachable) > 0 {
+		fmt.Fprintln(writer, "  classDef unreachable stroke-dasharray: 5 5")
+		fmt.Fprintf(writer, "  class %s unreachable\n", strings.Join(unreachable, ","))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,116 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`Dialog analyzer`, func() {
+	unmarshalDialogNodes := func(data string) []assistantv1.DialogNode {
+		var raw []json.RawMessage
+		Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+		nodes := make([]assistantv1.DialogNode, len(raw))
+		for i := range raw {
+			var rawNode map[string]json.RawMessage
+			Expect(json.Unmarshal(raw[i], &rawNode)).To(Succeed())
+			var node *assistantv1.DialogNode
+			Expect(assistantv1.UnmarshalDialogNode(rawNode, &node)).To(Succeed())
+			nodes[i] = *node
+		}
+		return nodes
+	}
+	issueCodes := func(tree *assistantv1.DialogTree) map[string]string {
+		codes := make(map[string]string)
+		for _, issue := range tree.Issues {
+			codes[issue.DialogNode+" "+issue.Code] = issue.Related
+		}
+		return codes
+	}
+
+	It(`Builds the tree in sibling order`, func() {
+		tree := assistantv1.AnalyzeDialogNodes(unmarshalDialogNodes(`[
+			{"dialog_node": "anything_else", "conditions": "anything_else", "previous_sibling": "order"},
+			{"dialog_node": "welcome", "title": "Welcome", "conditions": "welcome"},
+			{"dialog_node": "order", "conditions": "#order", "previous_sibling": "welcome", "type": "frame"},
+			{"dialog_node": "size", "parent": "order", "type": "slot", "variable": "$size"},
+			{"dialog_node": "crust", "parent": "order", "type": "slot", "variable": "$crust", "previous_sibling": "size"}
+		]`))
+		Expect(tree.Issues).To(BeEmpty())
+		Expect(tree.Roots).To(HaveLen(3))
+		Expect(tree.Roots[0].Label()).To(Equal("Welcome"))
+		Expect(tree.Roots[1].ID()).To(Equal("order"))
+		Expect(tree.Roots[1].Children[1].ID()).To(Equal("crust"))
+		Expect(tree.Roots[2].ID()).To(Equal("anything_else"))
+	})
+	It(`Reports structural problems`, func() {
+		tree := assistantv1.AnalyzeDialogNodes(unmarshalDialogNodes(`[
+			{"dialog_node": "welcome", "conditions": "welcome", "next_step": {"behavior": "jump_to", "dialog_node": "gone", "selector": "body"}},
+			{"dialog_node": "orphan", "parent": "missing"},
+			{"dialog_node": "a", "previous_sibling": "b", "parent": "welcome"},
+			{"dialog_node": "b", "previous_sibling": "a", "parent": "welcome"},
+			{"dialog_node": "p", "parent": "q"},
+			{"dialog_node": "q", "parent": "p"},
+			{"dialog_node": "slot", "type": "slot", "previous_sibling": "welcome"},
+			{"dialog_node": "jumper", "previous_sibling": "slot", "next_step": {"behavior": "jump_to"}},
+			{"dialog_node": "dangling", "previous_sibling": "nowhere"}
+		]`))
+		codes := issueCodes(tree)
+		Expect(codes).To(HaveKeyWithValue("welcome missing_jump_to_target", "gone"))
+		Expect(codes).To(HaveKeyWithValue("orphan dangling_parent", "missing"))
+		Expect(codes).To(HaveKey("a sibling_cycle"))
+		Expect(codes).To(HaveKey("p parent_cycle"))
+		Expect(codes).To(HaveKey("slot slot_without_variable"))
+		Expect(codes).To(HaveKey("jumper jump_to_without_target"))
+		Expect(codes).To(HaveKeyWithValue("dangling dangling_previous_sibling", "nowhere"))
+		for _, id := range []string{"orphan", "a", "b", "p", "q"} {
+			Expect(codes).To(HaveKey(id + " unreachable_node"))
+		}
+		Expect(codes).ToNot(HaveKey("dangling unreachable_node"))
+		Expect(tree.IssuesFor("welcome")).To(HaveLen(1))
+	})
+	It(`Exports DOT and Mermaid diagrams`, func() {
+		tree := assistantv1.AnalyzeDialogNodes(unmarshalDialogNodes(`[
+			{"dialog_node": "welcome", "title": "Say \"hi\"", "conditions": "welcome"},
+			{"dialog_node": "child", "parent": "welcome", "next_step": {"behavior": "jump_to", "dialog_node": "welcome", "selector": "body"}}
+		]`))
+		Expect(tree.HasIssues()).To(BeFalse())
+
+		var dot bytes.Buffer
+		Expect(tree.WriteDOT(&dot)).To(Succeed())
+		Expect(dot.String()).To(Equal("digraph dialog {\n" +
+			"  node [shape=box];\n" +
+			"  \"welcome\" [label=\"Say \\\"hi\\\"\\nwelcome\"];\n" +
+			"  \"child\" [label=\"child\"];\n" +
+			"  \"welcome\" -> \"child\";\n" +
+			"  \"child\" -> \"welcome\" [style=dashed, label=\"jump_to\"];\n" +
+			"}\n"))
+
+		var mermaid bytes.Buffer
+		Expect(tree.WriteMermaid(&mermaid)).To(Succeed())
+		Expect(mermaid.String()).To(Equal("flowchart TD\n" +
+			"  n0[\"Say #quot;hi#quot;<br/>welcome\"]\n" +
+			"  n1[\"child\"]\n" +
+			"  n0 --> n1\n" +
+			"  n1 -. jump_to .-> n0\n"))
+	})
+})
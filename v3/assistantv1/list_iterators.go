@@ -0,0 +1,508 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// ErrIteratorDone is returned by the Next method of an iterator when there are no more items.
+var ErrIteratorDone = errors.New("no more items in iterator")
+
+// Pager : Follows the cursors of a paginated list operation. It is embedded in every iterator and holds the settings
+// that control retries and checkpoints.
+//
+// Rate-limited (429) and unavailable (503) responses are retried with exponential backoff, honouring the Retry-After
+// header when the service sends one.
+type Pager struct {
+	// The maximum number of times a page request is retried. Defaults to 5.
+	MaxRetries int
+
+	// The delay before the first retry, doubled for every subsequent retry. Defaults to 1 second.
+	InitialBackoff time.Duration
+
+	// The upper bound of the delay between retries. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+
+	// If set, called with the cursor of the next page when Next moves past the last item of a page. Persist the cursor
+	// and set it as the Cursor of the list options to resume iteration after a restart.
+	Checkpoint func(cursor string) error
+
+	fetch      func(ctx context.Context, cursor *string) (nextCursor *string, count int, response *core.DetailedResponse, err error)
+	cursor     *string
+	nextCursor *string
+	index      int
+	size       int
+	fetched    bool
+	done       bool
+}
+
+func newPager(cursor *string, fetch func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error)) *Pager {
+	return &Pager{
+		MaxRetries:     5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		fetch:          fetch,
+		nextCursor:     cursor,
+	}
+}
+
+// Cursor returns the cursor of the page currently being returned by Next, or an empty string for the first page.
+func (pager *Pager) Cursor() string {
+	if pager.cursor == nil {
+		return ""
+	}
+	return *pager.cursor
+}
+
+// advance returns the index of the next item within the current page, fetching pages as needed.
+func (pager *Pager) advance(ctx context.Context) (int, error) {
+	for pager.index >= pager.size {
+		if pager.fetched && pager.Checkpoint != nil && !pager.done {
+			err := pager.Checkpoint(*pager.nextCursor)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if pager.done {
+			return 0, ErrIteratorDone
+		}
+
+		cursor := pager.nextCursor
+		nextCursor, count, err := pager.fetchWithRetries(ctx, cursor)
+		if err != nil {
+			return 0, err
+		}
+		pager.cursor = cursor
+		pager.nextCursor = nextCursor
+		pager.index = 0
+		pager.size = count
+		pager.fetched = true
+		pager.done = nextCursor == nil || *nextCursor == ""
+	}
+	pager.index++
+	return pager.index - 1, nil
+}
+
+func (pager *Pager) fetchWithRetries(ctx context.Context, cursor *string) (*string, int, error) {
+	backoff := pager.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		nextCursor, count, response, err := pager.fetch(ctx, cursor)
+		if err == nil {
+			return nextCursor, count, nil
+		}
+		if response == nil || attempt >= pager.MaxRetries ||
+			(response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusServiceUnavailable) {
+			return nil, 0, err
+		}
+
+		delay := backoff
+		if seconds, convErr := strconv.Atoi(response.GetHeaders().Get("Retry-After")); convErr == nil && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		if pager.MaxBackoff > 0 && delay > pager.MaxBackoff {
+			delay = pager.MaxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+// LogsIterator : Iterates over log events across all pages of results.
+type LogsIterator struct {
+	*Pager
+	page []Log
+}
+
+// Next returns the next Log, or ErrIteratorDone when there are no more.
+func (iterator *LogsIterator) Next(ctx context.Context) (*Log, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewLogsIterator : Instantiate LogsIterator for the ListLogs operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewLogsIterator(listLogsOptions *ListLogsOptions) *LogsIterator {
+	options := ListLogsOptions{}
+	if listLogsOptions != nil {
+		options = *listLogsOptions
+	}
+	iterator := &LogsIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListLogsWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Logs
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Logs), response, nil
+	})
+	return iterator
+}
+
+// NewAllLogsIterator : Instantiate LogsIterator for the ListAllLogs operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewAllLogsIterator(listAllLogsOptions *ListAllLogsOptions) *LogsIterator {
+	options := ListAllLogsOptions{}
+	if listAllLogsOptions != nil {
+		options = *listAllLogsOptions
+	}
+	iterator := &LogsIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListAllLogsWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Logs
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Logs), response, nil
+	})
+	return iterator
+}
+
+// WorkspacesIterator : Iterates over workspaces across all pages of results.
+type WorkspacesIterator struct {
+	*Pager
+	page []Workspace
+}
+
+// Next returns the next Workspace, or ErrIteratorDone when there are no more.
+func (iterator *WorkspacesIterator) Next(ctx context.Context) (*Workspace, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewWorkspacesIterator : Instantiate WorkspacesIterator for the ListWorkspaces operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewWorkspacesIterator(listWorkspacesOptions *ListWorkspacesOptions) *WorkspacesIterator {
+	options := ListWorkspacesOptions{}
+	if listWorkspacesOptions != nil {
+		options = *listWorkspacesOptions
+	}
+	iterator := &WorkspacesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListWorkspacesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Workspaces
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Workspaces), response, nil
+	})
+	return iterator
+}
+
+// IntentsIterator : Iterates over the intents of a workspace across all pages of results.
+type IntentsIterator struct {
+	*Pager
+	page []Intent
+}
+
+// Next returns the next Intent, or ErrIteratorDone when there are no more.
+func (iterator *IntentsIterator) Next(ctx context.Context) (*Intent, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewIntentsIterator : Instantiate IntentsIterator for the ListIntents operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewIntentsIterator(listIntentsOptions *ListIntentsOptions) *IntentsIterator {
+	options := ListIntentsOptions{}
+	if listIntentsOptions != nil {
+		options = *listIntentsOptions
+	}
+	iterator := &IntentsIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListIntentsWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Intents
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Intents), response, nil
+	})
+	return iterator
+}
+
+// ExamplesIterator : Iterates over the user input examples of an intent across all pages of results.
+type ExamplesIterator struct {
+	*Pager
+	page []Example
+}
+
+// Next returns the next Example, or ErrIteratorDone when there are no more.
+func (iterator *ExamplesIterator) Next(ctx context.Context) (*Example, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewExamplesIterator : Instantiate ExamplesIterator for the ListExamples operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewExamplesIterator(listExamplesOptions *ListExamplesOptions) *ExamplesIterator {
+	options := ListExamplesOptions{}
+	if listExamplesOptions != nil {
+		options = *listExamplesOptions
+	}
+	iterator := &ExamplesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListExamplesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Examples
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Examples), response, nil
+	})
+	return iterator
+}
+
+// CounterexamplesIterator : Iterates over the counterexamples of a workspace across all pages of results.
+type CounterexamplesIterator struct {
+	*Pager
+	page []Counterexample
+}
+
+// Next returns the next Counterexample, or ErrIteratorDone when there are no more.
+func (iterator *CounterexamplesIterator) Next(ctx context.Context) (*Counterexample, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewCounterexamplesIterator : Instantiate CounterexamplesIterator for the ListCounterexamples operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewCounterexamplesIterator(listCounterexamplesOptions *ListCounterexamplesOptions) *CounterexamplesIterator {
+	options := ListCounterexamplesOptions{}
+	if listCounterexamplesOptions != nil {
+		options = *listCounterexamplesOptions
+	}
+	iterator := &CounterexamplesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListCounterexamplesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Counterexamples
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Counterexamples), response, nil
+	})
+	return iterator
+}
+
+// EntitiesIterator : Iterates over the entities of a workspace across all pages of results.
+type EntitiesIterator struct {
+	*Pager
+	page []Entity
+}
+
+// Next returns the next Entity, or ErrIteratorDone when there are no more.
+func (iterator *EntitiesIterator) Next(ctx context.Context) (*Entity, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewEntitiesIterator : Instantiate EntitiesIterator for the ListEntities operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewEntitiesIterator(listEntitiesOptions *ListEntitiesOptions) *EntitiesIterator {
+	options := ListEntitiesOptions{}
+	if listEntitiesOptions != nil {
+		options = *listEntitiesOptions
+	}
+	iterator := &EntitiesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListEntitiesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Entities
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Entities), response, nil
+	})
+	return iterator
+}
+
+// ValuesIterator : Iterates over the values of an entity across all pages of results.
+type ValuesIterator struct {
+	*Pager
+	page []Value
+}
+
+// Next returns the next Value, or ErrIteratorDone when there are no more.
+func (iterator *ValuesIterator) Next(ctx context.Context) (*Value, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewValuesIterator : Instantiate ValuesIterator for the ListValues operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewValuesIterator(listValuesOptions *ListValuesOptions) *ValuesIterator {
+	options := ListValuesOptions{}
+	if listValuesOptions != nil {
+		options = *listValuesOptions
+	}
+	iterator := &ValuesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListValuesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Values
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Values), response, nil
+	})
+	return iterator
+}
+
+// SynonymsIterator : Iterates over the synonyms of an entity value across all pages of results.
+type SynonymsIterator struct {
+	*Pager
+	page []Synonym
+}
+
+// Next returns the next Synonym, or ErrIteratorDone when there are no more.
+func (iterator *SynonymsIterator) Next(ctx context.Context) (*Synonym, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewSynonymsIterator : Instantiate SynonymsIterator for the ListSynonyms operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewSynonymsIterator(listSynonymsOptions *ListSynonymsOptions) *SynonymsIterator {
+	options := ListSynonymsOptions{}
+	if listSynonymsOptions != nil {
+		options = *listSynonymsOptions
+	}
+	iterator := &SynonymsIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListSynonymsWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.Synonyms
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.Synonyms), response, nil
+	})
+	return iterator
+}
+
+// DialogNodesIterator : Iterates over the dialog nodes of a workspace across all pages of results.
+type DialogNodesIterator struct {
+	*Pager
+	page []DialogNode
+}
+
+// Next returns the next DialogNode, or ErrIteratorDone when there are no more.
+func (iterator *DialogNodesIterator) Next(ctx context.Context) (*DialogNode, error) {
+	i, err := iterator.advance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator.page[i], nil
+}
+
+// NewDialogNodesIterator : Instantiate DialogNodesIterator for the ListDialogNodes operation
+// Iteration starts at the cursor set in the options, if any.
+func (assistant *AssistantV1) NewDialogNodesIterator(listDialogNodesOptions *ListDialogNodesOptions) *DialogNodesIterator {
+	options := ListDialogNodesOptions{}
+	if listDialogNodesOptions != nil {
+		options = *listDialogNodesOptions
+	}
+	iterator := &DialogNodesIterator{}
+	iterator.Pager = newPager(options.Cursor, func(ctx context.Context, cursor *string) (*string, int, *core.DetailedResponse, error) {
+		options.Cursor = cursor
+		result, response, err := assistant.ListDialogNodesWithContext(ctx, &options)
+		if err != nil {
+			return nil, 0, response, err
+		}
+		iterator.page = result.DialogNodes
+		var nextCursor *string
+		if result.Pagination != nil {
+			nextCursor = result.Pagination.NextCursor
+		}
+		return nextCursor, len(result.DialogNodes), response, nil
+	})
+	return iterator
+}
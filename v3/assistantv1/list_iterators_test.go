@@ -0,0 +1,145 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`List iterators`, func() {
+	var testServer *httptest.Server
+	var requests int
+	var rateLimited bool
+
+	BeforeEach(func() {
+		requests = 0
+		rateLimited = false
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			requests++
+			res.Header().Set("Content-type", "application/json")
+
+			cursor := req.URL.Query().Get("cursor")
+			if cursor == "page2" && !rateLimited {
+				rateLimited = true
+				res.WriteHeader(429)
+				fmt.Fprintf(res, `{"error": "Too many requests", "code": 429}`)
+				return
+			}
+
+			switch req.URL.Path {
+			case "/v1/workspaces/ws1/logs":
+				switch cursor {
+				case "":
+					fmt.Fprintf(res, `{"logs": [{"log_id": "1"}, {"log_id": "2"}], "pagination": {"next_cursor": "page2"}}`)
+				case "page2":
+					fmt.Fprintf(res, `{"logs": [], "pagination": {"next_cursor": "page3"}}`)
+				case "page3":
+					fmt.Fprintf(res, `{"logs": [{"log_id": "3"}], "pagination": {}}`)
+				}
+			case "/v1/workspaces/ws1/intents":
+				if cursor == "" {
+					fmt.Fprintf(res, `{"intents": [{"intent": "hello"}], "pagination": {"refresh_url": "x", "next_cursor": "next"}}`)
+				} else {
+					fmt.Fprintf(res, `{"intents": [{"intent": "goodbye"}], "pagination": {"refresh_url": "x"}}`)
+				}
+			default:
+				res.WriteHeader(404)
+			}
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	newService := func() *assistantv1.AssistantV1 {
+		assistantService, serviceErr := assistantv1.NewAssistantV1(&assistantv1.AssistantV1Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(serviceErr).To(BeNil())
+		return assistantService
+	}
+
+	It(`Follows cursors, retries rate-limited requests and records checkpoints`, func() {
+		assistantService := newService()
+		iterator := assistantService.NewLogsIterator(assistantService.NewListLogsOptions("ws1"))
+		iterator.InitialBackoff = time.Millisecond
+		var checkpoints []string
+		iterator.Checkpoint = func(cursor string) error {
+			checkpoints = append(checkpoints, cursor)
+			return nil
+		}
+
+		var ids []string
+		for {
+			log, err := iterator.Next(context.Background())
+			if err == assistantv1.ErrIteratorDone {
+				break
+			}
+			Expect(err).To(BeNil())
+			ids = append(ids, *log.LogID)
+		}
+		Expect(ids).To(Equal([]string{"1", "2", "3"}))
+		Expect(checkpoints).To(Equal([]string{"page2", "page3"}))
+		Expect(iterator.Cursor()).To(Equal("page3"))
+		Expect(requests).To(Equal(4))
+
+		_, err := iterator.Next(context.Background())
+		Expect(err).To(Equal(assistantv1.ErrIteratorDone))
+	})
+	It(`Resumes from a cursor`, func() {
+		assistantService := newService()
+		iterator := assistantService.NewLogsIterator(assistantService.NewListLogsOptions("ws1").SetCursor("page3"))
+		log, err := iterator.Next(context.Background())
+		Expect(err).To(BeNil())
+		Expect(*log.LogID).To(Equal("3"))
+		_, err = iterator.Next(context.Background())
+		Expect(err).To(Equal(assistantv1.ErrIteratorDone))
+	})
+	It(`Gives up when retries are exhausted`, func() {
+		assistantService := newService()
+		iterator := assistantService.NewLogsIterator(assistantService.NewListLogsOptions("ws1").SetCursor("page2"))
+		iterator.MaxRetries = 0
+		_, err := iterator.Next(context.Background())
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Iterates over intents`, func() {
+		assistantService := newService()
+		iterator := assistantService.NewIntentsIterator(assistantService.NewListIntentsOptions("ws1"))
+		var intents []string
+		for {
+			intent, err := iterator.Next(context.Background())
+			if err == assistantv1.ErrIteratorDone {
+				break
+			}
+			Expect(err).To(BeNil())
+			intents = append(intents, *intent.Intent)
+		}
+		Expect(intents).To(Equal([]string{"hello", "goodbye"}))
+	})
+})
@@ -0,0 +1,67 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`LogAnalyzer`, func() {
+	const exportedLogs = `{"logs": [
+		{"log_id": "1", "workspace_id": "ws", "request_timestamp": "2022-03-01T10:00:00.000Z",
+		 "request": {"input": {"text": "hello"}},
+		 "response": {"input": {"text": "hello"}, "intents": [{"intent": "greeting", "confidence": 0.9}], "entities": [],
+			"context": {"conversation_id": "c1"},
+			"output": {"log_messages": [], "text": ["Hi!"], "nodes_visited": ["node_welcome"],
+				"nodes_visited_details": [{"dialog_node": "node_welcome", "title": "Welcome", "conditions": "#greeting"}]}}},
+		{"log_id": "2", "workspace_id": "ws", "request_timestamp": "2022-03-01T10:00:03.000Z",
+		 "request": {"input": {"text": "weather?"}},
+		 "response": {"input": {"text": "weather?"}, "intents": [{"intent": "weather", "confidence": 0.3}], "entities": [],
+			"context": {"conversation_id": "c1"},
+			"output": {"log_messages": [], "generic": [{"response_type": "text", "text": "Sorry."}], "nodes_visited": ["node_else"],
+				"nodes_visited_details": [{"dialog_node": "node_else", "conditions": "anything_else"}]}}}
+	], "pagination": {}}`
+
+	It(`Groups logs by conversation ID and reports statistics`, func() {
+		logs, err := assistantv1.ReadLogs(strings.NewReader(exportedLogs))
+		Expect(err).To(BeNil())
+
+		analyzer := assistantv1.NewLogAnalyzer()
+		analyzer.AddLogs(logs)
+
+		transcripts := analyzer.Transcripts()
+		Expect(transcripts).To(HaveLen(1))
+		Expect(transcripts[0].ConversationID).To(Equal("c1"))
+		Expect(transcripts[0].Turns[0].ResponseTexts).To(Equal([]string{"Hi!"}))
+		Expect(transcripts[0].Turns[1].ResponseTexts).To(Equal([]string{"Sorry."}))
+
+		report := analyzer.Report()
+		Expect(report.TotalTurns).To(Equal(2))
+		Expect(report.LowConfidenceTurns).To(HaveLen(1))
+		Expect(report.AnythingElseRate).To(Equal(0.5))
+		Expect(report.DialogNodes[0].Count).To(Equal(1))
+
+		var buffer bytes.Buffer
+		Expect(report.WriteSummaryCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("anything_else_rate,0.5\n"))
+	})
+})
@@ -0,0 +1,292 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+// RuntimeResponseGenericVisitor : Receives the concrete variants of RuntimeResponseGenericIntf. VisitUnknown is called
+// for every value that has no dedicated method, such as the RuntimeResponseGeneric base model or response types added
+// to the service after this visitor was written.
+type RuntimeResponseGenericVisitor interface {
+	VisitText(*RuntimeResponseGenericRuntimeResponseTypeText) error
+	VisitOption(*RuntimeResponseGenericRuntimeResponseTypeOption) error
+	VisitImage(*RuntimeResponseGenericRuntimeResponseTypeImage) error
+	VisitPause(*RuntimeResponseGenericRuntimeResponseTypePause) error
+	VisitSuggestion(*RuntimeResponseGenericRuntimeResponseTypeSuggestion) error
+	VisitChannelTransfer(*RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error
+	VisitConnectToAgent(*RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error
+	VisitUserDefined(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) error
+	VisitAudio(*RuntimeResponseGenericRuntimeResponseTypeAudio) error
+	VisitVideo(*RuntimeResponseGenericRuntimeResponseTypeVideo) error
+	VisitIframe(*RuntimeResponseGenericRuntimeResponseTypeIframe) error
+	VisitUnknown(RuntimeResponseGenericIntf) error
+}
+
+// RuntimeResponseGenericVisitorFuncs : A RuntimeResponseGenericVisitor built from optional functions. A response type
+// without a function is passed to Default; when Default is nil as well, the response is ignored.
+//
+// The struct can also be embedded to provide the fallback behaviour for a partial visitor implementation.
+type RuntimeResponseGenericVisitorFuncs struct {
+	Text            func(*RuntimeResponseGenericRuntimeResponseTypeText) error
+	Option          func(*RuntimeResponseGenericRuntimeResponseTypeOption) error
+	Image           func(*RuntimeResponseGenericRuntimeResponseTypeImage) error
+	Pause           func(*RuntimeResponseGenericRuntimeResponseTypePause) error
+	Suggestion      func(*RuntimeResponseGenericRuntimeResponseTypeSuggestion) error
+	ChannelTransfer func(*RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error
+	ConnectToAgent  func(*RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error
+	UserDefined     func(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) error
+	Audio           func(*RuntimeResponseGenericRuntimeResponseTypeAudio) error
+	Video           func(*RuntimeResponseGenericRuntimeResponseTypeVideo) error
+	Iframe          func(*RuntimeResponseGenericRuntimeResponseTypeIframe) error
+	Default         func(RuntimeResponseGenericIntf) error
+}
+
+// VisitText calls the Text function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitText(response *RuntimeResponseGenericRuntimeResponseTypeText) error {
+	if funcs.Text != nil {
+		return funcs.Text(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitOption calls the Option function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitOption(response *RuntimeResponseGenericRuntimeResponseTypeOption) error {
+	if funcs.Option != nil {
+		return funcs.Option(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitImage calls the Image function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitImage(response *RuntimeResponseGenericRuntimeResponseTypeImage) error {
+	if funcs.Image != nil {
+		return funcs.Image(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitPause calls the Pause function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitPause(response *RuntimeResponseGenericRuntimeResponseTypePause) error {
+	if funcs.Pause != nil {
+		return funcs.Pause(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitSuggestion calls the Suggestion function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitSuggestion(response *RuntimeResponseGenericRuntimeResponseTypeSuggestion) error {
+	if funcs.Suggestion != nil {
+		return funcs.Suggestion(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitChannelTransfer calls the ChannelTransfer function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitChannelTransfer(response *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error {
+	if funcs.ChannelTransfer != nil {
+		return funcs.ChannelTransfer(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitConnectToAgent calls the ConnectToAgent function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitConnectToAgent(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error {
+	if funcs.ConnectToAgent != nil {
+		return funcs.ConnectToAgent(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitUserDefined calls the UserDefined function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitUserDefined(response *RuntimeResponseGenericRuntimeResponseTypeUserDefined) error {
+	if funcs.UserDefined != nil {
+		return funcs.UserDefined(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitAudio calls the Audio function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitAudio(response *RuntimeResponseGenericRuntimeResponseTypeAudio) error {
+	if funcs.Audio != nil {
+		return funcs.Audio(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitVideo calls the Video function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitVideo(response *RuntimeResponseGenericRuntimeResponseTypeVideo) error {
+	if funcs.Video != nil {
+		return funcs.Video(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitIframe calls the Iframe function, or Default
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitIframe(response *RuntimeResponseGenericRuntimeResponseTypeIframe) error {
+	if funcs.Iframe != nil {
+		return funcs.Iframe(response)
+	}
+	return funcs.VisitUnknown(response)
+}
+
+// VisitUnknown calls the Default function, if any
+func (funcs RuntimeResponseGenericVisitorFuncs) VisitUnknown(response RuntimeResponseGenericIntf) error {
+	if funcs.Default != nil {
+		return funcs.Default(response)
+	}
+	return nil
+}
+
+// AcceptRuntimeResponseGeneric dispatches a single response to the visitor method matching its concrete type.
+func AcceptRuntimeResponseGeneric(response RuntimeResponseGenericIntf, visitor RuntimeResponseGenericVisitor) error {
+	switch r := response.(type) {
+	case *RuntimeResponseGenericRuntimeResponseTypeText:
+		return visitor.VisitText(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeOption:
+		return visitor.VisitOption(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeImage:
+		return visitor.VisitImage(r)
+	case *RuntimeResponseGenericRuntimeResponseTypePause:
+		return visitor.VisitPause(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeSuggestion:
+		return visitor.VisitSuggestion(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer:
+		return visitor.VisitChannelTransfer(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent:
+		return visitor.VisitConnectToAgent(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeUserDefined:
+		return visitor.VisitUserDefined(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeAudio:
+		return visitor.VisitAudio(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeVideo:
+		return visitor.VisitVideo(r)
+	case *RuntimeResponseGenericRuntimeResponseTypeIframe:
+		return visitor.VisitIframe(r)
+	default:
+		return visitor.VisitUnknown(response)
+	}
+}
+
+// VisitRuntimeResponseGeneric dispatches each response to the visitor in order, stopping at the first error.
+func VisitRuntimeResponseGeneric(responses []RuntimeResponseGenericIntf, visitor RuntimeResponseGenericVisitor) error {
+	for _, response := range responses {
+		err := AcceptRuntimeResponseGeneric(response, visitor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRuntimeResponseGenericType returns the response_type of a response, or an empty string when it is not set.
+func GetRuntimeResponseGenericType(response RuntimeResponseGenericIntf) string {
+	var responseType *string
+	switch r := response.(type) {
+	case *RuntimeResponseGeneric:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeText:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeOption:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeImage:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypePause:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeSuggestion:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeUserDefined:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeAudio:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeVideo:
+		responseType = r.ResponseType
+	case *RuntimeResponseGenericRuntimeResponseTypeIframe:
+		responseType = r.ResponseType
+	}
+	if responseType == nil {
+		return ""
+	}
+	return *responseType
+}
+
+// GetRuntimeResponseGenericChannels returns the channels a response is intended for. An empty result means that the
+// response applies to every channel.
+func GetRuntimeResponseGenericChannels(response RuntimeResponseGenericIntf) []ResponseGenericChannel {
+	switch r := response.(type) {
+	case *RuntimeResponseGeneric:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeText:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeOption:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeImage:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypePause:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeSuggestion:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeUserDefined:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeAudio:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeVideo:
+		return r.Channels
+	case *RuntimeResponseGenericRuntimeResponseTypeIframe:
+		return r.Channels
+	}
+	return nil
+}
+
+// RuntimeResponseGenericAppliesToChannel reports whether a response should be rendered on the given channel (for
+// example "slack", "chat" or "voice_telephony"). Responses that do not list any channels apply to all of them.
+func RuntimeResponseGenericAppliesToChannel(response RuntimeResponseGenericIntf, channel string) bool {
+	channels := GetRuntimeResponseGenericChannels(response)
+	if len(channels) == 0 {
+		return true
+	}
+	for _, c := range channels {
+		if c.Channel != nil && *c.Channel == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterRuntimeResponseGenericByChannel returns the responses that apply to the given channel, in their original order.
+func FilterRuntimeResponseGenericByChannel(responses []RuntimeResponseGenericIntf, channel string) []RuntimeResponseGenericIntf {
+	filtered := make([]RuntimeResponseGenericIntf, 0, len(responses))
+	for _, response := range responses {
+		if RuntimeResponseGenericAppliesToChannel(response, channel) {
+			filtered = append(filtered, response)
+		}
+	}
+	return filtered
+}
+
+// VisitForChannel dispatches the generic responses of the output that apply to the given channel to the visitor.
+func (output *OutputData) VisitForChannel(channel string, visitor RuntimeResponseGenericVisitor) error {
+	if output == nil {
+		return nil
+	}
+	return VisitRuntimeResponseGeneric(FilterRuntimeResponseGenericByChannel(output.Generic, channel), visitor)
+}
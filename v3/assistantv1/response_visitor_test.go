@@ -0,0 +1,103 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+func unmarshalTestOutputData(data string) *assistantv1.OutputData {
+	var raw map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+	var output *assistantv1.OutputData
+	Expect(core.UnmarshalModel(raw, "", &output, assistantv1.UnmarshalOutputData)).To(Succeed())
+	return output
+}
+
+var _ = Describe(`RuntimeResponseGenericVisitor`, func() {
+	output := unmarshalTestOutputData(`{"log_messages": [], "generic": [
+		{"response_type": "text", "text": "Hello"},
+		{"response_type": "pause", "time": 500, "typing": true, "channels": [{"channel": "chat"}]},
+		{"response_type": "option", "title": "Pick one", "options": [{"label": "A", "value": {"input": {"text": "a"}}}]},
+		{"response_type": "text", "text": "Say it", "channels": [{"channel": "voice_telephony"}]},
+		{"response_type": "user_defined", "user_defined": {"card": "x"}}
+	]}`)
+
+	It(`Dispatches each response type to its function`, func() {
+		var visited []string
+		visitor := assistantv1.RuntimeResponseGenericVisitorFuncs{
+			Text: func(response *assistantv1.RuntimeResponseGenericRuntimeResponseTypeText) error {
+				visited = append(visited, "text:"+*response.Text)
+				return nil
+			},
+			Option: func(response *assistantv1.RuntimeResponseGenericRuntimeResponseTypeOption) error {
+				visited = append(visited, "option:"+*response.Options[0].Label)
+				return nil
+			},
+			Default: func(response assistantv1.RuntimeResponseGenericIntf) error {
+				visited = append(visited, "default:"+assistantv1.GetRuntimeResponseGenericType(response))
+				return nil
+			},
+		}
+		Expect(assistantv1.VisitRuntimeResponseGeneric(output.Generic, visitor)).To(Succeed())
+		Expect(visited).To(Equal([]string{"text:Hello", "default:pause", "option:A", "text:Say it", "default:user_defined"}))
+	})
+	It(`Passes models without a dedicated method to the default handler`, func() {
+		var unknown assistantv1.RuntimeResponseGenericIntf
+		visitor := assistantv1.RuntimeResponseGenericVisitorFuncs{
+			Default: func(response assistantv1.RuntimeResponseGenericIntf) error {
+				unknown = response
+				return nil
+			},
+		}
+		base := &assistantv1.RuntimeResponseGeneric{ResponseType: core.StringPtr("carousel")}
+		Expect(assistantv1.AcceptRuntimeResponseGeneric(base, visitor)).To(Succeed())
+		Expect(unknown).To(Equal(base))
+		Expect(assistantv1.GetRuntimeResponseGenericType(base)).To(Equal("carousel"))
+	})
+	It(`Stops at the first error`, func() {
+		calls := 0
+		visitor := assistantv1.RuntimeResponseGenericVisitorFuncs{
+			Default: func(assistantv1.RuntimeResponseGenericIntf) error {
+				calls++
+				return errors.New("stop")
+			},
+		}
+		Expect(assistantv1.VisitRuntimeResponseGeneric(output.Generic, visitor)).To(MatchError("stop"))
+		Expect(calls).To(Equal(1))
+	})
+	It(`Filters responses by channel`, func() {
+		Expect(assistantv1.FilterRuntimeResponseGenericByChannel(output.Generic, assistantv1.ResponseGenericChannelChannelSlackConst)).To(HaveLen(3))
+		Expect(assistantv1.FilterRuntimeResponseGenericByChannel(output.Generic, assistantv1.ResponseGenericChannelChannelChatConst)).To(HaveLen(4))
+
+		var texts []string
+		err := output.VisitForChannel(assistantv1.ResponseGenericChannelChannelVoiceTelephonyConst, assistantv1.RuntimeResponseGenericVisitorFuncs{
+			Text: func(response *assistantv1.RuntimeResponseGenericRuntimeResponseTypeText) error {
+				texts = append(texts, *response.Text)
+				return nil
+			},
+		})
+		Expect(err).To(BeNil())
+		Expect(texts).To(Equal([]string{"Hello", "Say it"}))
+	})
+})
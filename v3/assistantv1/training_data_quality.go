@@ -0,0 +1,655 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// Default values used by CheckTrainingData.
+const (
+	DefaultMinExamplesPerIntent   = 5
+	DefaultNearDuplicateThreshold = 0.85
+)
+
+// Constants associated with the TrainingDataIssue.Code property.
+const (
+	TrainingDataIssueDuplicateExampleConst        = "duplicate_example"
+	TrainingDataIssueNearDuplicateExampleConst    = "near_duplicate_example"
+	TrainingDataIssueCounterexampleCollisionConst = "counterexample_collision"
+	TrainingDataIssueTooFewExamplesConst          = "too_few_examples"
+	TrainingDataIssueSynonymClashConst            = "synonym_clash"
+)
+
+// TrainingDataIssue : A problem found in the training data of a workspace.
+type TrainingDataIssue struct {
+	// The kind of problem.
+	Code string `json:"code"`
+
+	// A description of the problem.
+	Message string `json:"message"`
+
+	// The intents and example texts involved, for example and intent problems.
+	Intent      string `json:"intent,omitempty"`
+	Text        string `json:"text,omitempty"`
+	OtherIntent string `json:"other_intent,omitempty"`
+	OtherText   string `json:"other_text,omitempty"`
+
+	// The token overlap of near-duplicate examples, between 0 and 1.
+	Similarity float64 `json:"similarity,omitempty"`
+
+	// The number of examples of an intent with too few examples.
+	Count int `json:"count,omitempty"`
+
+	// The clashing entity values, for synonym problems.
+	Entity      string `json:"entity,omitempty"`
+	Value       string `json:"value,omitempty"`
+	OtherEntity string `json:"other_entity,omitempty"`
+	OtherValue  string `json:"other_value,omitempty"`
+}
+
+// TrainingDataReport : The result of CheckTrainingData.
+type TrainingDataReport struct {
+	Intents         int                 `json:"intents"`
+	Examples        int                 `json:"examples"`
+	Counterexamples int                 `json:"counterexamples"`
+	Entities        int                 `json:"entities"`
+	Issues          []TrainingDataIssue `json:"issues"`
+}
+
+// IssuesWithCode returns the issues of one kind.
+func (report *TrainingDataReport) IssuesWithCode(code string) []TrainingDataIssue {
+	var issues []TrainingDataIssue
+	for _, issue := range report.Issues {
+		if issue.Code == code {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// WriteJSON writes the report as indented JSON.
+func (report *TrainingDataReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// TrainingDataCheckOptions : The thresholds used by CheckTrainingData.
+type TrainingDataCheckOptions struct {
+	// Intents with fewer examples are reported. Defaults to DefaultMinExamplesPerIntent.
+	MinExamplesPerIntent int
+
+	// Examples of different intents whose token overlap (Jaccard index) is at least this value are reported as near
+	// duplicates. Defaults to DefaultNearDuplicateThreshold; a value above 1 disables the check.
+	NearDuplicateThreshold float64
+}
+
+// normalizeTrainingText lowercases a text and reduces it to its words, so that "Pizza, please!" and "pizza please"
+// compare equal.
+func normalizeTrainingText(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '@' && r != '#'
+	})
+}
+
+func tokenSimilarity(a []string, b []string) float64 {
+	set := make(map[string]int)
+	for _, token := range a {
+		set[token] |= 1
+	}
+	for _, token := range b {
+		set[token] |= 2
+	}
+	if len(set) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, membership := range set {
+		if membership == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(set))
+}
+
+// similarTrainingTexts returns the pairs of texts, as indexes in ascending order, whose tokens are the same or overlap
+// by at least threshold, and possibly some pairs that overlap less. Comparing every pair does not scale to large
+// workspaces, so texts are bucketed by their tokens to find duplicates and, for near duplicates, an inverted index of
+// the rarest tokens of every text finds the candidates: two token sets whose Jaccard index is at least t share one of
+// the first n-ceil(t*n)+1 tokens of each set, in any fixed order.
+func similarTrainingTexts(texts [][]string, threshold float64) [][2]int {
+	var pairs [][2]int
+	keys := make([]string, len(texts))
+	buckets := make(map[string][]int)
+	for i, tokens := range texts {
+		keys[i] = strings.Join(tokens, " ")
+		for _, j := range buckets[keys[i]] {
+			pairs = append(pairs, [2]int{j, i})
+		}
+		buckets[keys[i]] = append(buckets[keys[i]], i)
+	}
+
+	if threshold <= 1 {
+		sets := make([][]string, len(texts))
+		frequency := make(map[string]int)
+		for i, tokens := range texts {
+			seen := make(map[string]bool)
+			for _, token := range tokens {
+				if !seen[token] {
+					seen[token] = true
+					sets[i] = append(sets[i], token)
+					frequency[token]++
+				}
+			}
+		}
+		index := make(map[string][]int)
+		for i, set := range sets {
+			sort.Slice(set, func(a, b int) bool {
+				if frequency[set[a]] != frequency[set[b]] {
+					return frequency[set[a]] < frequency[set[b]]
+				}
+				return set[a] < set[b]
+			})
+			// The small epsilon keeps rounding errors from shortening the prefix.
+			prefix := len(set) - int(math.Ceil(threshold*float64(len(set))-1e-9)) + 1
+			if prefix > len(set) {
+				prefix = len(set)
+			}
+			candidates := make(map[int]bool)
+			for _, token := range set[:prefix] {
+				for _, j := range index[token] {
+					candidates[j] = true
+				}
+				index[token] = append(index[token], i)
+			}
+			for j := range candidates {
+				if keys[j] != keys[i] {
+					pairs = append(pairs, [2]int{j, i})
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a][0] != pairs[b][0] {
+			return pairs[a][0] < pairs[b][0]
+		}
+		return pairs[a][1] < pairs[b][1]
+	})
+	return pairs
+}
+
+// CheckTrainingData checks the training data of a workspace, as exported or loaded from files, for duplicate and
+// near-duplicate examples across intents, examples that are also counterexamples, intents with too few examples and
+// synonyms shared by values of different entities. A nil options uses the defaults.
+func CheckTrainingData(workspace *Workspace, options *TrainingDataCheckOptions) *TrainingDataReport {
+	minExamples := DefaultMinExamplesPerIntent
+	threshold := DefaultNearDuplicateThreshold
+	if options != nil {
+		if options.MinExamplesPerIntent > 0 {
+			minExamples = options.MinExamplesPerIntent
+		}
+		if options.NearDuplicateThreshold > 0 {
+			threshold = options.NearDuplicateThreshold
+		}
+	}
+
+	report := &TrainingDataReport{
+		Intents:         len(workspace.Intents),
+		Counterexamples: len(workspace.Counterexamples),
+		Entities:        len(workspace.Entities),
+		Issues:          []TrainingDataIssue{},
+	}
+
+	type example struct {
+		intent string
+		text   string
+		key    string
+		tokens []string
+	}
+	var examples []example
+	for _, intent := range workspace.Intents {
+		name := stringValue(intent.Intent)
+		report.Examples += len(intent.Examples)
+		if len(intent.Examples) < minExamples {
+			report.Issues = append(report.Issues, TrainingDataIssue{
+				Code:    TrainingDataIssueTooFewExamplesConst,
+				Message: fmt.Sprintf("intent #%s has %d examples, at least %d are recommended", name, len(intent.Examples), minExamples),
+				Intent:  name,
+				Count:   len(intent.Examples),
+			})
+		}
+		for _, e := range intent.Examples {
+			text := stringValue(e.Text)
+			tokens := normalizeTrainingText(text)
+			examples = append(examples, example{intent: name, text: text, key: strings.Join(tokens, " "), tokens: tokens})
+		}
+	}
+
+	texts := make([][]string, len(examples))
+	for i := range examples {
+		texts[i] = examples[i].tokens
+	}
+	for _, pair := range similarTrainingTexts(texts, threshold) {
+		a, b := &examples[pair[0]], &examples[pair[1]]
+		if a.intent == b.intent {
+			continue
+		}
+		issue := TrainingDataIssue{Intent: a.intent, Text: a.text, OtherIntent: b.intent, OtherText: b.text}
+		if a.key == b.key {
+			issue.Code = TrainingDataIssueDuplicateExampleConst
+			issue.Message = fmt.Sprintf("%q in #%s duplicates %q in #%s", a.text, a.intent, b.text, b.intent)
+		} else if similarity := tokenSimilarity(a.tokens, b.tokens); similarity >= threshold {
+			issue.Code = TrainingDataIssueNearDuplicateExampleConst
+			issue.Similarity = similarity
+			issue.Message = fmt.Sprintf("%q in #%s is nearly the same as %q in #%s", a.text, a.intent, b.text, b.intent)
+		} else {
+			continue
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	counterexamples := make(map[string]string)
+	for _, counterexample := range workspace.Counterexamples {
+		text := stringValue(counterexample.Text)
+		counterexamples[strings.Join(normalizeTrainingText(text), " ")] = text
+	}
+	for _, e := range examples {
+		if counterexample, ok := counterexamples[e.key]; ok {
+			report.Issues = append(report.Issues, TrainingDataIssue{
+				Code:      TrainingDataIssueCounterexampleCollisionConst,
+				Message:   fmt.Sprintf("%q in #%s is also the counterexample %q", e.text, e.intent, counterexample),
+				Intent:    e.intent,
+				Text:      e.text,
+				OtherText: counterexample,
+			})
+		}
+	}
+
+	type entityValue struct {
+		entity string
+		value  string
+	}
+	var terms []string
+	owners := make(map[string][]entityValue)
+	for _, entity := range workspace.Entities {
+		for _, value := range entity.Values {
+			if stringValue(value.Type) == ValueTypePatternsConst {
+				continue
+			}
+			owner := entityValue{entity: stringValue(entity.Entity), value: stringValue(value.Value)}
+			for _, term := range append([]string{owner.value}, value.Synonyms...) {
+				key := strings.ToLower(strings.TrimSpace(term))
+				if _, ok := owners[key]; !ok {
+					terms = append(terms, key)
+				}
+				owners[key] = append(owners[key], owner)
+			}
+		}
+	}
+	for _, term := range terms {
+		values := owners[term]
+		// The values of an entity are added one after the other, so the values of other entities that share the term
+		// start after the last value of its entity.
+		others := make([]int, len(values))
+		for i := len(values) - 1; i >= 0; i-- {
+			others[i] = i + 1
+			if i+1 < len(values) && values[i+1].entity == values[i].entity {
+				others[i] = others[i+1]
+			}
+		}
+		for i := range values {
+			for j := others[i]; j < len(values); j++ {
+				if values[i].entity == values[j].entity {
+					continue
+				}
+				report.Issues = append(report.Issues, TrainingDataIssue{
+					Code: TrainingDataIssueSynonymClashConst,
+					Message: fmt.Sprintf("%q is used by @%s:%s and @%s:%s",
+						term, values[i].entity, values[i].value, values[j].entity, values[j].value),
+					Text:        term,
+					Entity:      values[i].entity,
+					Value:       values[i].value,
+					OtherEntity: values[j].entity,
+					OtherValue:  values[j].value,
+				})
+			}
+		}
+	}
+	return report
+}
+
+// LabeledExample : A user input together with the intent it is expected to be classified as.
+type LabeledExample struct {
+	Intent string `json:"intent"`
+	Text   string `json:"text"`
+}
+
+// WorkspaceExamples returns all intent examples of a workspace.
+func WorkspaceExamples(workspace *Workspace) []LabeledExample {
+	var examples []LabeledExample
+	for _, intent := range workspace.Intents {
+		for _, example := range intent.Examples {
+			examples = append(examples, LabeledExample{Intent: stringValue(intent.Intent), Text: stringValue(example.Text)})
+		}
+	}
+	return examples
+}
+
+// SplitHeldOut holds out a fraction of the examples of every intent for evaluation. It returns a copy of the workspace
+// without the held-out examples, and the held-out examples. The split is random but repeatable for the same seed.
+func SplitHeldOut(workspace *Workspace, fraction float64, seed int64) (*Workspace, []LabeledExample) {
+	random := rand.New(rand.NewSource(seed))
+	training := *workspace
+	training.Intents = make([]Intent, len(workspace.Intents))
+	var heldOut []LabeledExample
+	for i, intent := range workspace.Intents {
+		training.Intents[i] = intent
+		training.Intents[i].Examples = nil
+		count := int(fraction * float64(len(intent.Examples)))
+		for j, k := range random.Perm(len(intent.Examples)) {
+			if j < count {
+				heldOut = append(heldOut, LabeledExample{Intent: stringValue(intent.Intent), Text: stringValue(intent.Examples[k].Text)})
+			} else {
+				training.Intents[i].Examples = append(training.Intents[i].Examples, intent.Examples[k])
+			}
+		}
+	}
+	return &training, heldOut
+}
+
+// IntentClassifier : Classifies user inputs with the intents of a workspace. It is implemented by AssistantV1 and by
+// AssistantV2, which classifies with the dialog skill that has the workspace ID as skill ID.
+type IntentClassifier interface {
+	// ClassifyIntents returns the top intent for each text, or an empty string when no intent was recognized.
+	ClassifyIntents(ctx context.Context, workspaceID string, texts []string) ([]string, error)
+}
+
+// DefaultBulkClassifyBatchSize is the default number of utterances sent in one bulk classify request.
+const DefaultBulkClassifyBatchSize = 50
+
+// BulkIntentClassifier : An IntentClassifier that classifies texts with BulkClassify, in batches.
+type BulkIntentClassifier struct {
+	Assistant *AssistantV1
+
+	// The number of utterances sent in one request. Defaults to DefaultBulkClassifyBatchSize.
+	BatchSize int
+}
+
+// NewBulkIntentClassifier : Instantiate BulkIntentClassifier
+func (assistant *AssistantV1) NewBulkIntentClassifier() *BulkIntentClassifier {
+	return &BulkIntentClassifier{Assistant: assistant, BatchSize: DefaultBulkClassifyBatchSize}
+}
+
+// ClassifyIntents classifies texts with BulkClassify, in batches of DefaultBulkClassifyBatchSize, and returns the top
+// intent of each one.
+func (assistant *AssistantV1) ClassifyIntents(ctx context.Context, workspaceID string, texts []string) ([]string, error) {
+	return assistant.NewBulkIntentClassifier().ClassifyIntents(ctx, workspaceID, texts)
+}
+
+// ClassifyIntents classifies texts in batches of BatchSize and returns the top intent of each one.
+func (classifier *BulkIntentClassifier) ClassifyIntents(ctx context.Context, workspaceID string, texts []string) ([]string, error) {
+	batchSize := classifier.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBulkClassifyBatchSize
+	}
+	intents := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		options := classifier.Assistant.NewBulkClassifyOptions(workspaceID)
+		for _, text := range texts[start:end] {
+			options.Input = append(options.Input, BulkClassifyUtterance{Text: core.StringPtr(text)})
+		}
+		result, _, err := classifier.Assistant.BulkClassifyWithContext(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Output) != end-start {
+			return nil, fmt.Errorf("bulk classify returned %d results for %d inputs", len(result.Output), end-start)
+		}
+		for _, output := range result.Output {
+			intent := ""
+			if len(output.Intents) > 0 {
+				intent = stringValue(output.Intents[0].Intent)
+			}
+			intents = append(intents, intent)
+		}
+	}
+	return intents, nil
+}
+
+// EvaluateIntents classifies held-out examples and compares the results with the expected intents.
+func EvaluateIntents(ctx context.Context, classifier IntentClassifier, workspaceID string, examples []LabeledExample) (*ConfusionMatrix, error) {
+	texts := make([]string, len(examples))
+	for i := range examples {
+		texts[i] = examples[i].Text
+	}
+	predicted, err := classifier.ClassifyIntents(ctx, workspaceID, texts)
+	if err != nil {
+		return nil, err
+	}
+	matrix := NewConfusionMatrix()
+	for i := range examples {
+		matrix.Add(examples[i].Intent, predicted[i])
+	}
+	return matrix, nil
+}
+
+// ConfusionMatrixIrrelevant is the label used for inputs that were not classified as any intent.
+const ConfusionMatrixIrrelevant = "(irrelevant)"
+
+// ConfusionMatrix : Counts how often inputs of each intent were classified as each intent.
+type ConfusionMatrix struct {
+	counts map[string]map[string]int
+	labels map[string]bool
+}
+
+// NewConfusionMatrix : Instantiate ConfusionMatrix
+func NewConfusionMatrix() *ConfusionMatrix {
+	return &ConfusionMatrix{
+		counts: make(map[string]map[string]int),
+		labels: make(map[string]bool),
+	}
+}
+
+// Add records one classification. An empty predicted intent is recorded as ConfusionMatrixIrrelevant.
+func (matrix *ConfusionMatrix) Add(actual string, predicted string) {
+	if predicted == "" {
+		predicted = ConfusionMatrixIrrelevant
+	}
+	if matrix.counts[actual] == nil {
+		matrix.counts[actual] = make(map[string]int)
+	}
+	matrix.counts[actual][predicted]++
+	matrix.labels[actual] = true
+	matrix.labels[predicted] = true
+}
+
+// Merge adds the counts of another matrix, for example of another fold.
+func (matrix *ConfusionMatrix) Merge(other *ConfusionMatrix) {
+	for actual, row := range other.counts {
+		for predicted, count := range row {
+			for i := 0; i < count; i++ {
+				matrix.Add(actual, predicted)
+			}
+		}
+	}
+}
+
+// Labels returns the intents seen as actual or predicted intents, sorted, with ConfusionMatrixIrrelevant last.
+func (matrix *ConfusionMatrix) Labels() []string {
+	var labels []string
+	for label := range matrix.labels {
+		if label != ConfusionMatrixIrrelevant {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	if matrix.labels[ConfusionMatrixIrrelevant] {
+		labels = append(labels, ConfusionMatrixIrrelevant)
+	}
+	return labels
+}
+
+// Count returns how often inputs of the actual intent were classified as the predicted intent.
+func (matrix *ConfusionMatrix) Count(actual string, predicted string) int {
+	return matrix.counts[actual][predicted]
+}
+
+// IntentMetrics : The classification quality of one intent.
+type IntentMetrics struct {
+	Intent string `json:"intent"`
+
+	// The number of inputs of the intent.
+	Support int `json:"support"`
+
+	// The number of inputs classified as the intent, and how many of them were correct.
+	Predicted     int `json:"predicted"`
+	TruePositives int `json:"true_positives"`
+
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// ClassificationReport : Accuracy, per-intent metrics and the confusion matrix of an evaluation.
+type ClassificationReport struct {
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+
+	// The average F1 score of the intents that have inputs.
+	MacroF1 float64 `json:"macro_f1"`
+
+	Intents []IntentMetrics `json:"intents"`
+
+	// Labels lists the intents in the order of the rows (actual intent) and columns (predicted intent) of Matrix.
+	Labels []string `json:"labels"`
+	Matrix [][]int  `json:"confusion_matrix"`
+}
+
+// Report computes accuracy, precision, recall and F1 from the counts.
+func (matrix *ConfusionMatrix) Report() *ClassificationReport {
+	labels := matrix.Labels()
+	report := &ClassificationReport{
+		Labels:  labels,
+		Matrix:  make([][]int, len(labels)),
+		Intents: []IntentMetrics{},
+	}
+	metrics := make([]IntentMetrics, len(labels))
+	for i, actual := range labels {
+		report.Matrix[i] = make([]int, len(labels))
+		metrics[i].Intent = actual
+		for j, predicted := range labels {
+			count := matrix.counts[actual][predicted]
+			report.Matrix[i][j] = count
+			report.Total += count
+			metrics[i].Support += count
+			metrics[j].Predicted += count
+			if i == j {
+				report.Correct += count
+				metrics[i].TruePositives = count
+			}
+		}
+	}
+	if report.Total > 0 {
+		report.Accuracy = float64(report.Correct) / float64(report.Total)
+	}
+
+	withSupport := 0
+	for _, m := range metrics {
+		if m.Intent == ConfusionMatrixIrrelevant && m.Support == 0 {
+			continue
+		}
+		if m.Predicted > 0 {
+			m.Precision = float64(m.TruePositives) / float64(m.Predicted)
+		}
+		if m.Support > 0 {
+			m.Recall = float64(m.TruePositives) / float64(m.Support)
+			withSupport++
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		if m.Support > 0 {
+			report.MacroF1 += m.F1
+		}
+		report.Intents = append(report.Intents, m)
+	}
+	if withSupport > 0 {
+		report.MacroF1 /= float64(withSupport)
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON.
+func (report *ClassificationReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteIntentsCSV writes the per-intent metrics as CSV.
+func (report *ClassificationReport) WriteIntentsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"intent", "support", "predicted", "true_positives", "precision", "recall", "f1"})
+	for _, m := range report.Intents {
+		writer.Write([]string{
+			m.Intent,
+			strconv.Itoa(m.Support),
+			strconv.Itoa(m.Predicted),
+			strconv.Itoa(m.TruePositives),
+			strconv.FormatFloat(m.Precision, 'f', 4, 64),
+			strconv.FormatFloat(m.Recall, 'f', 4, 64),
+			strconv.FormatFloat(m.F1, 'f', 4, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteConfusionMatrixCSV writes the confusion matrix as CSV, with a row per actual intent and a column per predicted
+// intent.
+func (report *ClassificationReport) WriteConfusionMatrixCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{"actual \\ predicted"}, report.Labels...))
+	for i, label := range report.Labels {
+		row := []string{label}
+		for _, count := range report.Matrix[i] {
+			row = append(row, strconv.Itoa(count))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,209 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`Training data quality`, func() {
+	unmarshalWorkspace := func(data string) *assistantv1.Workspace {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+		var workspace *assistantv1.Workspace
+		Expect(assistantv1.UnmarshalWorkspace(raw, &workspace)).To(Succeed())
+		return workspace
+	}
+
+	It(`Reports duplicates, collisions, small intents and synonym clashes`, func() {
+		workspace := unmarshalWorkspace(`{
+			"name": "pizza", "language": "en", "learning_opt_out": false,
+			"intents": [
+				{"intent": "order", "examples": [{"text": "I want a pizza"}, {"text": "order a large pizza now"}, {"text": "Hello there"}]},
+				{"intent": "greeting", "examples": [{"text": "hello, there!"}, {"text": "order a large pizza now please"}]}
+			],
+			"counterexamples": [{"text": "i want a PIZZA"}],
+			"entities": [
+				{"entity": "size", "values": [{"value": "large", "type": "synonyms", "synonyms": ["big"]}]},
+				{"entity": "crust", "values": [{"value": "thick", "type": "synonyms", "synonyms": ["Big", "deep"]}]},
+				{"entity": "phone", "values": [{"value": "large", "type": "patterns", "patterns": ["\\d+"]}]}
+			]
+		}`)
+		report := assistantv1.CheckTrainingData(workspace, &assistantv1.TrainingDataCheckOptions{MinExamplesPerIntent: 3, NearDuplicateThreshold: 0.8})
+		Expect(report.Examples).To(Equal(5))
+
+		duplicates := report.IssuesWithCode(assistantv1.TrainingDataIssueDuplicateExampleConst)
+		Expect(duplicates).To(HaveLen(1))
+		Expect(duplicates[0].Text).To(Equal("Hello there"))
+		Expect(duplicates[0].OtherIntent).To(Equal("greeting"))
+
+		nearDuplicates := report.IssuesWithCode(assistantv1.TrainingDataIssueNearDuplicateExampleConst)
+		Expect(nearDuplicates).To(HaveLen(1))
+		Expect(nearDuplicates[0].Similarity).To(BeNumerically("~", 5.0/6.0, 0.001))
+
+		collisions := report.IssuesWithCode(assistantv1.TrainingDataIssueCounterexampleCollisionConst)
+		Expect(collisions).To(HaveLen(1))
+		Expect(collisions[0].Intent).To(Equal("order"))
+
+		small := report.IssuesWithCode(assistantv1.TrainingDataIssueTooFewExamplesConst)
+		Expect(small).To(HaveLen(1))
+		Expect(small[0].Intent).To(Equal("greeting"))
+		Expect(small[0].Count).To(Equal(2))
+
+		clashes := report.IssuesWithCode(assistantv1.TrainingDataIssueSynonymClashConst)
+		Expect(clashes).To(HaveLen(1))
+		Expect(clashes[0].Text).To(Equal("big"))
+		Expect(clashes[0].Entity).To(Equal("size"))
+		Expect(clashes[0].OtherEntity).To(Equal("crust"))
+
+		var buffer bytes.Buffer
+		Expect(report.WriteJSON(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring(`"code": "synonym_clash"`))
+	})
+	It(`Finds the same near duplicates as comparing every pair`, func() {
+		random := rand.New(rand.NewSource(7))
+		words := []string{"pizza", "order", "large", "small", "please", "now", "want", "a", "the", "cheese"}
+		workspace := &assistantv1.Workspace{}
+		var intents, texts []string
+		for i := 0; i < 6; i++ {
+			intent := assistantv1.Intent{Intent: core.StringPtr(fmt.Sprintf("intent%d", i))}
+			for j := 0; j < 40; j++ {
+				var text []string
+				for k := 0; k < 2+random.Intn(5); k++ {
+					text = append(text, words[random.Intn(len(words))])
+				}
+				intent.Examples = append(intent.Examples, assistantv1.Example{Text: core.StringPtr(strings.Join(text, " "))})
+				intents = append(intents, *intent.Intent)
+				texts = append(texts, strings.Join(text, " "))
+			}
+			workspace.Intents = append(workspace.Intents, intent)
+		}
+
+		expected := 0
+		for i := range texts {
+			for j := i + 1; j < len(texts); j++ {
+				if intents[i] == intents[j] {
+					continue
+				}
+				union := make(map[string]int)
+				for _, token := range strings.Fields(texts[i]) {
+					union[token] |= 1
+				}
+				for _, token := range strings.Fields(texts[j]) {
+					union[token] |= 2
+				}
+				shared := 0
+				for _, membership := range union {
+					if membership == 3 {
+						shared++
+					}
+				}
+				if texts[i] == texts[j] || float64(shared)/float64(len(union)) >= 0.6 {
+					expected++
+				}
+			}
+		}
+		report := assistantv1.CheckTrainingData(workspace, &assistantv1.TrainingDataCheckOptions{NearDuplicateThreshold: 0.6})
+		found := len(report.IssuesWithCode(assistantv1.TrainingDataIssueDuplicateExampleConst)) +
+			len(report.IssuesWithCode(assistantv1.TrainingDataIssueNearDuplicateExampleConst))
+		Expect(expected).To(BeNumerically(">", 100))
+		Expect(found).To(Equal(expected))
+	})
+	It(`Splits held-out examples repeatably`, func() {
+		workspace := unmarshalWorkspace(`{"name": "w", "language": "en", "learning_opt_out": false, "intents": [
+			{"intent": "a", "examples": [{"text": "1"}, {"text": "2"}, {"text": "3"}, {"text": "4"}]},
+			{"intent": "b", "examples": [{"text": "5"}, {"text": "6"}]}
+		]}`)
+		training, heldOut := assistantv1.SplitHeldOut(workspace, 0.5, 42)
+		Expect(heldOut).To(HaveLen(3))
+		Expect(training.Intents[0].Examples).To(HaveLen(2))
+		Expect(training.Intents[1].Examples).To(HaveLen(1))
+		Expect(workspace.Intents[0].Examples).To(HaveLen(4))
+		_, again := assistantv1.SplitHeldOut(workspace, 0.5, 42)
+		Expect(again).To(Equal(heldOut))
+	})
+	It(`Evaluates held-out examples with BulkClassify`, func() {
+		var batches int
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			batches++
+			Expect(req.URL.Path).To(Equal("/v1/workspaces/ws1/bulk_classify"))
+			var body struct {
+				Input []struct {
+					Text string `json:"text"`
+				} `json:"input"`
+			}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			var outputs []string
+			for _, input := range body.Input {
+				intents := `[]`
+				if strings.HasPrefix(input.Text, "hi") || strings.HasPrefix(input.Text, "bye") {
+					intents = `[{"intent": "greeting", "confidence": 0.9}]`
+				} else if strings.HasPrefix(input.Text, "pizza") {
+					intents = `[{"intent": "order", "confidence": 0.8}]`
+				}
+				outputs = append(outputs, fmt.Sprintf(`{"input": {"text": %q}, "intents": %s}`, input.Text, intents))
+			}
+			res.Header().Set("Content-type", "application/json")
+			fmt.Fprintf(res, `{"output": [%s]}`, strings.Join(outputs, ","))
+		}))
+		defer testServer.Close()
+		assistantService, err := assistantv1.NewAssistantV1(&assistantv1.AssistantV1Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(err).To(BeNil())
+
+		classifier := assistantService.NewBulkIntentClassifier()
+		classifier.BatchSize = 2
+		matrix, err := assistantv1.EvaluateIntents(context.Background(), classifier, "ws1", []assistantv1.LabeledExample{
+			{Intent: "greeting", Text: "hi"},
+			{Intent: "greeting", Text: "hi there"},
+			{Intent: "goodbye", Text: "bye"},
+			{Intent: "order", Text: "pizza"},
+			{Intent: "order", Text: "one large"},
+		})
+		Expect(err).To(BeNil())
+		Expect(batches).To(Equal(3))
+
+		report := matrix.Report()
+		Expect(report.Labels).To(Equal([]string{"goodbye", "greeting", "order", assistantv1.ConfusionMatrixIrrelevant}))
+		Expect(report.Accuracy).To(BeNumerically("~", 0.6, 0.001))
+		Expect(report.Matrix[0]).To(Equal([]int{0, 1, 0, 0}))
+		Expect(report.Intents).To(HaveLen(3))
+		Expect(report.Intents[1].Precision).To(BeNumerically("~", 2.0/3.0, 0.001))
+		Expect(report.Intents[1].Recall).To(BeNumerically("~", 1.0, 0.001))
+		Expect(report.Intents[2].F1).To(BeNumerically("~", 2.0/3.0, 0.001))
+
+		var buffer bytes.Buffer
+		Expect(report.WriteConfusionMatrixCSV(&buffer)).To(Succeed())
+		Expect(strings.Split(buffer.String(), "\n")[1]).To(Equal("goodbye,0,1,0,0"))
+	})
+})
@@ -0,0 +1,810 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Constants associated with the WorkspaceChange.Action property.
+const (
+	WorkspaceChangeActionCreateConst = "create"
+	WorkspaceChangeActionUpdateConst = "update"
+	WorkspaceChangeActionDeleteConst = "delete"
+)
+
+// Constants associated with the WorkspaceChange.Kind property.
+const (
+	WorkspaceChangeKindWorkspaceConst      = "workspace"
+	WorkspaceChangeKindIntentConst         = "intent"
+	WorkspaceChangeKindExampleConst        = "example"
+	WorkspaceChangeKindCounterexampleConst = "counterexample"
+	WorkspaceChangeKindEntityConst         = "entity"
+	WorkspaceChangeKindValueConst          = "value"
+	WorkspaceChangeKindSynonymConst        = "synonym"
+	WorkspaceChangeKindDialogNodeConst     = "dialog_node"
+)
+
+// WorkspaceChange : A single difference between a live workspace and its desired definition.
+type WorkspaceChange struct {
+	// Whether the element is created, updated or deleted.
+	Action string `json:"action"`
+
+	// The kind of element that changes.
+	Kind string `json:"kind"`
+
+	// The identifiers of the element. Only the ones that apply to the kind are set; Field names the changed workspace
+	// property for changes of kind "workspace".
+	Field      string `json:"field,omitempty"`
+	Intent     string `json:"intent,omitempty"`
+	Entity     string `json:"entity,omitempty"`
+	Value      string `json:"value,omitempty"`
+	Text       string `json:"text,omitempty"`
+	DialogNode string `json:"dialog_node,omitempty"`
+
+	// The element as it is in the live workspace (for updates and deletes) and as it should be (for creates and
+	// updates).
+	Current interface{} `json:"current,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// String returns a one-line description of the change, such as `+ example #order "I want a pizza"`.
+func (change *WorkspaceChange) String() string {
+	symbol := map[string]string{
+		WorkspaceChangeActionCreateConst: "+",
+		WorkspaceChangeActionUpdateConst: "~",
+		WorkspaceChangeActionDeleteConst: "-",
+	}[change.Action]
+
+	var path string
+	switch change.Kind {
+	case WorkspaceChangeKindWorkspaceConst:
+		path = "workspace " + change.Field
+	case WorkspaceChangeKindIntentConst:
+		path = "intent #" + change.Intent
+	case WorkspaceChangeKindExampleConst:
+		path = fmt.Sprintf("example #%s %q", change.Intent, change.Text)
+	case WorkspaceChangeKindCounterexampleConst:
+		path = fmt.Sprintf("counterexample %q", change.Text)
+	case WorkspaceChangeKindEntityConst:
+		path = "entity @" + change.Entity
+	case WorkspaceChangeKindValueConst:
+		path = fmt.Sprintf("value @%s:%s", change.Entity, change.Value)
+	case WorkspaceChangeKindSynonymConst:
+		path = fmt.Sprintf("synonym @%s:%s %q", change.Entity, change.Value, change.Text)
+	case WorkspaceChangeKindDialogNodeConst:
+		path = "dialog node " + change.DialogNode
+	}
+	return symbol + " " + path
+}
+
+// WorkspaceDiff : The structured difference between a live workspace and its desired definition.
+type WorkspaceDiff struct {
+	Changes []WorkspaceChange `json:"changes"`
+}
+
+// IsEmpty reports whether the workspaces are equivalent.
+func (diff *WorkspaceDiff) IsEmpty() bool {
+	return len(diff.Changes) == 0
+}
+
+// Plan returns a human-readable description of the changes, one per line, followed by a summary line.
+func (diff *WorkspaceDiff) Plan() string {
+	var builder strings.Builder
+	counts := make(map[string]int)
+	for i := range diff.Changes {
+		builder.WriteString(diff.Changes[i].String())
+		builder.WriteString("\n")
+		counts[diff.Changes[i].Action]++
+	}
+	fmt.Fprintf(&builder, "Plan: %d to create, %d to update, %d to delete.\n",
+		counts[WorkspaceChangeActionCreateConst], counts[WorkspaceChangeActionUpdateConst], counts[WorkspaceChangeActionDeleteConst])
+	return builder.String()
+}
+
+func (diff *WorkspaceDiff) add(change WorkspaceChange) {
+	diff.Changes = append(diff.Changes, change)
+}
+
+func (diff *WorkspaceDiff) filter(kind string, action string) []WorkspaceChange {
+	var changes []WorkspaceChange
+	for _, change := range diff.Changes {
+		if change.Kind == kind && (action == "" || change.Action == action) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// jsonEqual compares the JSON representations of two values, treating null, empty objects and empty arrays alike.
+func jsonEqual(a interface{}, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err.Error()
+		}
+		switch string(data) {
+		case "null", "{}", "[]", `""`:
+			return nil
+		}
+		var normalized interface{}
+		json.Unmarshal(data, &normalized)
+		return normalized
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// isNilValue returns whether a value is nil or a nil pointer, map or slice.
+func isNilValue(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return value == nil
+}
+
+func withoutAuditDialogNode(node DialogNode) DialogNode {
+	node.Created = nil
+	node.Updated = nil
+	return node
+}
+
+// DiffWorkspaces computes the changes that turn the current workspace into the desired one. Audit timestamps, the
+// workspace ID and the status are ignored, and so are the fields that the desired workspace leaves out: a nil field,
+// such as metadata, webhooks or intents, is left unchanged, while an empty list of intents, entities, counterexamples
+// or dialog nodes deletes all of them. A nil desired workspace leaves everything unchanged.
+func DiffWorkspaces(current *Workspace, desired *Workspace) *WorkspaceDiff {
+	if current == nil {
+		current = new(Workspace)
+	}
+	diff := &WorkspaceDiff{Changes: []WorkspaceChange{}}
+	if desired == nil {
+		return diff
+	}
+
+	workspaceFields := []struct {
+		name             string
+		current, desired interface{}
+	}{
+		{"name", current.Name, desired.Name},
+		{"description", current.Description, desired.Description},
+		{"language", current.Language, desired.Language},
+		{"learning_opt_out", current.LearningOptOut, desired.LearningOptOut},
+		{"metadata", current.Metadata, desired.Metadata},
+		{"system_settings", current.SystemSettings, desired.SystemSettings},
+		{"webhooks", current.Webhooks, desired.Webhooks},
+	}
+	for _, field := range workspaceFields {
+		if isNilValue(field.desired) {
+			continue
+		}
+		if !jsonEqual(field.current, field.desired) {
+			diff.add(WorkspaceChange{
+				Action:  WorkspaceChangeActionUpdateConst,
+				Kind:    WorkspaceChangeKindWorkspaceConst,
+				Field:   field.name,
+				Current: field.current,
+				Desired: field.desired,
+			})
+		}
+	}
+
+	if desired.Intents != nil {
+		diffIntents(diff, current.Intents, desired.Intents)
+	}
+	if desired.Entities != nil {
+		diffEntities(diff, current.Entities, desired.Entities)
+	}
+	if desired.Counterexamples != nil {
+		diffCounterexamples(diff, current.Counterexamples, desired.Counterexamples)
+	}
+	if desired.DialogNodes != nil {
+		diffDialogNodes(diff, current.DialogNodes, desired.DialogNodes)
+	}
+	return diff
+}
+
+func diffCounterexamples(diff *WorkspaceDiff, current []Counterexample, desired []Counterexample) {
+
+	currentCounterexamples := make(map[string]bool)
+	for _, counterexample := range current {
+		currentCounterexamples[stringValue(counterexample.Text)] = true
+	}
+	desiredCounterexamples := make(map[string]bool)
+	for _, counterexample := range desired {
+		text := stringValue(counterexample.Text)
+		desiredCounterexamples[text] = true
+		if !currentCounterexamples[text] {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindCounterexampleConst, Text: text})
+		}
+	}
+	for _, counterexample := range current {
+		if text := stringValue(counterexample.Text); !desiredCounterexamples[text] {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindCounterexampleConst, Text: text})
+		}
+	}
+}
+
+func diffDialogNodes(diff *WorkspaceDiff, current []DialogNode, desired []DialogNode) {
+	currentNodes := make(map[string]DialogNode)
+	for _, node := range current {
+		currentNodes[stringValue(node.DialogNode)] = withoutAuditDialogNode(node)
+	}
+	desiredNodes := make(map[string]bool)
+	for _, node := range desired {
+		id := stringValue(node.DialogNode)
+		desiredNodes[id] = true
+		desiredNode := withoutAuditDialogNode(node)
+		currentNode, ok := currentNodes[id]
+		if !ok {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindDialogNodeConst, DialogNode: id, Desired: &desiredNode})
+		} else if !jsonEqual(&currentNode, &desiredNode) {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionUpdateConst, Kind: WorkspaceChangeKindDialogNodeConst, DialogNode: id, Current: &currentNode, Desired: &desiredNode})
+		}
+	}
+	for _, node := range current {
+		if id := stringValue(node.DialogNode); !desiredNodes[id] {
+			currentNode := currentNodes[id]
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindDialogNodeConst, DialogNode: id, Current: &currentNode})
+		}
+	}
+}
+
+func diffIntents(diff *WorkspaceDiff, current []Intent, desired []Intent) {
+	currentIntents := make(map[string]*Intent)
+	for i := range current {
+		currentIntents[stringValue(current[i].Intent)] = &current[i]
+	}
+	desiredIntents := make(map[string]bool)
+	for i := range desired {
+		desiredIntent := &desired[i]
+		name := stringValue(desiredIntent.Intent)
+		desiredIntents[name] = true
+		currentIntent, ok := currentIntents[name]
+		if !ok {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindIntentConst, Intent: name, Desired: desiredIntent})
+			continue
+		}
+		if stringValue(currentIntent.Description) != stringValue(desiredIntent.Description) {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionUpdateConst, Kind: WorkspaceChangeKindIntentConst, Intent: name, Current: currentIntent, Desired: desiredIntent})
+		}
+
+		currentExamples := make(map[string]*Example)
+		for j := range currentIntent.Examples {
+			currentExamples[stringValue(currentIntent.Examples[j].Text)] = &currentIntent.Examples[j]
+		}
+		desiredExamples := make(map[string]bool)
+		for j := range desiredIntent.Examples {
+			desiredExample := &desiredIntent.Examples[j]
+			text := stringValue(desiredExample.Text)
+			desiredExamples[text] = true
+			currentExample, ok := currentExamples[text]
+			if !ok {
+				diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindExampleConst, Intent: name, Text: text, Desired: desiredExample})
+			} else if !jsonEqual(currentExample.Mentions, desiredExample.Mentions) {
+				diff.add(WorkspaceChange{Action: WorkspaceChangeActionUpdateConst, Kind: WorkspaceChangeKindExampleConst, Intent: name, Text: text, Current: currentExample, Desired: desiredExample})
+			}
+		}
+		for j := range currentIntent.Examples {
+			if text := stringValue(currentIntent.Examples[j].Text); !desiredExamples[text] {
+				diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindExampleConst, Intent: name, Text: text, Current: &currentIntent.Examples[j]})
+			}
+		}
+	}
+	for i := range current {
+		if name := stringValue(current[i].Intent); !desiredIntents[name] {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindIntentConst, Intent: name, Current: &current[i]})
+		}
+	}
+}
+
+func diffEntities(diff *WorkspaceDiff, current []Entity, desired []Entity) {
+	currentEntities := make(map[string]*Entity)
+	for i := range current {
+		currentEntities[stringValue(current[i].Entity)] = &current[i]
+	}
+	desiredEntities := make(map[string]bool)
+	for i := range desired {
+		desiredEntity := &desired[i]
+		name := stringValue(desiredEntity.Entity)
+		desiredEntities[name] = true
+		currentEntity, ok := currentEntities[name]
+		if !ok {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindEntityConst, Entity: name, Desired: desiredEntity})
+			continue
+		}
+		if stringValue(currentEntity.Description) != stringValue(desiredEntity.Description) ||
+			!jsonEqual(currentEntity.Metadata, desiredEntity.Metadata) ||
+			!jsonEqual(currentEntity.FuzzyMatch, desiredEntity.FuzzyMatch) {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionUpdateConst, Kind: WorkspaceChangeKindEntityConst, Entity: name, Current: currentEntity, Desired: desiredEntity})
+		}
+		diffValues(diff, name, currentEntity.Values, desiredEntity.Values)
+	}
+	for i := range current {
+		if name := stringValue(current[i].Entity); !desiredEntities[name] {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindEntityConst, Entity: name, Current: &current[i]})
+		}
+	}
+}
+
+func diffValues(diff *WorkspaceDiff, entity string, current []Value, desired []Value) {
+	currentValues := make(map[string]*Value)
+	for i := range current {
+		currentValues[stringValue(current[i].Value)] = &current[i]
+	}
+	desiredValues := make(map[string]bool)
+	for i := range desired {
+		desiredValue := &desired[i]
+		name := stringValue(desiredValue.Value)
+		desiredValues[name] = true
+		currentValue, ok := currentValues[name]
+		if !ok {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindValueConst, Entity: entity, Value: name, Desired: desiredValue})
+			continue
+		}
+		if stringValue(currentValue.Type) != stringValue(desiredValue.Type) ||
+			!jsonEqual(currentValue.Metadata, desiredValue.Metadata) ||
+			!jsonEqual(currentValue.Patterns, desiredValue.Patterns) {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionUpdateConst, Kind: WorkspaceChangeKindValueConst, Entity: entity, Value: name, Current: currentValue, Desired: desiredValue})
+		}
+
+		currentSynonyms := make(map[string]bool)
+		for _, synonym := range currentValue.Synonyms {
+			currentSynonyms[synonym] = true
+		}
+		desiredSynonyms := make(map[string]bool)
+		for _, synonym := range desiredValue.Synonyms {
+			desiredSynonyms[synonym] = true
+			if !currentSynonyms[synonym] {
+				diff.add(WorkspaceChange{Action: WorkspaceChangeActionCreateConst, Kind: WorkspaceChangeKindSynonymConst, Entity: entity, Value: name, Text: synonym})
+			}
+		}
+		for _, synonym := range currentValue.Synonyms {
+			if !desiredSynonyms[synonym] {
+				diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindSynonymConst, Entity: entity, Value: name, Text: synonym})
+			}
+		}
+	}
+	for i := range current {
+		if name := stringValue(current[i].Value); !desiredValues[name] {
+			diff.add(WorkspaceChange{Action: WorkspaceChangeActionDeleteConst, Kind: WorkspaceChangeKindValueConst, Entity: entity, Value: name, Current: &current[i]})
+		}
+	}
+}
+
+// LoadWorkspaceFiles reads a workspace definition from one or more JSON or YAML files (chosen by the .json, .yaml or
+// .yml extension). The files are merged in order: arrays such as intents, entities and dialog_nodes are concatenated
+// and other properties are overridden, so a workspace can be split into several files.
+func LoadWorkspaceFiles(paths ...string) (*Workspace, error) {
+	merged := make(map[string]interface{})
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var document map[string]interface{}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &document)
+		case ".json":
+			err = json.Unmarshal(data, &document)
+		default:
+			err = fmt.Errorf("unsupported file extension, expected .json, .yaml or .yml")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading workspace file %s: %s", path, err.Error())
+		}
+
+		for key, value := range document {
+			if items, ok := value.([]interface{}); ok {
+				if existing, ok := merged[key].([]interface{}); ok {
+					merged[key] = append(existing, items...)
+					continue
+				}
+			}
+			merged[key] = value
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var rawWorkspace map[string]json.RawMessage
+	err = json.Unmarshal(data, &rawWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	var workspace *Workspace
+	err = core.UnmarshalModel(rawWorkspace, "", &workspace, UnmarshalWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// LoadWorkspaceDir reads a workspace definition from every JSON and YAML file in a directory, in lexical order of
+// their names. See LoadWorkspaceFiles for how the files are merged.
+func LoadWorkspaceDir(dir string) (*Workspace, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no workspace files found in %s", dir)
+	}
+	return LoadWorkspaceFiles(paths...)
+}
+
+// WriteWorkspaceFile writes a workspace definition to a JSON or YAML file (chosen by the extension), leaving out the
+// workspace ID, the status and audit timestamps so that the file only changes when the definition does.
+func WriteWorkspaceFile(path string, workspace *Workspace) error {
+	data, err := json.Marshal(workspace)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	err = json.Unmarshal(data, &document)
+	if err != nil {
+		return err
+	}
+	document = withoutServiceProperties(document)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(document)
+	case ".json":
+		data, err = json.MarshalIndent(document, "", "  ")
+		data = append(data, '\n')
+	default:
+		err = fmt.Errorf("unsupported file extension, expected .json, .yaml or .yml")
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func withoutServiceProperties(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range []string{"created", "updated", "workspace_id", "status"} {
+			delete(v, key)
+		}
+		for key, child := range v {
+			v[key] = withoutServiceProperties(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = withoutServiceProperties(child)
+		}
+	}
+	return value
+}
+
+// WaitForWorkspaceAvailable polls the status of a workspace until it is Available, for example after training.
+// It fails if the status becomes Failed or when the context is done.
+func (assistant *AssistantV1) WaitForWorkspaceAvailable(ctx context.Context, workspaceID string, pollInterval time.Duration) (*Workspace, error) {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	for {
+		workspace, _, err := assistant.GetWorkspaceWithContext(ctx, assistant.NewGetWorkspaceOptions(workspaceID))
+		if err != nil {
+			return nil, err
+		}
+		switch stringValue(workspace.Status) {
+		case WorkspaceStatusAvailableConst:
+			return workspace, nil
+		case WorkspaceStatusFailedConst:
+			return workspace, fmt.Errorf("workspace %s failed to train", workspaceID)
+		}
+		select {
+		case <-ctx.Done():
+			return workspace, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WorkspaceSync : Synchronizes a live workspace with a definition kept in files. Plan computes the differences and
+// Apply makes the live workspace match the definition with targeted create, update and delete calls, then waits for
+// the workspace to become available again.
+type WorkspaceSync struct {
+	Assistant *AssistantV1
+
+	// The workspace to synchronize.
+	WorkspaceID string
+
+	// When set, Apply only computes and returns the plan without changing the workspace.
+	DryRun bool
+
+	// How often the status is polled after applying changes. Defaults to 5 seconds.
+	PollInterval time.Duration
+
+	// When set, Apply returns after applying the changes without waiting for the workspace to become available.
+	SkipWait bool
+}
+
+// NewWorkspaceSync : Instantiate WorkspaceSync
+func (assistant *AssistantV1) NewWorkspaceSync(workspaceID string) *WorkspaceSync {
+	return &WorkspaceSync{
+		Assistant:    assistant,
+		WorkspaceID:  workspaceID,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// Export returns the full live workspace, including all of its elements.
+func (sync *WorkspaceSync) Export(ctx context.Context) (*Workspace, error) {
+	options := sync.Assistant.NewGetWorkspaceOptions(sync.WorkspaceID).SetExport(true)
+	workspace, _, err := sync.Assistant.GetWorkspaceWithContext(ctx, options)
+	return workspace, err
+}
+
+// Plan computes the changes needed to make the live workspace match the desired definition.
+func (sync *WorkspaceSync) Plan(ctx context.Context, desired *Workspace) (*WorkspaceDiff, error) {
+	current, err := sync.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return DiffWorkspaces(current, desired), nil
+}
+
+// Apply makes the live workspace match the desired definition and returns the changes that were made (or, with
+// DryRun, that would be made).
+func (sync *WorkspaceSync) Apply(ctx context.Context, desired *Workspace) (*WorkspaceDiff, error) {
+	diff, err := sync.Plan(ctx, desired)
+	if err != nil || sync.DryRun || diff.IsEmpty() {
+		return diff, err
+	}
+	err = sync.applyDiff(ctx, desired, diff)
+	if err != nil {
+		return diff, err
+	}
+	if !sync.SkipWait {
+		_, err = sync.Assistant.WaitForWorkspaceAvailable(ctx, sync.WorkspaceID, sync.PollInterval)
+	}
+	return diff, err
+}
+
+func ignoreNotFound(response *core.DetailedResponse, err error) error {
+	if err != nil && response != nil && response.StatusCode == 404 {
+		return nil
+	}
+	return err
+}
+
+// workspaceChangeOrder is the order in which elements are created and updated: entities and their values and
+// synonyms before the intents and examples that mention them. Deletes run in the reverse order.
+var workspaceChangeOrder = []string{
+	WorkspaceChangeKindEntityConst,
+	WorkspaceChangeKindValueConst,
+	WorkspaceChangeKindSynonymConst,
+	WorkspaceChangeKindIntentConst,
+	WorkspaceChangeKindExampleConst,
+	WorkspaceChangeKindCounterexampleConst,
+}
+
+func (sync *WorkspaceSync) applyDiff(ctx context.Context, desired *Workspace, diff *WorkspaceDiff) error {
+	if err := sync.applyWorkspaceFields(ctx, desired, diff); err != nil {
+		return err
+	}
+
+	for i := len(workspaceChangeOrder) - 1; i >= 0; i-- {
+		for _, change := range diff.filter(workspaceChangeOrder[i], WorkspaceChangeActionDeleteConst) {
+			if err := sync.applyChange(ctx, change); err != nil {
+				return fmt.Errorf("%s: %s", change.String(), err.Error())
+			}
+		}
+	}
+	for _, kind := range workspaceChangeOrder {
+		for _, change := range diff.filter(kind, "") {
+			if change.Action == WorkspaceChangeActionDeleteConst {
+				continue
+			}
+			if err := sync.applyChange(ctx, change); err != nil {
+				return fmt.Errorf("%s: %s", change.String(), err.Error())
+			}
+		}
+	}
+
+	return sync.applyDialogNodes(ctx, desired, diff)
+}
+
+// applyChange applies a change to an intent, example, entity, value, synonym or counterexample.
+func (sync *WorkspaceSync) applyChange(ctx context.Context, change WorkspaceChange) (err error) {
+	assistant := sync.Assistant
+	workspaceID := sync.WorkspaceID
+
+	switch change.Kind {
+	case WorkspaceChangeKindIntentConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			intent := change.Desired.(*Intent)
+			options := assistant.NewCreateIntentOptions(workspaceID, change.Intent)
+			options.Description = intent.Description
+			options.Examples = intent.Examples
+			_, _, err = assistant.CreateIntentWithContext(ctx, options)
+		case WorkspaceChangeActionUpdateConst:
+			options := assistant.NewUpdateIntentOptions(workspaceID, change.Intent)
+			options.SetNewDescription(stringValue(change.Desired.(*Intent).Description))
+			_, _, err = assistant.UpdateIntentWithContext(ctx, options)
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteIntentWithContext(ctx, assistant.NewDeleteIntentOptions(workspaceID, change.Intent)))
+		}
+	case WorkspaceChangeKindExampleConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			options := assistant.NewCreateExampleOptions(workspaceID, change.Intent, change.Text)
+			options.Mentions = change.Desired.(*Example).Mentions
+			_, _, err = assistant.CreateExampleWithContext(ctx, options)
+		case WorkspaceChangeActionUpdateConst:
+			options := assistant.NewUpdateExampleOptions(workspaceID, change.Intent, change.Text)
+			options.NewMentions = change.Desired.(*Example).Mentions
+			_, _, err = assistant.UpdateExampleWithContext(ctx, options)
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteExampleWithContext(ctx, assistant.NewDeleteExampleOptions(workspaceID, change.Intent, change.Text)))
+		}
+	case WorkspaceChangeKindEntityConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			entity := change.Desired.(*Entity)
+			options := assistant.NewCreateEntityOptions(workspaceID, change.Entity)
+			options.Description = entity.Description
+			options.Metadata = entity.Metadata
+			options.FuzzyMatch = entity.FuzzyMatch
+			options.Values = createValues(entity.Values)
+			_, _, err = assistant.CreateEntityWithContext(ctx, options)
+		case WorkspaceChangeActionUpdateConst:
+			entity := change.Desired.(*Entity)
+			options := assistant.NewUpdateEntityOptions(workspaceID, change.Entity)
+			options.SetNewDescription(stringValue(entity.Description))
+			options.NewMetadata = entity.Metadata
+			options.NewFuzzyMatch = entity.FuzzyMatch
+			_, _, err = assistant.UpdateEntityWithContext(ctx, options)
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteEntityWithContext(ctx, assistant.NewDeleteEntityOptions(workspaceID, change.Entity)))
+		}
+	case WorkspaceChangeKindValueConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			value := change.Desired.(*Value)
+			options := assistant.NewCreateValueOptions(workspaceID, change.Entity, change.Value)
+			options.Metadata = value.Metadata
+			options.Type = value.Type
+			options.Synonyms = value.Synonyms
+			options.Patterns = value.Patterns
+			_, _, err = assistant.CreateValueWithContext(ctx, options)
+		case WorkspaceChangeActionUpdateConst:
+			value := change.Desired.(*Value)
+			options := assistant.NewUpdateValueOptions(workspaceID, change.Entity, change.Value)
+			options.NewMetadata = value.Metadata
+			options.NewType = value.Type
+			options.NewPatterns = value.Patterns
+			_, _, err = assistant.UpdateValueWithContext(ctx, options)
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteValueWithContext(ctx, assistant.NewDeleteValueOptions(workspaceID, change.Entity, change.Value)))
+		}
+	case WorkspaceChangeKindSynonymConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			_, _, err = assistant.CreateSynonymWithContext(ctx, assistant.NewCreateSynonymOptions(workspaceID, change.Entity, change.Value, change.Text))
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteSynonymWithContext(ctx, assistant.NewDeleteSynonymOptions(workspaceID, change.Entity, change.Value, change.Text)))
+		}
+	case WorkspaceChangeKindCounterexampleConst:
+		switch change.Action {
+		case WorkspaceChangeActionCreateConst:
+			_, _, err = assistant.CreateCounterexampleWithContext(ctx, assistant.NewCreateCounterexampleOptions(workspaceID, change.Text))
+		case WorkspaceChangeActionDeleteConst:
+			err = ignoreNotFound(assistant.DeleteCounterexampleWithContext(ctx, assistant.NewDeleteCounterexampleOptions(workspaceID, change.Text)))
+		}
+	}
+	return err
+}
+
+func (sync *WorkspaceSync) applyWorkspaceFields(ctx context.Context, desired *Workspace, diff *WorkspaceDiff) error {
+	changes := diff.filter(WorkspaceChangeKindWorkspaceConst, "")
+	if len(changes) == 0 {
+		return nil
+	}
+	options := sync.Assistant.NewUpdateWorkspaceOptions(sync.WorkspaceID)
+	for _, change := range changes {
+		switch change.Field {
+		case "name":
+			options.Name = desired.Name
+		case "description":
+			options.SetDescription(stringValue(desired.Description))
+		case "language":
+			return fmt.Errorf("%s: the language of an existing workspace cannot be changed", change.String())
+		case "learning_opt_out":
+			options.LearningOptOut = desired.LearningOptOut
+		case "metadata":
+			options.Metadata = desired.Metadata
+		case "system_settings":
+			options.SystemSettings = desired.SystemSettings
+		case "webhooks":
+			if len(desired.Webhooks) == 0 {
+				return fmt.Errorf("%s: removing all webhooks is not supported by the update workspace API", change.String())
+			}
+			options.Webhooks = desired.Webhooks
+		}
+	}
+	_, _, err := sync.Assistant.UpdateWorkspaceWithContext(ctx, options)
+	return err
+}
+
+// applyDialogNodes replaces the dialog as a whole, because nodes reference each other through their parent, previous
+// sibling and next step and cannot be reordered reliably one at a time.
+func (sync *WorkspaceSync) applyDialogNodes(ctx context.Context, desired *Workspace, diff *WorkspaceDiff) error {
+	changes := diff.filter(WorkspaceChangeKindDialogNodeConst, "")
+	if len(changes) == 0 {
+		return nil
+	}
+	if len(desired.DialogNodes) > 0 {
+		options := sync.Assistant.NewUpdateWorkspaceOptions(sync.WorkspaceID)
+		options.DialogNodes = desired.DialogNodes
+		_, _, err := sync.Assistant.UpdateWorkspaceWithContext(ctx, options)
+		return err
+	}
+	for _, change := range changes {
+		options := sync.Assistant.NewDeleteDialogNodeOptions(sync.WorkspaceID, change.DialogNode)
+		err := ignoreNotFound(sync.Assistant.DeleteDialogNodeWithContext(ctx, options))
+		if err != nil {
+			return fmt.Errorf("%s: %s", change.String(), err.Error())
+		}
+	}
+	return nil
+}
+
+func createValues(values []Value) []CreateValue {
+	var createValues []CreateValue
+	for _, value := range values {
+		createValues = append(createValues, CreateValue{
+			Value:    value.Value,
+			Metadata: value.Metadata,
+			Type:     value.Type,
+			Synonyms: value.Synonyms,
+			Patterns: value.Patterns,
+		})
+	}
+	return createValues
+}
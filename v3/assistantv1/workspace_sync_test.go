@@ -0,0 +1,212 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv1_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv1"
+)
+
+var _ = Describe(`Workspace sync`, func() {
+	const liveWorkspace = `{
+		"name": "pizza", "language": "en", "learning_opt_out": false, "workspace_id": "ws1", "status": "Available",
+		"intents": [
+			{"intent": "order", "examples": [{"text": "I want a pizza"}, {"text": "pizza please"}]},
+			{"intent": "cancel", "examples": [{"text": "cancel my order"}]}
+		],
+		"entities": [
+			{"entity": "size", "values": [
+				{"value": "small", "type": "synonyms", "synonyms": ["little"]},
+				{"value": "huge", "type": "synonyms", "synonyms": []}
+			]}
+		],
+		"counterexamples": [{"text": "I like cats"}],
+		"dialog_nodes": [{"dialog_node": "welcome", "conditions": "welcome", "created": "2022-01-01T00:00:00Z"}]
+	}`
+	const desiredYAML = `name: pizza
+language: en
+learning_opt_out: false
+intents:
+  - intent: order
+    examples:
+      - text: I want a pizza
+      - text: one pizza
+  - intent: hours
+    examples:
+      - text: when are you open
+entities:
+  - entity: size
+    values:
+      - value: small
+        type: synonyms
+        synonyms: [tiny]
+      - value: large
+        type: synonyms
+counterexamples: []
+`
+	const desiredDialogJSON = `{"dialog_nodes": [
+		{"dialog_node": "welcome", "conditions": "welcome"},
+		{"dialog_node": "hours", "conditions": "#hours", "previous_sibling": "welcome"}
+	]}`
+
+	var testServer *httptest.Server
+	var requests []string
+	var statusPolls int
+	var dir string
+
+	BeforeEach(func() {
+		requests = nil
+		statusPolls = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			if req.Method == http.MethodGet && req.URL.Path == "/v1/workspaces/ws1" {
+				if req.URL.Query().Get("export") == "true" {
+					fmt.Fprint(res, liveWorkspace)
+					return
+				}
+				statusPolls++
+				if statusPolls < 2 {
+					fmt.Fprint(res, `{"name": "pizza", "language": "en", "learning_opt_out": false, "status": "Training"}`)
+				} else {
+					fmt.Fprint(res, `{"name": "pizza", "language": "en", "learning_opt_out": false, "status": "Available"}`)
+				}
+				return
+			}
+			requests = append(requests, req.Method+" "+req.URL.Path)
+			res.WriteHeader(200)
+			fmt.Fprint(res, `{}`)
+		}))
+
+		var err error
+		dir, err = ioutil.TempDir("", "workspace")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte(desiredYAML), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(desiredDialogJSON), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		testServer.Close()
+		os.RemoveAll(dir)
+	})
+
+	newSync := func() *assistantv1.WorkspaceSync {
+		assistantService, serviceErr := assistantv1.NewAssistantV1(&assistantv1.AssistantV1Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(serviceErr).To(BeNil())
+		sync := assistantService.NewWorkspaceSync("ws1")
+		sync.PollInterval = time.Millisecond
+		return sync
+	}
+
+	It(`Loads and merges workspace files`, func() {
+		workspace, err := assistantv1.LoadWorkspaceDir(dir)
+		Expect(err).To(BeNil())
+		Expect(*workspace.Name).To(Equal("pizza"))
+		Expect(workspace.Intents).To(HaveLen(2))
+		Expect(workspace.Entities[0].Values).To(HaveLen(2))
+		Expect(workspace.DialogNodes).To(HaveLen(2))
+
+		path := filepath.Join(dir, "export.json")
+		Expect(assistantv1.WriteWorkspaceFile(path, workspace)).To(Succeed())
+		reloaded, err := assistantv1.LoadWorkspaceFiles(path)
+		Expect(err).To(BeNil())
+		Expect(assistantv1.DiffWorkspaces(workspace, reloaded).IsEmpty()).To(BeTrue())
+	})
+	It(`Plans changes without applying them in dry-run mode`, func() {
+		desired, err := assistantv1.LoadWorkspaceDir(dir)
+		Expect(err).To(BeNil())
+		sync := newSync()
+		sync.DryRun = true
+		diff, err := sync.Apply(context.Background(), desired)
+		Expect(err).To(BeNil())
+		Expect(requests).To(BeEmpty())
+
+		var lines []string
+		for i := range diff.Changes {
+			lines = append(lines, diff.Changes[i].String())
+		}
+		Expect(lines).To(Equal([]string{
+			`+ example #order "one pizza"`,
+			`- example #order "pizza please"`,
+			`+ intent #hours`,
+			`- intent #cancel`,
+			`+ synonym @size:small "tiny"`,
+			`- synonym @size:small "little"`,
+			`+ value @size:large`,
+			`- value @size:huge`,
+			`- counterexample "I like cats"`,
+			`+ dialog node hours`,
+		}))
+		Expect(diff.Plan()).To(HaveSuffix("Plan: 5 to create, 0 to update, 5 to delete.\n"))
+	})
+	It(`Applies changes and waits for the workspace to be available`, func() {
+		desired, err := assistantv1.LoadWorkspaceDir(dir)
+		Expect(err).To(BeNil())
+		_, err = newSync().Apply(context.Background(), desired)
+		Expect(err).To(BeNil())
+		Expect(requests).To(Equal([]string{
+			"DELETE /v1/workspaces/ws1/counterexamples/I like cats",
+			"DELETE /v1/workspaces/ws1/intents/order/examples/pizza please",
+			"DELETE /v1/workspaces/ws1/intents/cancel",
+			"DELETE /v1/workspaces/ws1/entities/size/values/small/synonyms/little",
+			"DELETE /v1/workspaces/ws1/entities/size/values/huge",
+			"POST /v1/workspaces/ws1/entities/size/values",
+			"POST /v1/workspaces/ws1/entities/size/values/small/synonyms",
+			"POST /v1/workspaces/ws1/intents",
+			"POST /v1/workspaces/ws1/intents/order/examples",
+			"POST /v1/workspaces/ws1",
+		}))
+		Expect(statusPolls).To(Equal(2))
+	})
+	It(`Leaves out workspace fields missing from the desired workspace and rejects language changes`, func() {
+		current := &assistantv1.Workspace{
+			Name:     core.StringPtr("pizza"),
+			Language: core.StringPtr("en"),
+			Metadata: map[string]interface{}{"owner": "ops"},
+			Webhooks: []assistantv1.Webhook{{Name: core.StringPtr("main"), URL: core.StringPtr("https://example.com")}},
+		}
+		Expect(assistantv1.DiffWorkspaces(current, &assistantv1.Workspace{Name: core.StringPtr("pizza")}).IsEmpty()).To(BeTrue())
+		Expect(assistantv1.DiffWorkspaces(current, nil).IsEmpty()).To(BeTrue())
+
+		current.Intents = []assistantv1.Intent{{Intent: core.StringPtr("order")}}
+		current.DialogNodes = []assistantv1.DialogNode{{DialogNode: core.StringPtr("welcome")}}
+		Expect(assistantv1.DiffWorkspaces(current, &assistantv1.Workspace{}).IsEmpty()).To(BeTrue())
+		Expect(assistantv1.DiffWorkspaces(current, &assistantv1.Workspace{Intents: []assistantv1.Intent{}}).Plan()).
+			To(HavePrefix("- intent #order\n"))
+
+		desired, err := assistantv1.LoadWorkspaceDir(dir)
+		Expect(err).To(BeNil())
+		desired.Language = core.StringPtr("fr")
+		_, err = newSync().Apply(context.Background(), desired)
+		Expect(err).To(MatchError("~ workspace language: the language of an existing workspace cannot be changed"))
+		Expect(requests).To(BeEmpty())
+	})
+})
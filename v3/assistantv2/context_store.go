@@ -0,0 +1,444 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+var (
+	// ErrContextNotFound is returned by a ContextStore when no context is stored for a conversation key.
+	ErrContextNotFound = errors.New("assistantv2: no context stored for conversation key")
+
+	// ErrContextConflict is returned by a ContextStore when the stored revision no longer matches the expected one,
+	// meaning that another turn of the same conversation saved its context first.
+	ErrContextConflict = errors.New("assistantv2: context was modified by a concurrent turn")
+)
+
+// StoredContext : A stateless conversation context together with the revision it was stored under.
+type StoredContext struct {
+	// The context returned by the last successful turn of the conversation.
+	Context *MessageContextStateless
+
+	// The revision of the stored context. Revisions start at 1 and are incremented by every save.
+	Revision int64
+}
+
+// ContextStore : Persists MessageContextStateless values between turns of a stateless conversation.
+//
+// Implementations must be safe for concurrent use and must implement optimistic concurrency: Save only succeeds when
+// the revision currently stored for the key equals expectedRevision (0 meaning that nothing is stored yet), and fails
+// with ErrContextConflict otherwise. Users can plug in their own implementations (for example backed by Redis or a
+// SQL database) by satisfying this interface.
+type ContextStore interface {
+	// Load returns the context stored for the key, or ErrContextNotFound, which may be wrapped.
+	Load(ctx context.Context, key string) (*StoredContext, error)
+
+	// Save stores the context for the key and returns its new revision, or ErrContextConflict, which may be wrapped.
+	Save(ctx context.Context, key string, messageContext *MessageContextStateless, expectedRevision int64) (int64, error)
+
+	// Delete removes the context stored for the key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// MarshalContextStateless serializes a stateless context to JSON, preserving additional skill system properties.
+func MarshalContextStateless(messageContext *MessageContextStateless) ([]byte, error) {
+	return json.Marshal(messageContext)
+}
+
+// UnmarshalContextStateless deserializes a stateless context previously serialized with MarshalContextStateless.
+func UnmarshalContextStateless(data []byte) (messageContext *MessageContextStateless, err error) {
+	var rawContext map[string]json.RawMessage
+	err = json.Unmarshal(data, &rawContext)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(rawContext, "", &messageContext, UnmarshalMessageContextStateless)
+	return
+}
+
+type memoryContextEntry struct {
+	data     []byte
+	revision int64
+}
+
+// MemoryContextStore : A ContextStore that keeps contexts in process memory. It is intended for tests and for
+// single-instance deployments.
+type MemoryContextStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryContextEntry
+}
+
+// NewMemoryContextStore : Instantiate MemoryContextStore
+func NewMemoryContextStore() *MemoryContextStore {
+	return &MemoryContextStore{
+		entries: make(map[string]memoryContextEntry),
+	}
+}
+
+// Load returns the context stored for the key, or ErrContextNotFound.
+func (store *MemoryContextStore) Load(ctx context.Context, key string) (*StoredContext, error) {
+	store.mutex.Lock()
+	entry, ok := store.entries[key]
+	store.mutex.Unlock()
+	if !ok {
+		return nil, ErrContextNotFound
+	}
+
+	messageContext, err := UnmarshalContextStateless(entry.data)
+	if err != nil {
+		return nil, err
+	}
+	return &StoredContext{Context: messageContext, Revision: entry.revision}, nil
+}
+
+// Save stores the context for the key if expectedRevision matches the stored revision.
+func (store *MemoryContextStore) Save(ctx context.Context, key string, messageContext *MessageContextStateless, expectedRevision int64) (int64, error) {
+	data, err := MarshalContextStateless(messageContext)
+	if err != nil {
+		return 0, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.entries[key].revision != expectedRevision {
+		return 0, ErrContextConflict
+	}
+	revision := expectedRevision + 1
+	store.entries[key] = memoryContextEntry{data: data, revision: revision}
+	return revision, nil
+}
+
+// Delete removes the context stored for the key.
+func (store *MemoryContextStore) Delete(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	delete(store.entries, key)
+	store.mutex.Unlock()
+	return nil
+}
+
+// FileContextStore : A ContextStore that keeps one JSON file per conversation key in a directory. Saves are guarded by
+// a lock file, so several processes sharing the directory (for example over a network file system) can use it safely.
+type FileContextStore struct {
+	// The directory that holds the context files.
+	Dir string
+
+	// How long Save waits between attempts to acquire a held lock. Defaults to 10 milliseconds.
+	LockRetryInterval time.Duration
+
+	// Lock files older than this are considered abandoned by a crashed process and are taken over. A held lock is
+	// touched every third of this time, so only a lock whose holder stopped is ever older. A stale lock is first
+	// renamed to a unique name, so that only one waiter takes it over, and is put back if it turns out to be a new
+	// lock created since it was found stale. Defaults to 30 seconds.
+	StaleLockTimeout time.Duration
+}
+
+type fileContextEntry struct {
+	Revision int64           `json:"revision"`
+	Context  json.RawMessage `json:"context"`
+}
+
+// NewFileContextStore : Instantiate FileContextStore, creating the directory if it does not exist
+func NewFileContextStore(dir string) (*FileContextStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &FileContextStore{
+		Dir:               dir,
+		LockRetryInterval: 10 * time.Millisecond,
+		StaleLockTimeout:  30 * time.Second,
+	}, nil
+}
+
+func (store *FileContextStore) path(key string) string {
+	// Keys are hex encoded so that any conversation key maps to a valid, unique file name.
+	return filepath.Join(store.Dir, hex.EncodeToString([]byte(key))+".json")
+}
+
+func (store *FileContextStore) read(key string) (*fileContextEntry, error) {
+	data, err := ioutil.ReadFile(store.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrContextNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := new(fileContextEntry)
+	err = json.Unmarshal(data, entry)
+	if err != nil {
+		return nil, fmt.Errorf("assistantv2: corrupt context file for key %q: %s", key, err.Error())
+	}
+	return entry, nil
+}
+
+// Load returns the context stored for the key, or ErrContextNotFound.
+func (store *FileContextStore) Load(ctx context.Context, key string) (*StoredContext, error) {
+	entry, err := store.read(key)
+	if err != nil {
+		return nil, err
+	}
+	messageContext, err := UnmarshalContextStateless(entry.Context)
+	if err != nil {
+		return nil, err
+	}
+	return &StoredContext{Context: messageContext, Revision: entry.Revision}, nil
+}
+
+// Save stores the context for the key if expectedRevision matches the stored revision.
+func (store *FileContextStore) Save(ctx context.Context, key string, messageContext *MessageContextStateless, expectedRevision int64) (int64, error) {
+	data, err := MarshalContextStateless(messageContext)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := store.lock(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var currentRevision int64
+	current, err := store.read(key)
+	if err == nil {
+		currentRevision = current.Revision
+	} else if !errors.Is(err, ErrContextNotFound) {
+		return 0, err
+	}
+	if currentRevision != expectedRevision {
+		return 0, ErrContextConflict
+	}
+
+	revision := expectedRevision + 1
+	entryData, err := json.Marshal(&fileContextEntry{Revision: revision, Context: data})
+	if err != nil {
+		return 0, err
+	}
+
+	// Write to a temporary file and rename it so that readers never observe a partially written context.
+	tmpFile, err := ioutil.TempFile(store.Dir, ".context-*")
+	if err != nil {
+		return 0, err
+	}
+	_, err = tmpFile.Write(entryData)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), store.path(key))
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return 0, err
+	}
+	return revision, nil
+}
+
+// Delete removes the context stored for the key.
+func (store *FileContextStore) Delete(ctx context.Context, key string) error {
+	unlock, err := store.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(store.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (store *FileContextStore) lock(ctx context.Context, key string) (unlock func(), err error) {
+	lockPath := store.path(key) + ".lock"
+	retryInterval := store.LockRetryInterval
+	if retryInterval <= 0 {
+		retryInterval = 10 * time.Millisecond
+	}
+	for {
+		lockFile, openErr := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if openErr == nil {
+			lockFile.Close()
+			return store.holdLock(lockPath), nil
+		}
+		if !os.IsExist(openErr) {
+			return nil, openErr
+		}
+
+		if store.StaleLockTimeout > 0 {
+			info, statErr := os.Stat(lockPath)
+			if statErr == nil && time.Since(info.ModTime()) > store.StaleLockTimeout {
+				if err := store.removeStaleLock(lockPath, info); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// holdLock keeps the acquired lock file from becoming stale, for example while a save is slowed down by a loaded
+// machine or network file system, and returns the function that releases the lock.
+func (store *FileContextStore) holdLock(lockPath string) (unlock func()) {
+	if store.StaleLockTimeout <= 0 {
+		return func() { os.Remove(lockPath) }
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(store.StaleLockTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				os.Chtimes(lockPath, now, now)
+			}
+		}
+	}()
+	return func() {
+		// The lock is only removed once it is no longer touched, so that a lock created after it is never touched.
+		close(done)
+		<-stopped
+		os.Remove(lockPath)
+	}
+}
+
+// removeStaleLock removes the lock file found stale with the given info. Removing the lock path directly would race
+// with other waiters: one of them could remove the lock that another has just created in its place. Instead the lock
+// is moved to a unique name, which only one waiter can do, and is removed only if it is the stale file; a newer lock
+// is put back unless yet another lock has been created meanwhile.
+func (store *FileContextStore) removeStaleLock(lockPath string, stale os.FileInfo) error {
+	tmpFile, err := ioutil.TempFile(store.Dir, ".lock-*")
+	if err != nil {
+		return err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if err := os.Rename(lockPath, tmpFile.Name()); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	moved, err := os.Stat(tmpFile.Name())
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(moved, stale) {
+		if err := os.Link(tmpFile.Name(), lockPath); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// StatelessConversation : Wraps AssistantV2.MessageStateless so that the conversation context is loaded from and
+// saved to a ContextStore by conversation key, which allows horizontally scaled applications to handle successive
+// turns of the same conversation on different instances.
+type StatelessConversation struct {
+	Assistant *AssistantV2
+	Store     ContextStore
+
+	// Unique identifier of the assistant.
+	AssistantID string
+
+	// How many times a turn is retried when another turn of the same conversation saved its context first. Each retry
+	// reloads the newer context and sends the message again. Defaults to 0, in which case ErrContextConflict is returned
+	// to the caller.
+	MaxConflictRetries int
+}
+
+// NewStatelessConversation : Instantiate StatelessConversation
+func (assistant *AssistantV2) NewStatelessConversation(assistantID string, store ContextStore) *StatelessConversation {
+	return &StatelessConversation{
+		Assistant:   assistant,
+		Store:       store,
+		AssistantID: assistantID,
+	}
+}
+
+// Message : Send user input for the conversation identified by key, using and updating its stored context
+// If no context is stored yet, the context set in the options (if any) starts the conversation.
+func (conversation *StatelessConversation) Message(key string, messageStatelessOptions *MessageStatelessOptions) (result *MessageResponseStateless, response *core.DetailedResponse, err error) {
+	return conversation.MessageWithContext(context.Background(), key, messageStatelessOptions)
+}
+
+// MessageWithContext is an alternate form of the Message method which supports a Context parameter
+func (conversation *StatelessConversation) MessageWithContext(ctx context.Context, key string, messageStatelessOptions *MessageStatelessOptions) (result *MessageResponseStateless, response *core.DetailedResponse, err error) {
+	if messageStatelessOptions == nil {
+		messageStatelessOptions = new(MessageStatelessOptions)
+	}
+	options := *messageStatelessOptions
+	if options.AssistantID == nil {
+		options.AssistantID = core.StringPtr(conversation.AssistantID)
+	}
+	initialContext := options.Context
+
+	for attempt := 0; ; attempt++ {
+		var revision int64
+		stored, loadErr := conversation.Store.Load(ctx, key)
+		switch {
+		case loadErr == nil:
+			options.Context = stored.Context
+			revision = stored.Revision
+		case errors.Is(loadErr, ErrContextNotFound):
+			options.Context = initialContext
+		default:
+			err = loadErr
+			return
+		}
+
+		result, response, err = conversation.Assistant.MessageStatelessWithContext(ctx, &options)
+		if err != nil {
+			return
+		}
+		if result.Context == nil {
+			return
+		}
+
+		_, err = conversation.Store.Save(ctx, key, result.Context, revision)
+		if !errors.Is(err, ErrContextConflict) || attempt >= conversation.MaxConflictRetries {
+			return
+		}
+	}
+}
+
+// Reset : Forget the stored context of the conversation identified by key
+func (conversation *StatelessConversation) Reset(ctx context.Context, key string) error {
+	return conversation.Store.Delete(ctx, key)
+}
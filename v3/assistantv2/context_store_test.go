@@ -0,0 +1,247 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`ContextStore`, func() {
+	newContext := func(turn int64) *assistantv2.MessageContextStateless {
+		system := &assistantv2.MessageContextSkillSystem{State: core.StringPtr("state")}
+		system.SetProperty("extra", "value")
+		return &assistantv2.MessageContextStateless{
+			Global: &assistantv2.MessageContextGlobalStateless{
+				System: &assistantv2.MessageContextGlobalSystem{TurnCount: core.Int64Ptr(turn)},
+			},
+			Skills: map[string]assistantv2.MessageContextSkill{
+				"main skill": {UserDefined: map[string]interface{}{"name": "Ann"}, System: system},
+			},
+		}
+	}
+
+	testStore := func(store assistantv2.ContextStore) {
+		ctx := context.Background()
+
+		_, err := store.Load(ctx, "conversation/1")
+		Expect(err).To(Equal(assistantv2.ErrContextNotFound))
+
+		revision, err := store.Save(ctx, "conversation/1", newContext(1), 0)
+		Expect(err).To(BeNil())
+		Expect(revision).To(Equal(int64(1)))
+
+		stored, err := store.Load(ctx, "conversation/1")
+		Expect(err).To(BeNil())
+		Expect(stored.Revision).To(Equal(int64(1)))
+		Expect(*stored.Context.Global.System.TurnCount).To(Equal(int64(1)))
+		Expect(stored.Context.Skills["main skill"].UserDefined["name"]).To(Equal("Ann"))
+		Expect(stored.Context.Skills["main skill"].System.GetProperty("extra")).To(Equal("value"))
+
+		_, err = store.Save(ctx, "conversation/1", newContext(2), 0)
+		Expect(err).To(Equal(assistantv2.ErrContextConflict))
+
+		revision, err = store.Save(ctx, "conversation/1", newContext(2), 1)
+		Expect(err).To(BeNil())
+		Expect(revision).To(Equal(int64(2)))
+
+		Expect(store.Delete(ctx, "conversation/1")).To(BeNil())
+		Expect(store.Delete(ctx, "conversation/1")).To(BeNil())
+		_, err = store.Load(ctx, "conversation/1")
+		Expect(err).To(Equal(assistantv2.ErrContextNotFound))
+	}
+
+	It(`MemoryContextStore supports optimistic concurrency`, func() {
+		testStore(assistantv2.NewMemoryContextStore())
+	})
+	It(`FileContextStore supports optimistic concurrency`, func() {
+		dir, err := ioutil.TempDir("", "context-store")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		store, err := assistantv2.NewFileContextStore(dir)
+		Expect(err).To(BeNil())
+		testStore(store)
+	})
+
+	It(`FileContextStore takes over stale locks`, func() {
+		dir, err := ioutil.TempDir("", "context-store")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		store, err := assistantv2.NewFileContextStore(dir)
+		Expect(err).To(BeNil())
+		store.StaleLockTimeout = time.Minute
+		lockPath := filepath.Join(dir, hex.EncodeToString([]byte("k"))+".json.lock")
+		Expect(ioutil.WriteFile(lockPath, nil, 0600)).To(Succeed())
+		old := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(lockPath, old, old)).To(Succeed())
+
+		_, err = store.Save(context.Background(), "k", newContext(1), 0)
+		Expect(err).To(BeNil())
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(Equal(hex.EncodeToString([]byte("k")) + ".json"))
+	})
+
+	Describe(`StatelessConversation`, func() {
+		var testServer *httptest.Server
+		var requestContexts []map[string]interface{}
+
+		BeforeEach(func() {
+			requestContexts = nil
+			testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				defer GinkgoRecover()
+
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				requestContexts = append(requestContexts, nil)
+				turn := 1
+				if requestContext, ok := body["context"].(map[string]interface{}); ok {
+					requestContexts[len(requestContexts)-1] = requestContext
+					turn = int(requestContext["global"].(map[string]interface{})["system"].(map[string]interface{})["turn_count"].(float64)) + 1
+				}
+
+				res.Header().Set("Content-type", "application/json")
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"output": {"generic": []}, "context": {"global": {"system": {"turn_count": %d}}}}`, turn)
+			}))
+		})
+		AfterEach(func() {
+			testServer.Close()
+		})
+
+		It(`Loads and saves the context between turns`, func() {
+			assistantService, serviceErr := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+				URL:           testServer.URL,
+				Authenticator: &core.NoAuthAuthenticator{},
+				Version:       core.StringPtr("testString"),
+			})
+			Expect(serviceErr).To(BeNil())
+
+			store := assistantv2.NewMemoryContextStore()
+			conversation := assistantService.NewStatelessConversation("testString", store)
+
+			for i := 1; i <= 3; i++ {
+				options := assistantService.NewMessageStatelessOptions("testString")
+				options.SetInput(&assistantv2.MessageInputStateless{Text: core.StringPtr("hello")})
+				result, response, err := conversation.Message("user-1", options)
+				Expect(err).To(BeNil())
+				Expect(response).ToNot(BeNil())
+				Expect(*result.Context.Global.System.TurnCount).To(Equal(int64(i)))
+			}
+			Expect(requestContexts[0]).To(BeNil())
+			Expect(requestContexts[2]).ToNot(BeNil())
+
+			stored, err := store.Load(context.Background(), "user-1")
+			Expect(err).To(BeNil())
+			Expect(stored.Revision).To(Equal(int64(3)))
+			Expect(*stored.Context.Global.System.TurnCount).To(Equal(int64(3)))
+
+			Expect(conversation.Reset(context.Background(), "user-1")).To(BeNil())
+			_, err = store.Load(context.Background(), "user-1")
+			Expect(err).To(Equal(assistantv2.ErrContextNotFound))
+		})
+		It(`Reports conflicting turns`, func() {
+			assistantService, serviceErr := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+				URL:           testServer.URL,
+				Authenticator: &core.NoAuthAuthenticator{},
+				Version:       core.StringPtr("testString"),
+			})
+			Expect(serviceErr).To(BeNil())
+
+			store := &racingContextStore{ContextStore: assistantv2.NewMemoryContextStore()}
+			conversation := assistantService.NewStatelessConversation("testString", store)
+			_, _, err := conversation.Message("user-1", nil)
+			Expect(err).To(Equal(assistantv2.ErrContextConflict))
+
+			store.raced = false
+			conversation.MaxConflictRetries = 1
+			result, _, err := conversation.Message("user-1", nil)
+			Expect(err).To(BeNil())
+			Expect(*result.Context.Global.System.TurnCount).To(Equal(int64(3)))
+		})
+		It(`Recognizes wrapped store errors`, func() {
+			assistantService, serviceErr := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+				URL:           testServer.URL,
+				Authenticator: &core.NoAuthAuthenticator{},
+				Version:       core.StringPtr("testString"),
+			})
+			Expect(serviceErr).To(BeNil())
+
+			store := &wrappingContextStore{&racingContextStore{ContextStore: assistantv2.NewMemoryContextStore()}}
+			conversation := assistantService.NewStatelessConversation("testString", store)
+			conversation.MaxConflictRetries = 1
+			result, _, err := conversation.Message("user-1", nil)
+			Expect(err).To(BeNil())
+			Expect(*result.Context.Global.System.TurnCount).To(Equal(int64(2)))
+			Expect(requestContexts[0]).To(BeNil())
+		})
+	})
+})
+
+// racingContextStore simulates a concurrent turn that saves its context between Load and Save, once.
+type racingContextStore struct {
+	assistantv2.ContextStore
+	raced bool
+}
+
+func (store *racingContextStore) Save(ctx context.Context, key string, messageContext *assistantv2.MessageContextStateless, expectedRevision int64) (int64, error) {
+	if !store.raced {
+		store.raced = true
+		_, err := store.ContextStore.Save(ctx, key, messageContext, expectedRevision)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return store.ContextStore.Save(ctx, key, messageContext, expectedRevision)
+}
+
+// wrappingContextStore wraps the errors of a store, as stores backed by other systems may.
+type wrappingContextStore struct {
+	assistantv2.ContextStore
+}
+
+func (store *wrappingContextStore) Load(ctx context.Context, key string) (*assistantv2.StoredContext, error) {
+	stored, err := store.ContextStore.Load(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", key, err)
+	}
+	return stored, nil
+}
+
+func (store *wrappingContextStore) Save(ctx context.Context, key string, messageContext *assistantv2.MessageContextStateless, expectedRevision int64) (int64, error) {
+	revision, err := store.ContextStore.Save(ctx, key, messageContext, expectedRevision)
+	if err != nil {
+		return 0, fmt.Errorf("saving %s: %w", key, err)
+	}
+	return revision, nil
+}
@@ -0,0 +1,250 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The names of the skills in the skills of a message context. The variables of both are accessed the same way.
+const (
+	DialogSkillName  = "main skill"
+	ActionsSkillName = "actions skill"
+)
+
+// decodeVariables converts a value, such as a variables map, into a user-supplied struct or other value through its
+// JSON representation, so that the json tags of the struct select the variables.
+func decodeVariables(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// encodeVariables converts a struct or map into a variables map through its JSON representation.
+func encodeVariables(source interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	var variables map[string]interface{}
+	err = json.Unmarshal(data, &variables)
+	if err != nil {
+		return nil, fmt.Errorf("variables must encode to a JSON object: %s", err.Error())
+	}
+	return variables, nil
+}
+
+// setSkillVariables merges variables into the user-defined variables of a skill, creating the skills map and the
+// skill as needed.
+func setSkillVariables(skills *map[string]MessageContextSkill, skill string, variables map[string]interface{}) {
+	if *skills == nil {
+		*skills = make(map[string]MessageContextSkill)
+	}
+	skillContext := (*skills)[skill]
+	if skillContext.UserDefined == nil {
+		skillContext.UserDefined = make(map[string]interface{})
+	}
+	for name, value := range variables {
+		skillContext.UserDefined[name] = value
+	}
+	(*skills)[skill] = skillContext
+}
+
+func setSkillSystemVariable(skills *map[string]MessageContextSkill, skill string, name string, value interface{}) {
+	if *skills == nil {
+		*skills = make(map[string]MessageContextSkill)
+	}
+	skillContext := (*skills)[skill]
+	if skillContext.System == nil {
+		skillContext.System = new(MessageContextSkillSystem)
+	}
+	if name == "state" {
+		state, _ := value.(string)
+		skillContext.System.State = &state
+	} else {
+		skillContext.System.SetProperty(name, value)
+	}
+	(*skills)[skill] = skillContext
+}
+
+func getSkillSystemVariable(skills map[string]MessageContextSkill, skill string, name string) interface{} {
+	system := skills[skill].System
+	if system == nil {
+		return nil
+	}
+	if name == "state" {
+		if system.State == nil {
+			return nil
+		}
+		return *system.State
+	}
+	return system.GetProperty(name)
+}
+
+// mergeSkills merges the skills of a context update into local skills: user-defined variables are merged one by one,
+// and the system context of a skill is replaced when the update includes one.
+func mergeSkills(skills *map[string]MessageContextSkill, update map[string]MessageContextSkill) {
+	for skill, skillUpdate := range update {
+		setSkillVariables(skills, skill, skillUpdate.UserDefined)
+		if skillUpdate.System != nil {
+			skillContext := (*skills)[skill]
+			skillContext.System = skillUpdate.System
+			(*skills)[skill] = skillContext
+		}
+	}
+}
+
+// GetSkillVariable returns a user-defined variable of a skill, such as DialogSkillName or ActionsSkillName, and
+// whether it is set.
+func (messageContext *MessageContext) GetSkillVariable(skill string, name string) (interface{}, bool) {
+	value, ok := messageContext.Skills[skill].UserDefined[name]
+	return value, ok
+}
+
+// DecodeSkillVariable decodes a user-defined variable of a skill into target, which is typically a pointer to a
+// struct with json tags.
+func (messageContext *MessageContext) DecodeSkillVariable(skill string, name string, target interface{}) error {
+	value, ok := messageContext.GetSkillVariable(skill, name)
+	if !ok {
+		return fmt.Errorf("skill variable %s is not set in %s", name, skill)
+	}
+	return decodeVariables(value, target)
+}
+
+// DecodeSkillVariables decodes all user-defined variables of a skill into target, typically a pointer to a struct
+// whose json tags name the variables.
+func (messageContext *MessageContext) DecodeSkillVariables(skill string, target interface{}) error {
+	return decodeVariables(messageContext.Skills[skill].UserDefined, target)
+}
+
+// SetSkillVariable sets a user-defined variable of a skill, creating the skill context as needed.
+func (messageContext *MessageContext) SetSkillVariable(skill string, name string, value interface{}) {
+	setSkillVariables(&messageContext.Skills, skill, map[string]interface{}{name: value})
+}
+
+// SetSkillVariables sets the user-defined variables of a skill from a struct with json tags or a map. Variables that
+// are not part of source are left unchanged.
+func (messageContext *MessageContext) SetSkillVariables(skill string, source interface{}) error {
+	variables, err := encodeVariables(source)
+	if err != nil {
+		return err
+	}
+	setSkillVariables(&messageContext.Skills, skill, variables)
+	return nil
+}
+
+// DeleteSkillVariable removes a user-defined variable of a skill.
+func (messageContext *MessageContext) DeleteSkillVariable(skill string, name string) {
+	delete(messageContext.Skills[skill].UserDefined, name)
+}
+
+// GetSkillSystemVariable returns a system variable of a skill, such as "state", or nil when it is not set.
+func (messageContext *MessageContext) GetSkillSystemVariable(skill string, name string) interface{} {
+	return getSkillSystemVariable(messageContext.Skills, skill, name)
+}
+
+// SetSkillSystemVariable sets a system variable of a skill.
+func (messageContext *MessageContext) SetSkillSystemVariable(skill string, name string, value interface{}) {
+	setSkillSystemVariable(&messageContext.Skills, skill, name, value)
+}
+
+// Merge applies a context returned by Message to this context. Skill variables are merged one by one, so variables
+// set locally and not returned by the service are kept; global and integration context is replaced when the update
+// includes it.
+func (messageContext *MessageContext) Merge(update *MessageContext) {
+	if update == nil {
+		return
+	}
+	if update.Global != nil {
+		messageContext.Global = update.Global
+	}
+	if update.Integrations != nil {
+		messageContext.Integrations = update.Integrations
+	}
+	mergeSkills(&messageContext.Skills, update.Skills)
+}
+
+// GetSkillVariable returns a user-defined variable of a skill, such as DialogSkillName or ActionsSkillName, and
+// whether it is set.
+func (messageContext *MessageContextStateless) GetSkillVariable(skill string, name string) (interface{}, bool) {
+	value, ok := messageContext.Skills[skill].UserDefined[name]
+	return value, ok
+}
+
+// DecodeSkillVariable decodes a user-defined variable of a skill into target, which is typically a pointer to a
+// struct with json tags.
+func (messageContext *MessageContextStateless) DecodeSkillVariable(skill string, name string, target interface{}) error {
+	value, ok := messageContext.GetSkillVariable(skill, name)
+	if !ok {
+		return fmt.Errorf("skill variable %s is not set in %s", name, skill)
+	}
+	return decodeVariables(value, target)
+}
+
+// DecodeSkillVariables decodes all user-defined variables of a skill into target, typically a pointer to a struct
+// whose json tags name the variables.
+func (messageContext *MessageContextStateless) DecodeSkillVariables(skill string, target interface{}) error {
+	return decodeVariables(messageContext.Skills[skill].UserDefined, target)
+}
+
+// SetSkillVariable sets a user-defined variable of a skill, creating the skill context as needed.
+func (messageContext *MessageContextStateless) SetSkillVariable(skill string, name string, value interface{}) {
+	setSkillVariables(&messageContext.Skills, skill, map[string]interface{}{name: value})
+}
+
+// SetSkillVariables sets the user-defined variables of a skill from a struct with json tags or a map. Variables that
+// are not part of source are left unchanged.
+func (messageContext *MessageContextStateless) SetSkillVariables(skill string, source interface{}) error {
+	variables, err := encodeVariables(source)
+	if err != nil {
+		return err
+	}
+	setSkillVariables(&messageContext.Skills, skill, variables)
+	return nil
+}
+
+// DeleteSkillVariable removes a user-defined variable of a skill.
+func (messageContext *MessageContextStateless) DeleteSkillVariable(skill string, name string) {
+	delete(messageContext.Skills[skill].UserDefined, name)
+}
+
+// GetSkillSystemVariable returns a system variable of a skill, such as "state", or nil when it is not set.
+func (messageContext *MessageContextStateless) GetSkillSystemVariable(skill string, name string) interface{} {
+	return getSkillSystemVariable(messageContext.Skills, skill, name)
+}
+
+// SetSkillSystemVariable sets a system variable of a skill.
+func (messageContext *MessageContextStateless) SetSkillSystemVariable(skill string, name string, value interface{}) {
+	setSkillSystemVariable(&messageContext.Skills, skill, name, value)
+}
+
+// Merge applies a context returned by MessageStateless to this context, in the same way as MessageContext.Merge.
+func (messageContext *MessageContextStateless) Merge(update *MessageContextStateless) {
+	if update == nil {
+		return
+	}
+	if update.Global != nil {
+		messageContext.Global = update.Global
+	}
+	if update.Integrations != nil {
+		messageContext.Integrations = update.Integrations
+	}
+	mergeSkills(&messageContext.Skills, update.Skills)
+}
@@ -0,0 +1,90 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`Skill variables`, func() {
+	type order struct {
+		Size     string   `json:"size"`
+		Toppings []string `json:"toppings,omitempty"`
+	}
+
+	It(`Gets and sets variables on an empty context`, func() {
+		messageContext := &assistantv2.MessageContext{}
+		_, ok := messageContext.GetSkillVariable(assistantv2.DialogSkillName, "order")
+		Expect(ok).To(BeFalse())
+		Expect(messageContext.GetSkillSystemVariable(assistantv2.ActionsSkillName, "state")).To(BeNil())
+
+		Expect(messageContext.SetSkillVariables(assistantv2.DialogSkillName, struct {
+			Order order `json:"order"`
+		}{order{Size: "large", Toppings: []string{"ham"}}})).To(Succeed())
+		messageContext.SetSkillVariable(assistantv2.ActionsSkillName, "count", 2)
+		messageContext.SetSkillSystemVariable(assistantv2.DialogSkillName, "state", "abc")
+		messageContext.SetSkillSystemVariable(assistantv2.DialogSkillName, "extra", 1)
+
+		var decoded order
+		Expect(messageContext.DecodeSkillVariable(assistantv2.DialogSkillName, "order", &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(order{Size: "large", Toppings: []string{"ham"}}))
+		Expect(messageContext.DecodeSkillVariable(assistantv2.DialogSkillName, "missing", &decoded)).ToNot(Succeed())
+		count, ok := messageContext.GetSkillVariable(assistantv2.ActionsSkillName, "count")
+		Expect(ok).To(BeTrue())
+		Expect(count).To(Equal(2))
+		Expect(messageContext.GetSkillSystemVariable(assistantv2.DialogSkillName, "state")).To(Equal("abc"))
+
+		data, err := json.Marshal(messageContext)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(MatchJSON(`{"skills": {
+			"main skill": {"user_defined": {"order": {"size": "large", "toppings": ["ham"]}}, "system": {"state": "abc", "extra": 1}},
+			"actions skill": {"user_defined": {"count": 2}}
+		}}`))
+
+		messageContext.DeleteSkillVariable(assistantv2.ActionsSkillName, "count")
+		_, ok = messageContext.GetSkillVariable(assistantv2.ActionsSkillName, "count")
+		Expect(ok).To(BeFalse())
+	})
+	It(`Merges context updates from the service`, func() {
+		local := &assistantv2.MessageContextStateless{}
+		local.SetSkillVariable(assistantv2.DialogSkillName, "local_only", true)
+		local.SetSkillVariable(assistantv2.DialogSkillName, "size", "small")
+
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(`{
+			"global": {"session_id": "s1"},
+			"skills": {"main skill": {"user_defined": {"size": "large"}, "system": {"state": "xyz"}}}
+		}`), &raw)).To(Succeed())
+		var update *assistantv2.MessageContextStateless
+		Expect(assistantv2.UnmarshalMessageContextStateless(raw, &update)).To(Succeed())
+		local.Merge(update)
+
+		var decoded struct {
+			Size      string `json:"size"`
+			LocalOnly bool   `json:"local_only"`
+		}
+		Expect(local.DecodeSkillVariables(assistantv2.DialogSkillName, &decoded)).To(Succeed())
+		Expect(decoded.Size).To(Equal("large"))
+		Expect(decoded.LocalOnly).To(BeTrue())
+		Expect(*local.Global.SessionID).To(Equal("s1"))
+		Expect(local.GetSkillSystemVariable(assistantv2.DialogSkillName, "state")).To(Equal("xyz"))
+	})
+})
@@ -0,0 +1,451 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"gopkg.in/yaml.v3"
+)
+
+// ConversationScenario : A scripted conversation: the user inputs of each turn and what the assistant is expected to
+// do in response.
+type ConversationScenario struct {
+	Name string `json:"name"`
+
+	// Whether the conversation uses the stateless message method instead of a session.
+	Stateless bool `json:"stateless,omitempty"`
+
+	UserID string                     `json:"user_id,omitempty"`
+	Turns  []ConversationScenarioTurn `json:"turns"`
+}
+
+// ConversationScenarioTurn : One user input and the expectations for the response.
+type ConversationScenarioTurn struct {
+	Input  string                  `json:"input"`
+	Expect ConversationExpectation `json:"expect"`
+}
+
+// ConversationExpectation : The assertions made about a response. Empty fields are not checked.
+type ConversationExpectation struct {
+	// The top intent, and its minimum confidence.
+	Intent        string  `json:"intent,omitempty"`
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+
+	// Dialog nodes, given by ID or title, that must be visited in this order. Other nodes may be visited in between.
+	NodesVisited []string `json:"nodes_visited,omitempty"`
+
+	// A regular expression that must match the text responses, joined by newlines.
+	Text string `json:"text,omitempty"`
+
+	// Skill variables that must have the given values after the turn. Skill defaults to DialogSkillName.
+	Skill     string                 `json:"skill,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// LoadConversationScenarios reads scenarios from JSON or YAML files (chosen by the .json, .yaml or .yml extension).
+// A file holds either a single scenario or a list of scenarios.
+func LoadConversationScenarios(paths ...string) ([]ConversationScenario, error) {
+	var scenarios []ConversationScenario
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var document interface{}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &document)
+			if err == nil {
+				data, err = json.Marshal(document)
+			}
+		case ".json":
+			err = json.Unmarshal(data, &document)
+		default:
+			err = fmt.Errorf("unsupported file extension, expected .json, .yaml or .yml")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading scenario file %s: %s", path, err.Error())
+		}
+
+		if _, isList := document.([]interface{}); isList {
+			var list []ConversationScenario
+			err = json.Unmarshal(data, &list)
+			scenarios = append(scenarios, list...)
+		} else {
+			var scenario ConversationScenario
+			err = json.Unmarshal(data, &scenario)
+			scenarios = append(scenarios, scenario)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading scenario file %s: %s", path, err.Error())
+		}
+	}
+	return scenarios, nil
+}
+
+// ConversationTurnFailure : An expectation that a turn did not meet.
+type ConversationTurnFailure struct {
+	// The 1-based number of the turn.
+	Turn    int    `json:"turn"`
+	Input   string `json:"input"`
+	Message string `json:"message"`
+}
+
+// ConversationScenarioResult : The outcome of running one scenario.
+type ConversationScenarioResult struct {
+	Name     string                    `json:"name"`
+	Duration time.Duration             `json:"duration"`
+	Failures []ConversationTurnFailure `json:"failures,omitempty"`
+
+	// Set when the scenario could not be run to the end, for example because a request failed.
+	Err error `json:"-"`
+}
+
+// Passed reports whether every expectation was met.
+func (result *ConversationScenarioResult) Passed() bool {
+	return result.Err == nil && len(result.Failures) == 0
+}
+
+// ConversationTestReport : The outcome of running a set of scenarios.
+type ConversationTestReport struct {
+	Name    string
+	Results []ConversationScenarioResult
+}
+
+// Passed reports whether every scenario passed.
+func (report *ConversationTestReport) Passed() bool {
+	for i := range report.Results {
+		if !report.Results[i].Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// WriteJUnitXML writes the report in the JUnit XML format understood by CI servers. Each scenario is a test case.
+func (report *ConversationTestReport) WriteJUnitXML(w io.Writer) error {
+	name := report.Name
+	if name == "" {
+		name = "conversation tests"
+	}
+	suite := junitTestSuite{Name: name, Tests: len(report.Results)}
+	var total time.Duration
+	for _, result := range report.Results {
+		total += result.Duration
+		testCase := junitTestCase{
+			Name:      result.Name,
+			Classname: name,
+			Time:      fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+		if result.Err != nil {
+			suite.Errors++
+			testCase.Error = &junitFailure{Message: result.Err.Error()}
+		} else if len(result.Failures) > 0 {
+			suite.Failures++
+			var lines []string
+			for _, failure := range result.Failures {
+				lines = append(lines, fmt.Sprintf("turn %d (%q): %s", failure.Turn, failure.Input, failure.Message))
+			}
+			testCase.Failure = &junitFailure{Message: result.Failures[0].Message, Text: strings.Join(lines, "\n")}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ConversationTestRunner : Runs conversation scenarios against an assistant. Every message is sent with the debug and
+// alternate_intents options so that intents and visited dialog nodes can be checked.
+type ConversationTestRunner struct {
+	Assistant   *AssistantV2
+	AssistantID string
+}
+
+// NewConversationTestRunner : Instantiate ConversationTestRunner
+func (assistant *AssistantV2) NewConversationTestRunner(assistantID string) *ConversationTestRunner {
+	return &ConversationTestRunner{
+		Assistant:   assistant,
+		AssistantID: assistantID,
+	}
+}
+
+// conversationTurnResponse holds the parts of a stateful or stateless response that are checked.
+type conversationTurnResponse struct {
+	output *MessageOutput
+	skills map[string]MessageContextSkill
+}
+
+// RunAll runs the scenarios one after another.
+func (runner *ConversationTestRunner) RunAll(ctx context.Context, scenarios []ConversationScenario) *ConversationTestReport {
+	report := &ConversationTestReport{}
+	for i := range scenarios {
+		report.Results = append(report.Results, *runner.Run(ctx, &scenarios[i]))
+	}
+	return report
+}
+
+// Run runs one scenario. Failed expectations are recorded in the result and do not stop the scenario; errors from the
+// service do.
+func (runner *ConversationTestRunner) Run(ctx context.Context, scenario *ConversationScenario) *ConversationScenarioResult {
+	result := &ConversationScenarioResult{Name: scenario.Name}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	var send func(text string) (*conversationTurnResponse, error)
+	if scenario.Stateless {
+		var messageContext *MessageContextStateless
+		send = func(text string) (*conversationTurnResponse, error) {
+			options := runner.Assistant.NewMessageStatelessOptions(runner.AssistantID)
+			options.Input = &MessageInputStateless{
+				MessageType: core.StringPtr(MessageInputStatelessMessageTypeTextConst),
+				Text:        core.StringPtr(text),
+				Options: &MessageInputOptionsStateless{
+					Debug:            core.BoolPtr(true),
+					AlternateIntents: core.BoolPtr(true),
+				},
+			}
+			options.Context = messageContext
+			if scenario.UserID != "" {
+				options.SetUserID(scenario.UserID)
+			}
+			response, _, err := runner.Assistant.MessageStatelessWithContext(ctx, options)
+			if err != nil {
+				return nil, err
+			}
+			messageContext = response.Context
+			turn := &conversationTurnResponse{output: response.Output}
+			if response.Context != nil {
+				turn.skills = response.Context.Skills
+			}
+			return turn, nil
+		}
+	} else {
+		sessionOptions := runner.Assistant.NewCreateSessionOptions(runner.AssistantID)
+		session, _, err := runner.Assistant.CreateSessionWithContext(ctx, sessionOptions)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		sessionID := stringValue(session.SessionID)
+		defer runner.Assistant.DeleteSessionWithContext(context.Background(), runner.Assistant.NewDeleteSessionOptions(runner.AssistantID, sessionID))
+
+		send = func(text string) (*conversationTurnResponse, error) {
+			options := runner.Assistant.NewMessageOptions(runner.AssistantID, sessionID)
+			options.Input = &MessageInput{
+				MessageType: core.StringPtr(MessageInputMessageTypeTextConst),
+				Text:        core.StringPtr(text),
+				Options: &MessageInputOptions{
+					Debug:            core.BoolPtr(true),
+					AlternateIntents: core.BoolPtr(true),
+					ReturnContext:    core.BoolPtr(true),
+				},
+			}
+			if scenario.UserID != "" {
+				options.SetUserID(scenario.UserID)
+			}
+			response, _, err := runner.Assistant.MessageWithContext(ctx, options)
+			if err != nil {
+				return nil, err
+			}
+			turn := &conversationTurnResponse{output: response.Output}
+			if response.Context != nil {
+				turn.skills = response.Context.Skills
+			}
+			return turn, nil
+		}
+	}
+
+	for i, turn := range scenario.Turns {
+		response, err := send(turn.Input)
+		if err != nil {
+			result.Err = fmt.Errorf("turn %d: %s", i+1, err.Error())
+			return result
+		}
+		for _, message := range checkConversationExpectation(&turn.Expect, response) {
+			result.Failures = append(result.Failures, ConversationTurnFailure{Turn: i + 1, Input: turn.Input, Message: message})
+		}
+	}
+	return result
+}
+
+// RunTests runs each scenario as a subtest of a go test, reporting failed expectations as test errors. It lets
+// scenario files be checked with `go test`:
+//
+//	func TestConversations(t *testing.T) {
+//		scenarios, err := assistantv2.LoadConversationScenarios("testdata/order.yaml")
+//		...
+//		assistant.NewConversationTestRunner(assistantID).RunTests(t, context.Background(), scenarios)
+//	}
+func (runner *ConversationTestRunner) RunTests(t *testing.T, ctx context.Context, scenarios []ConversationScenario) {
+	t.Helper()
+	for i := range scenarios {
+		scenario := &scenarios[i]
+		t.Run(scenario.Name, func(t *testing.T) {
+			result := runner.Run(ctx, scenario)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			for _, failure := range result.Failures {
+				t.Errorf("turn %d (%q): %s", failure.Turn, failure.Input, failure.Message)
+			}
+		})
+	}
+}
+
+func responseTexts(output *MessageOutput) []string {
+	var texts []string
+	for _, response := range output.Generic {
+		if text, ok := response.(*RuntimeResponseGenericRuntimeResponseTypeText); ok {
+			texts = append(texts, stringValue(text.Text))
+		}
+	}
+	return texts
+}
+
+// normalizeJSONValue converts a value to the form it has when decoded from JSON, so that values read from YAML
+// compare equal to the same values in a response.
+func normalizeJSONValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	json.Unmarshal(data, &normalized)
+	return normalized
+}
+
+func checkConversationExpectation(expect *ConversationExpectation, response *conversationTurnResponse) (failures []string) {
+	output := response.output
+	if output == nil {
+		output = &MessageOutput{}
+	}
+
+	if expect.Intent != "" || expect.MinConfidence > 0 {
+		intent, confidence := "", 0.0
+		if len(output.Intents) > 0 {
+			intent = stringValue(output.Intents[0].Intent)
+			if output.Intents[0].Confidence != nil {
+				confidence = *output.Intents[0].Confidence
+			}
+		}
+		if expect.Intent != "" && intent != expect.Intent {
+			failures = append(failures, fmt.Sprintf("expected intent #%s, got #%s", expect.Intent, intent))
+		} else if confidence < expect.MinConfidence {
+			failures = append(failures, fmt.Sprintf("expected confidence of at least %g, got %g", expect.MinConfidence, confidence))
+		}
+	}
+
+	if len(expect.NodesVisited) > 0 {
+		var visited []string
+		next := 0
+		if output.Debug != nil {
+			for _, node := range output.Debug.NodesVisited {
+				visited = append(visited, stringValue(node.DialogNode))
+				if next < len(expect.NodesVisited) &&
+					(stringValue(node.DialogNode) == expect.NodesVisited[next] || stringValue(node.Title) == expect.NodesVisited[next]) {
+					next++
+				}
+			}
+		}
+		if next < len(expect.NodesVisited) {
+			failures = append(failures, fmt.Sprintf("expected to visit dialog nodes %v, visited %v", expect.NodesVisited, visited))
+		}
+	}
+
+	if expect.Text != "" {
+		text := strings.Join(responseTexts(output), "\n")
+		matcher, err := regexp.Compile(expect.Text)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("invalid text expression %q: %s", expect.Text, err.Error()))
+		} else if !matcher.MatchString(text) {
+			failures = append(failures, fmt.Sprintf("expected text to match %q, got %q", expect.Text, text))
+		}
+	}
+
+	if len(expect.Variables) > 0 {
+		skillName := expect.Skill
+		if skillName == "" {
+			skillName = DialogSkillName
+		}
+		variables := response.skills[skillName].UserDefined
+		var names []string
+		for name := range expect.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			expected := expect.Variables[name]
+			actual, ok := variables[name]
+			if !ok {
+				failures = append(failures, fmt.Sprintf("expected skill variable %s to be set", name))
+			} else if !reflect.DeepEqual(normalizeJSONValue(expected), normalizeJSONValue(actual)) {
+				failures = append(failures, fmt.Sprintf("expected skill variable %s to be %v, got %v", name, expected, actual))
+			}
+		}
+	}
+	return failures
+}
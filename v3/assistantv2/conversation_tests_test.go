@@ -0,0 +1,208 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`Conversation test runner`, func() {
+	const scenariosYAML = `- name: order a pizza
+  turns:
+    - input: I want a pizza
+      expect:
+        intent: order
+        min_confidence: 0.5
+        nodes_visited: [Order, order_size]
+        text: (?i)what size
+    - input: large
+      expect:
+        text: large pizza
+        variables:
+          size: large
+          count: 1
+- name: stateless greeting
+  stateless: true
+  turns:
+    - input: hello
+      expect:
+        intent: greeting
+    - input: hello again
+      expect:
+        intent: goodbye
+        text: welcome back
+`
+	var testServer *httptest.Server
+	var sessionsDeleted int
+	var dir string
+
+	BeforeEach(func() {
+		sessionsDeleted = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case req.Method == http.MethodPost && req.URL.Path == "/v2/assistants/a1/sessions":
+				res.WriteHeader(201)
+				fmt.Fprint(res, `{"session_id": "s1"}`)
+				return
+			case req.Method == http.MethodDelete && req.URL.Path == "/v2/assistants/a1/sessions/s1":
+				sessionsDeleted++
+				fmt.Fprint(res, `{}`)
+				return
+			case !strings.HasPrefix(req.URL.Path, "/v2/assistants/a1/"):
+				res.WriteHeader(404)
+				fmt.Fprint(res, `{"error": "Resource not found", "code": 404}`)
+				return
+			}
+
+			var body struct {
+				Input struct {
+					Text    string                 `json:"text"`
+					Options map[string]interface{} `json:"options"`
+				} `json:"input"`
+				Context json.RawMessage `json:"context"`
+			}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			Expect(body.Input.Options).To(HaveKeyWithValue("debug", true))
+			Expect(body.Input.Options).To(HaveKeyWithValue("alternate_intents", true))
+
+			switch body.Input.Text {
+			case "I want a pizza":
+				Expect(req.URL.Path).To(Equal("/v2/assistants/a1/sessions/s1/message"))
+				fmt.Fprint(res, `{"output": {
+					"intents": [{"intent": "order", "confidence": 0.93}],
+					"generic": [{"response_type": "text", "text": "What size would you like?"}],
+					"debug": {"nodes_visited": [{"dialog_node": "welcome"}, {"dialog_node": "order", "title": "Order"}, {"dialog_node": "order_size"}]}
+				}, "user_id": "u1", "context": {"skills": {"main skill": {"user_defined": {}}}}}`)
+			case "large":
+				fmt.Fprint(res, `{"output": {"generic": [{"response_type": "text", "text": "One large pizza coming up"}]},
+					"user_id": "u1", "context": {"skills": {"main skill": {"user_defined": {"size": "large", "count": 2}}}}}`)
+			case "hello":
+				Expect(req.URL.Path).To(Equal("/v2/assistants/a1/message"))
+				Expect(string(body.Context)).To(Equal(""))
+				fmt.Fprint(res, `{"output": {"intents": [{"intent": "greeting", "confidence": 0.8}], "generic": []},
+					"context": {"global": {"session_id": "x"}, "skills": {"main skill": {"user_defined": {"greeted": true}}}}}`)
+			case "hello again":
+				Expect(string(body.Context)).To(ContainSubstring(`"greeted":true`))
+				fmt.Fprint(res, `{"output": {"intents": [{"intent": "greeting", "confidence": 0.8}],
+					"generic": [{"response_type": "text", "text": "Welcome back"}]}, "context": {}}`)
+			}
+		}))
+
+		var err error
+		dir, err = ioutil.TempDir("", "scenarios")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "scenarios.yaml"), []byte(scenariosYAML), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		testServer.Close()
+		os.RemoveAll(dir)
+	})
+
+	It(`Runs scenarios and reports failed expectations as JUnit XML`, func() {
+		scenarios, err := assistantv2.LoadConversationScenarios(filepath.Join(dir, "scenarios.yaml"))
+		Expect(err).To(BeNil())
+		Expect(scenarios).To(HaveLen(2))
+		Expect(scenarios[0].Turns[1].Expect.Variables).To(HaveKeyWithValue("size", "large"))
+
+		assistantService, err := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(err).To(BeNil())
+		report := assistantService.NewConversationTestRunner("a1").RunAll(context.Background(), scenarios)
+		Expect(report.Passed()).To(BeFalse())
+		Expect(sessionsDeleted).To(Equal(1))
+
+		Expect(report.Results[0].Err).To(BeNil())
+		Expect(report.Results[0].Failures).To(HaveLen(1))
+		Expect(report.Results[0].Failures[0].Turn).To(Equal(2))
+		Expect(report.Results[0].Failures[0].Message).To(Equal("expected skill variable count to be 1, got 2"))
+
+		Expect(report.Results[1].Err).To(BeNil())
+		Expect(report.Results[1].Failures).To(HaveLen(2))
+		Expect(report.Results[1].Failures[0].Message).To(Equal("expected intent #goodbye, got #greeting"))
+		Expect(report.Results[1].Failures[1].Message).To(ContainSubstring(`expected text to match "welcome back"`))
+
+		var buffer bytes.Buffer
+		report.Name = "pizza bot"
+		Expect(report.WriteJUnitXML(&buffer)).To(Succeed())
+		xml := buffer.String()
+		Expect(xml).To(HavePrefix(`<?xml version="1.0" encoding="UTF-8"?>`))
+		Expect(xml).To(ContainSubstring(`<testsuite name="pizza bot" tests="2" failures="2" errors="0"`))
+		Expect(xml).To(ContainSubstring(`<failure message="expected skill variable count to be 1, got 2">turn 2 (&#34;large&#34;)`))
+		Expect(strings.Count(xml, "<testcase ")).To(Equal(2))
+	})
+	It(`Reports service errors`, func() {
+		assistantService, err := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(err).To(BeNil())
+		result := assistantService.NewConversationTestRunner("missing").Run(context.Background(), &assistantv2.ConversationScenario{
+			Name:  "missing assistant",
+			Turns: []assistantv2.ConversationScenarioTurn{{Input: "hi"}},
+		})
+		Expect(result.Passed()).To(BeFalse())
+		Expect(result.Err).ToNot(BeNil())
+	})
+})
+
+// TestConversationScenariosAsSubtests runs scenarios with `go test`, one subtest per scenario.
+func TestConversationScenariosAsSubtests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-type", "application/json")
+		fmt.Fprint(res, `{"output": {"intents": [{"intent": "greeting", "confidence": 0.8}],
+			"generic": [{"response_type": "text", "text": "Hello!"}]}, "context": {}}`)
+	}))
+	defer server.Close()
+	assistantService, err := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+		URL:           server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		Version:       core.StringPtr("testString"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenarios := []assistantv2.ConversationScenario{{
+		Name:      "greeting",
+		Stateless: true,
+		Turns: []assistantv2.ConversationScenarioTurn{
+			{Input: "hi", Expect: assistantv2.ConversationExpectation{Intent: "greeting", Text: "Hello"}},
+		},
+	}}
+
+	assistantService.NewConversationTestRunner("a1").RunTests(t, context.Background(), scenarios)
+}
@@ -0,0 +1,345 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Constants associated with the HandoffRequest.Kind property.
+const (
+	HandoffKindConnectToAgentConst  = "connect_to_agent"
+	HandoffKindChannelTransferConst = "channel_transfer"
+)
+
+// DefaultHandoffHistory is the number of recent turns included in a handoff request.
+const DefaultHandoffHistory = 10
+
+// DefaultHandoffSessions is the number of sessions whose turns a Handoff keeps.
+const DefaultHandoffSessions = 1000
+
+// ErrNoAgentConnector is returned by Handle when a handoff has no Connector and no fallback to use instead.
+var ErrNoAgentConnector = errors.New("handoff has no agent connector")
+
+// HandoffTurn : One exchange between the user and the assistant, as included in a handoff request.
+type HandoffTurn struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserInput string    `json:"user_input"`
+	Responses []string  `json:"responses,omitempty"`
+	Intent    string    `json:"intent,omitempty"`
+}
+
+// HandoffRequest : Everything a contact-center system needs to take over a conversation: what the assistant asked
+// for, the recent turns and the session metadata.
+type HandoffRequest struct {
+	// HandoffKindConnectToAgentConst or HandoffKindChannelTransferConst.
+	Kind string `json:"kind"`
+
+	AssistantID string `json:"assistant_id,omitempty"`
+	SessionID   string `json:"session_id,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+
+	// For connect_to_agent: the message for the agent, the topic, and the routing target per service desk.
+	MessageToHumanAgent string                            `json:"message_to_human_agent,omitempty"`
+	Topic               string                            `json:"topic,omitempty"`
+	Target              map[string]map[string]interface{} `json:"target,omitempty"`
+
+	// For channel_transfer: the message for the user and the URL of the web chat to continue in.
+	MessageToUser string `json:"message_to_user,omitempty"`
+	TransferURL   string `json:"transfer_url,omitempty"`
+
+	// The user-defined variables of the dialog skill.
+	SkillVariables map[string]interface{} `json:"skill_variables,omitempty"`
+
+	// Metadata supplied by the application, such as the customer's account or channel.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// The most recent turns of the conversation, oldest first.
+	History []HandoffTurn `json:"history,omitempty"`
+
+	// The response that triggered the handoff.
+	Response RuntimeResponseGenericIntf `json:"-"`
+}
+
+// AgentConnector : Connects conversations to a contact-center system.
+type AgentConnector interface {
+	// AgentsAvailable reports whether an agent can take the conversation, for example by checking the queue for the
+	// request's target or topic.
+	AgentsAvailable(ctx context.Context, request *HandoffRequest) (bool, error)
+
+	// Transfer hands the conversation over.
+	Transfer(ctx context.Context, request *HandoffRequest) error
+}
+
+// HandoffFallback is called instead of a transfer when no agent is available or the transfer fails, for example to
+// open a ticket or schedule a call back. transferErr is the error of the failed transfer, or nil when no agent was
+// available.
+type HandoffFallback func(ctx context.Context, request *HandoffRequest, transferErr error) error
+
+// HandoffResult : The outcome of a handoff.
+type HandoffResult struct {
+	Request *HandoffRequest
+
+	// Whether the conversation was handed to the connector.
+	Transferred bool
+
+	// Whether the fallback was used.
+	FellBack bool
+
+	// The message to show the user: the agent_available or agent_unavailable message of a connect_to_agent response,
+	// or the message_to_user of a channel_transfer response.
+	MessageToUser string
+}
+
+// Handoff : Detects connect_to_agent and channel_transfer responses and hands the conversation to an AgentConnector.
+// Record the turns of each session so that they can be included in the handoff. Stateless conversations use
+// NewHandoffRequestStateless and HandleStateless, with the session ID of their context or any other key that identifies
+// the conversation.
+type Handoff struct {
+	// Without a connector, no agent is ever available and channel transfers fail with ErrNoAgentConnector.
+	Connector   AgentConnector
+	Fallback    HandoffFallback
+	AssistantID string
+
+	// The number of recent turns kept per session. Defaults to DefaultHandoffHistory.
+	MaxHistory int
+
+	// The number of sessions whose turns are kept. When a turn is recorded for a new session beyond this number, the
+	// session with the oldest last turn is forgotten. Defaults to DefaultHandoffSessions.
+	MaxSessions int
+
+	// The channel the conversation is on; responses for other channels are ignored. Empty accepts all responses.
+	Channel string
+
+	mutex   sync.Mutex
+	history map[string][]HandoffTurn
+}
+
+// NewHandoff : Instantiate Handoff
+func NewHandoff(assistantID string, connector AgentConnector) *Handoff {
+	return &Handoff{
+		Connector:   connector,
+		AssistantID: assistantID,
+		MaxHistory:  DefaultHandoffHistory,
+		MaxSessions: DefaultHandoffSessions,
+	}
+}
+
+// Record adds a turn to the history of a session.
+func (handoff *Handoff) Record(sessionID string, userInput string, output *MessageOutput) {
+	turn := HandoffTurn{Timestamp: time.Now(), UserInput: userInput}
+	if output != nil {
+		turn.Responses = responseTexts(output)
+		if len(output.Intents) > 0 {
+			turn.Intent = stringValue(output.Intents[0].Intent)
+		}
+	}
+
+	maxHistory := handoff.MaxHistory
+	if maxHistory <= 0 {
+		maxHistory = DefaultHandoffHistory
+	}
+	maxSessions := handoff.MaxSessions
+	if maxSessions <= 0 {
+		maxSessions = DefaultHandoffSessions
+	}
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+	if handoff.history == nil {
+		handoff.history = make(map[string][]HandoffTurn)
+	}
+	if _, ok := handoff.history[sessionID]; !ok && len(handoff.history) >= maxSessions {
+		handoff.forgetOldest()
+	}
+	turns := append(handoff.history[sessionID], turn)
+	if len(turns) > maxHistory {
+		turns = append([]HandoffTurn{}, turns[len(turns)-maxHistory:]...)
+	}
+	handoff.history[sessionID] = turns
+}
+
+// forgetOldest drops the history of the session whose last turn is the oldest.
+func (handoff *Handoff) forgetOldest() {
+	oldest := ""
+	var oldestTime time.Time
+	for sessionID, turns := range handoff.history {
+		last := turns[len(turns)-1].Timestamp
+		if oldest == "" || last.Before(oldestTime) {
+			oldest, oldestTime = sessionID, last
+		}
+	}
+	delete(handoff.history, oldest)
+}
+
+// History returns the recorded turns of a session, oldest first.
+func (handoff *Handoff) History(sessionID string) []HandoffTurn {
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+	return append([]HandoffTurn{}, handoff.history[sessionID]...)
+}
+
+// Forget drops the history of a session, for example when the session ends.
+func (handoff *Handoff) Forget(sessionID string) {
+	handoff.mutex.Lock()
+	defer handoff.mutex.Unlock()
+	delete(handoff.history, sessionID)
+}
+
+// FindHandoffResponse returns the first connect_to_agent or channel_transfer response, or nil if there is none.
+func FindHandoffResponse(responses []RuntimeResponseGenericIntf) RuntimeResponseGenericIntf {
+	for _, response := range responses {
+		switch response.(type) {
+		case *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent, *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer:
+			return response
+		}
+	}
+	return nil
+}
+
+// NewHandoffRequest assembles the handoff request for a message output, or returns nil when the output does not ask
+// for a handoff. The session's recorded turns are included; messageContext and metadata are optional.
+func (handoff *Handoff) NewHandoffRequest(sessionID string, userID string, output *MessageOutput, messageContext *MessageContext, metadata map[string]string) *HandoffRequest {
+	var skills map[string]MessageContextSkill
+	if messageContext != nil {
+		skills = messageContext.Skills
+	}
+	return handoff.newHandoffRequest(sessionID, userID, output, skills, metadata)
+}
+
+// NewHandoffRequestStateless assembles the handoff request for the output of a stateless message, or returns nil when
+// the output does not ask for a handoff. sessionID is the key the turns were recorded with; when it is empty, the
+// session ID of messageContext is used. messageContext and metadata are optional.
+func (handoff *Handoff) NewHandoffRequestStateless(sessionID string, userID string, output *MessageOutput, messageContext *MessageContextStateless, metadata map[string]string) *HandoffRequest {
+	var skills map[string]MessageContextSkill
+	if messageContext != nil {
+		skills = messageContext.Skills
+		if sessionID == "" && messageContext.Global != nil {
+			sessionID = stringValue(messageContext.Global.SessionID)
+		}
+	}
+	return handoff.newHandoffRequest(sessionID, userID, output, skills, metadata)
+}
+
+func (handoff *Handoff) newHandoffRequest(sessionID string, userID string, output *MessageOutput, skills map[string]MessageContextSkill, metadata map[string]string) *HandoffRequest {
+	if output == nil {
+		return nil
+	}
+	responses := output.Generic
+	if handoff.Channel != "" {
+		responses = FilterRuntimeResponseGenericByChannel(responses, handoff.Channel)
+	}
+	response := FindHandoffResponse(responses)
+	if response == nil {
+		return nil
+	}
+
+	request := &HandoffRequest{
+		AssistantID: handoff.AssistantID,
+		SessionID:   sessionID,
+		UserID:      userID,
+		Metadata:    metadata,
+		History:     handoff.History(sessionID),
+		Response:    response,
+	}
+	switch r := response.(type) {
+	case *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent:
+		request.Kind = HandoffKindConnectToAgentConst
+		request.MessageToHumanAgent = stringValue(r.MessageToHumanAgent)
+		request.Topic = stringValue(r.Topic)
+		if r.TransferInfo != nil {
+			request.Target = r.TransferInfo.Target
+		}
+	case *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer:
+		request.Kind = HandoffKindChannelTransferConst
+		request.MessageToUser = stringValue(r.MessageToUser)
+		request.TransferURL = transferURL(r.TransferInfo)
+	}
+	if skills != nil {
+		request.SkillVariables = skills[DialogSkillName].UserDefined
+	}
+	return request
+}
+
+// Handle checks a message output for a handoff response and, if there is one, hands the conversation to the
+// connector. A connect_to_agent handoff first checks that an agent is available and uses the fallback when none is
+// or the transfer fails. It returns nil when the output does not ask for a handoff.
+func (handoff *Handoff) Handle(ctx context.Context, sessionID string, userID string, output *MessageOutput, messageContext *MessageContext, metadata map[string]string) (*HandoffResult, error) {
+	return handoff.handle(ctx, handoff.NewHandoffRequest(sessionID, userID, output, messageContext, metadata))
+}
+
+// HandleStateless is Handle for the output of a stateless message; sessionID is as for NewHandoffRequestStateless.
+func (handoff *Handoff) HandleStateless(ctx context.Context, sessionID string, userID string, output *MessageOutput, messageContext *MessageContextStateless, metadata map[string]string) (*HandoffResult, error) {
+	return handoff.handle(ctx, handoff.NewHandoffRequestStateless(sessionID, userID, output, messageContext, metadata))
+}
+
+func (handoff *Handoff) handle(ctx context.Context, request *HandoffRequest) (*HandoffResult, error) {
+	if request == nil {
+		return nil, nil
+	}
+	result := &HandoffResult{Request: request}
+
+	if request.Kind == HandoffKindChannelTransferConst {
+		result.MessageToUser = request.MessageToUser
+		if handoff.Connector == nil {
+			return result, ErrNoAgentConnector
+		}
+		if err := handoff.Connector.Transfer(ctx, request); err != nil {
+			return result, err
+		}
+		result.Transferred = true
+		return result, nil
+	}
+
+	response := request.Response.(*RuntimeResponseGenericRuntimeResponseTypeConnectToAgent)
+	available := false
+	if handoff.Connector != nil {
+		var err error
+		if available, err = handoff.Connector.AgentsAvailable(ctx, request); err != nil {
+			return result, err
+		}
+	}
+	var transferErr error
+	if available {
+		transferErr = handoff.Connector.Transfer(ctx, request)
+		if transferErr == nil {
+			result.Transferred = true
+			if response.AgentAvailable != nil {
+				result.MessageToUser = stringValue(response.AgentAvailable.Message)
+			}
+			return result, nil
+		}
+	}
+
+	if response.AgentUnavailable != nil {
+		result.MessageToUser = stringValue(response.AgentUnavailable.Message)
+	}
+	if handoff.Fallback == nil {
+		if handoff.Connector == nil {
+			return result, ErrNoAgentConnector
+		}
+		if transferErr != nil {
+			return result, fmt.Errorf("transfer failed and no fallback is configured: %s", transferErr.Error())
+		}
+		return result, nil
+	}
+	result.FellBack = true
+	return result, handoff.Fallback(ctx, request, transferErr)
+}
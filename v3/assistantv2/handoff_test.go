@@ -0,0 +1,169 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+type fakeAgentConnector struct {
+	available   bool
+	transferErr error
+	transferred []*assistantv2.HandoffRequest
+}
+
+func (connector *fakeAgentConnector) AgentsAvailable(ctx context.Context, request *assistantv2.HandoffRequest) (bool, error) {
+	return connector.available, nil
+}
+
+func (connector *fakeAgentConnector) Transfer(ctx context.Context, request *assistantv2.HandoffRequest) error {
+	if connector.transferErr != nil {
+		return connector.transferErr
+	}
+	connector.transferred = append(connector.transferred, request)
+	return nil
+}
+
+var _ = Describe(`Handoff`, func() {
+	connectToAgent := unmarshalTestMessageOutput(`{"generic": [
+		{"response_type": "text", "text": "Let me get someone"},
+		{"response_type": "connect_to_agent", "message_to_human_agent": "Wants a refund", "topic": "billing",
+			"agent_available": {"message": "Connecting you now"},
+			"agent_unavailable": {"message": "Nobody is around"},
+			"transfer_info": {"target": {"zendesk": {"department": "billing"}}}}
+	]}`)
+	var connector *fakeAgentConnector
+	var handoff *assistantv2.Handoff
+
+	BeforeEach(func() {
+		connector = &fakeAgentConnector{available: true}
+		handoff = assistantv2.NewHandoff("a1", connector)
+		handoff.MaxHistory = 2
+		for i := 1; i <= 3; i++ {
+			handoff.Record("s1", fmt.Sprintf("input %d", i), unmarshalTestMessageOutput(fmt.Sprintf(`{"generic": [{"response_type": "text", "text": "reply %d"}], "intents": [{"intent": "refund", "confidence": 0.9}]}`, i)))
+		}
+	})
+
+	It(`Transfers to an available agent with the recent turns`, func() {
+		messageContext := &assistantv2.MessageContext{}
+		messageContext.SetSkillVariable(assistantv2.DialogSkillName, "order_id", "A-1")
+		result, err := handoff.Handle(context.Background(), "s1", "u1", connectToAgent, messageContext, map[string]string{"channel": "web"})
+		Expect(err).To(BeNil())
+		Expect(result.Transferred).To(BeTrue())
+		Expect(result.MessageToUser).To(Equal("Connecting you now"))
+
+		Expect(connector.transferred).To(HaveLen(1))
+		request := connector.transferred[0]
+		Expect(request.Kind).To(Equal(assistantv2.HandoffKindConnectToAgentConst))
+		Expect(request.MessageToHumanAgent).To(Equal("Wants a refund"))
+		Expect(request.Topic).To(Equal("billing"))
+		Expect(request.Target["zendesk"]["department"]).To(Equal("billing"))
+		Expect(request.SkillVariables).To(HaveKeyWithValue("order_id", "A-1"))
+		Expect(request.Metadata).To(HaveKeyWithValue("channel", "web"))
+		Expect(request.History).To(HaveLen(2))
+		Expect(request.History[0].UserInput).To(Equal("input 2"))
+		Expect(request.History[1].Responses).To(Equal([]string{"reply 3"}))
+		Expect(request.History[1].Intent).To(Equal("refund"))
+	})
+	It(`Falls back when no agent is available or the transfer fails`, func() {
+		var fallbacks []error
+		handoff.Fallback = func(ctx context.Context, request *assistantv2.HandoffRequest, transferErr error) error {
+			fallbacks = append(fallbacks, transferErr)
+			return nil
+		}
+
+		connector.available = false
+		result, err := handoff.Handle(context.Background(), "s1", "u1", connectToAgent, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(result.Transferred).To(BeFalse())
+		Expect(result.FellBack).To(BeTrue())
+		Expect(result.MessageToUser).To(Equal("Nobody is around"))
+
+		connector.available = true
+		connector.transferErr = errors.New("queue closed")
+		result, err = handoff.Handle(context.Background(), "s1", "u1", connectToAgent, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(result.FellBack).To(BeTrue())
+		Expect(fallbacks).To(Equal([]error{nil, connector.transferErr}))
+
+		handoff.Fallback = nil
+		_, err = handoff.Handle(context.Background(), "s1", "u1", connectToAgent, nil, nil)
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Hands over channel transfers and ignores other output`, func() {
+		output := unmarshalTestMessageOutput(`{"generic": [{"response_type": "channel_transfer", "message_to_user": "Continue on the web",
+			"transfer_info": {"target": {"chat": {"url": "https://example.com/chat"}}}}]}`)
+		result, err := handoff.Handle(context.Background(), "s1", "u1", output, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(result.Transferred).To(BeTrue())
+		Expect(result.MessageToUser).To(Equal("Continue on the web"))
+		Expect(result.Request.TransferURL).To(Equal("https://example.com/chat"))
+
+		result, err = handoff.Handle(context.Background(), "s1", "u1", unmarshalTestMessageOutput(`{"generic": [{"response_type": "text", "text": "hi"}]}`), nil, nil)
+		Expect(err).To(BeNil())
+		Expect(result).To(BeNil())
+
+		handoff.Forget("s1")
+		Expect(handoff.History("s1")).To(BeEmpty())
+	})
+	It(`Forgets the session with the oldest turn beyond MaxSessions`, func() {
+		handoff.MaxSessions = 2
+		handoff.Record("s2", "hello", nil)
+		handoff.Record("s3", "hi", nil)
+		Expect(handoff.History("s1")).To(BeEmpty())
+		Expect(handoff.History("s2")).To(HaveLen(1))
+		Expect(handoff.History("s3")).To(HaveLen(1))
+	})
+	It(`Handles stateless output and a missing connector`, func() {
+		messageContext := &assistantv2.MessageContextStateless{
+			Global: &assistantv2.MessageContextGlobalStateless{SessionID: core.StringPtr("s1")},
+			Skills: map[string]assistantv2.MessageContextSkill{
+				assistantv2.DialogSkillName: {UserDefined: map[string]interface{}{"order_id": "A-1"}},
+			},
+		}
+		result, err := handoff.HandleStateless(context.Background(), "", "u1", connectToAgent, messageContext, nil)
+		Expect(err).To(BeNil())
+		Expect(result.Transferred).To(BeTrue())
+		Expect(result.Request.SessionID).To(Equal("s1"))
+		Expect(result.Request.History).To(HaveLen(2))
+		Expect(result.Request.SkillVariables).To(HaveKeyWithValue("order_id", "A-1"))
+
+		handoff.Connector = nil
+		result, err = handoff.Handle(context.Background(), "s1", "u1", connectToAgent, nil, nil)
+		Expect(err).To(Equal(assistantv2.ErrNoAgentConnector))
+		Expect(result.Transferred).To(BeFalse())
+		Expect(result.MessageToUser).To(Equal("Nobody is around"))
+
+		handoff.Fallback = func(ctx context.Context, request *assistantv2.HandoffRequest, transferErr error) error {
+			return nil
+		}
+		result, err = handoff.Handle(context.Background(), "s1", "u1", connectToAgent, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(result.FellBack).To(BeTrue())
+
+		output := unmarshalTestMessageOutput(`{"generic": [{"response_type": "channel_transfer", "message_to_user": "Continue on the web"}]}`)
+		_, err = handoff.Handle(context.Background(), "s1", "u1", output, nil, nil)
+		Expect(err).To(Equal(assistantv2.ErrNoAgentConnector))
+	})
+})
@@ -0,0 +1,84 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// DefaultBulkClassifyBatchSize is the default number of utterances sent in one bulk classify request.
+const DefaultBulkClassifyBatchSize = 50
+
+// BulkIntentClassifier : Classifies texts with the BulkClassify method of a skill, in batches. It can be used as an
+// assistantv1.IntentClassifier.
+type BulkIntentClassifier struct {
+	Assistant *AssistantV2
+
+	// The number of utterances sent in one request. Defaults to DefaultBulkClassifyBatchSize.
+	BatchSize int
+}
+
+// NewBulkIntentClassifier : Instantiate BulkIntentClassifier
+func (assistant *AssistantV2) NewBulkIntentClassifier() *BulkIntentClassifier {
+	return &BulkIntentClassifier{Assistant: assistant, BatchSize: DefaultBulkClassifyBatchSize}
+}
+
+// ClassifyIntents classifies texts with the BulkClassify method of the skill, in batches of
+// DefaultBulkClassifyBatchSize, and returns the top intent of each one, or an empty string when no intent was
+// recognized. For dialog skills the skill ID is the workspace ID, so AssistantV2 can be used as an
+// assistantv1.IntentClassifier.
+func (assistant *AssistantV2) ClassifyIntents(ctx context.Context, skillID string, texts []string) ([]string, error) {
+	return assistant.NewBulkIntentClassifier().ClassifyIntents(ctx, skillID, texts)
+}
+
+// ClassifyIntents classifies texts in batches of BatchSize and returns the top intent of each one, or an empty string
+// when no intent was recognized.
+func (classifier *BulkIntentClassifier) ClassifyIntents(ctx context.Context, skillID string, texts []string) ([]string, error) {
+	batchSize := classifier.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBulkClassifyBatchSize
+	}
+	intents := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		options := classifier.Assistant.NewBulkClassifyOptions(skillID)
+		for _, text := range texts[start:end] {
+			options.Input = append(options.Input, BulkClassifyUtterance{Text: core.StringPtr(text)})
+		}
+		result, _, err := classifier.Assistant.BulkClassifyWithContext(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Output) != end-start {
+			return nil, fmt.Errorf("bulk classify returned %d results for %d inputs", len(result.Output), end-start)
+		}
+		for _, output := range result.Output {
+			intent := ""
+			if len(output.Intents) > 0 {
+				intent = stringValue(output.Intents[0].Intent)
+			}
+			intents = append(intents, intent)
+		}
+	}
+	return intents, nil
+}
@@ -0,0 +1,103 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`LogsIterator`, func() {
+	var testServer *httptest.Server
+	var rateLimited bool
+
+	BeforeEach(func() {
+		rateLimited = false
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/v2/assistants/testString/logs"))
+			res.Header().Set("Content-type", "application/json")
+			switch req.URL.Query().Get("cursor") {
+			case "":
+				fmt.Fprintf(res, `{"logs": [{"log_id": "1"}, {"log_id": "2"}], "pagination": {"next_cursor": "page2"}}`)
+			case "page2":
+				if !rateLimited {
+					rateLimited = true
+					res.Header().Set("Retry-After", "0")
+					res.WriteHeader(503)
+					fmt.Fprintf(res, `{"error": "Service unavailable", "code": 503}`)
+					return
+				}
+				fmt.Fprintf(res, `{"logs": [{"log_id": "3"}], "pagination": {}}`)
+			}
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Streams logs across pages`, func() {
+		assistantService, serviceErr := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(serviceErr).To(BeNil())
+
+		iterator := assistantService.NewLogsIterator(assistantService.NewListLogsOptions("testString").SetPageLimit(2))
+		iterator.InitialBackoff = time.Millisecond
+		var checkpoints []string
+		iterator.Checkpoint = func(cursor string) error {
+			checkpoints = append(checkpoints, cursor)
+			return nil
+		}
+
+		var ids []string
+		for {
+			log, err := iterator.Next(context.Background())
+			if err == assistantv2.ErrIteratorDone {
+				break
+			}
+			Expect(err).To(BeNil())
+			ids = append(ids, *log.LogID)
+		}
+		Expect(ids).To(Equal([]string{"1", "2", "3"}))
+		Expect(checkpoints).To(Equal([]string{"page2"}))
+	})
+	It(`Stops when the context is cancelled during backoff`, func() {
+		assistantService, serviceErr := assistantv2.NewAssistantV2(&assistantv2.AssistantV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			Version:       core.StringPtr("testString"),
+		})
+		Expect(serviceErr).To(BeNil())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		iterator := assistantService.NewLogsIterator(assistantService.NewListLogsOptions("testString").SetCursor("page2"))
+		_, err := iterator.Next(ctx)
+		Expect(err).ToNot(BeNil())
+	})
+})
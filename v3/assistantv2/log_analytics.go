@@ -0,0 +1,419 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// DefaultLowConfidenceThreshold is the intent confidence below which LogAnalyzer reports a turn as low confidence.
+const DefaultLowConfidenceThreshold = 0.5
+
+// ConversationTurn : One request/response pair of a conversation, flattened from a Log.
+type ConversationTurn struct {
+	LogID             string    `json:"log_id"`
+	ConversationID    string    `json:"conversation_id"`
+	RequestTimestamp  time.Time `json:"request_timestamp"`
+	InputText         string    `json:"input_text"`
+	ResponseTexts     []string  `json:"response_texts,omitempty"`
+	Intent            string    `json:"intent,omitempty"`
+	Confidence        float64   `json:"confidence,omitempty"`
+	NodesVisited      []string  `json:"nodes_visited,omitempty"`
+	AnythingElse      bool      `json:"anything_else"`
+	IntentRecognized  bool      `json:"intent_recognized"`
+	requestTimeString string
+}
+
+// ConversationTranscript : The turns of one conversation, ordered by request time.
+type ConversationTranscript struct {
+	ConversationID string             `json:"conversation_id"`
+	Turns          []ConversationTurn `json:"turns"`
+}
+
+// IntentCount : How often an intent was the top intent of a turn.
+type IntentCount struct {
+	Intent            string  `json:"intent"`
+	Count             int     `json:"count"`
+	AverageConfidence float64 `json:"average_confidence"`
+}
+
+// DialogNodeCount : How often a dialog node was visited.
+type DialogNodeCount struct {
+	DialogNode string `json:"dialog_node"`
+	Title      string `json:"title,omitempty"`
+	Count      int    `json:"count"`
+}
+
+// LogReport : Aggregated statistics computed by LogAnalyzer.
+type LogReport struct {
+	TotalTurns    int `json:"total_turns"`
+	Conversations int `json:"conversations"`
+
+	// Top intents ordered by descending count.
+	Intents []IntentCount `json:"intents"`
+
+	// Turns whose top intent confidence is below the threshold, ordered by request time.
+	LowConfidenceTurns []ConversationTurn `json:"low_confidence_turns"`
+
+	// Turns for which no intent was recognized, and their share of all turns.
+	IrrelevantTurns int     `json:"irrelevant_turns"`
+	IrrelevantRate  float64 `json:"irrelevant_rate"`
+
+	// Turns that were handled by an `anything_else` node, and their share of all turns.
+	AnythingElseTurns int     `json:"anything_else_turns"`
+	AnythingElseRate  float64 `json:"anything_else_rate"`
+
+	// Visited dialog nodes ordered by descending count.
+	DialogNodes []DialogNodeCount `json:"dialog_nodes"`
+}
+
+// LogAnalyzer : Accumulates logs from the list APIs or from exported JSON files, and rebuilds conversation transcripts
+// and intent and dialog statistics from them. Logs are grouped by session ID.
+type LogAnalyzer struct {
+	// Turns whose top intent confidence is below this value are reported as low confidence. Defaults to
+	// DefaultLowConfidenceThreshold.
+	LowConfidenceThreshold float64
+
+	conversations map[string][]ConversationTurn
+	nodeTitles    map[string]string
+	seen          map[string]bool
+}
+
+// NewLogAnalyzer : Instantiate LogAnalyzer
+func NewLogAnalyzer() *LogAnalyzer {
+	return &LogAnalyzer{
+		LowConfidenceThreshold: DefaultLowConfidenceThreshold,
+		conversations:          make(map[string][]ConversationTurn),
+		nodeTitles:             make(map[string]string),
+		seen:                   make(map[string]bool),
+	}
+}
+
+// Add adds a log to the analyzer. Logs that were already added (by log ID) are ignored.
+func (analyzer *LogAnalyzer) Add(log *Log) {
+	if log == nil {
+		return
+	}
+	logID := stringValue(log.LogID)
+	if logID != "" {
+		if analyzer.seen[logID] {
+			return
+		}
+		analyzer.seen[logID] = true
+	}
+
+	turn := ConversationTurn{
+		LogID:             logID,
+		ConversationID:    stringValue(log.SessionID),
+		requestTimeString: stringValue(log.RequestTimestamp),
+	}
+	turn.RequestTimestamp, _ = time.Parse(time.RFC3339Nano, turn.requestTimeString)
+	if log.Request != nil && log.Request.Input != nil {
+		turn.InputText = stringValue(log.Request.Input.Text)
+	}
+	if log.Response != nil && log.Response.Output != nil {
+		output := log.Response.Output
+		for _, response := range output.Generic {
+			if text, ok := response.(*RuntimeResponseGenericRuntimeResponseTypeText); ok && text.Text != nil {
+				turn.ResponseTexts = append(turn.ResponseTexts, *text.Text)
+			}
+		}
+		if len(output.Intents) > 0 {
+			turn.IntentRecognized = true
+			turn.Intent = stringValue(output.Intents[0].Intent)
+			if output.Intents[0].Confidence != nil {
+				turn.Confidence = *output.Intents[0].Confidence
+			}
+		}
+		if output.Debug != nil {
+			for _, node := range output.Debug.NodesVisited {
+				turn.NodesVisited = append(turn.NodesVisited, stringValue(node.DialogNode))
+				if stringValue(node.Conditions) == "anything_else" {
+					turn.AnythingElse = true
+				}
+			}
+		}
+	}
+	analyzer.addNodeTitles(log)
+	analyzer.conversations[turn.ConversationID] = append(analyzer.conversations[turn.ConversationID], turn)
+}
+
+func (analyzer *LogAnalyzer) addNodeTitles(log *Log) {
+	if log.Response == nil || log.Response.Output == nil || log.Response.Output.Debug == nil {
+		return
+	}
+	for _, node := range log.Response.Output.Debug.NodesVisited {
+		if node.DialogNode != nil && node.Title != nil {
+			analyzer.nodeTitles[*node.DialogNode] = *node.Title
+		}
+	}
+}
+
+// AddLogs adds several logs to the analyzer.
+func (analyzer *LogAnalyzer) AddLogs(logs []Log) {
+	for i := range logs {
+		analyzer.Add(&logs[i])
+	}
+}
+
+// AddFromIterator adds every log returned by the iterator to the analyzer.
+func (analyzer *LogAnalyzer) AddFromIterator(ctx context.Context, iterator *LogsIterator) error {
+	for {
+		log, err := iterator.Next(ctx)
+		if err == ErrIteratorDone {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		analyzer.Add(log)
+	}
+}
+
+// Transcripts returns the conversations ordered by the time of their first turn, each with its turns in request
+// order.
+func (analyzer *LogAnalyzer) Transcripts() []ConversationTranscript {
+	transcripts := make([]ConversationTranscript, 0, len(analyzer.conversations))
+	for conversationID, turns := range analyzer.conversations {
+		sorted := append([]ConversationTurn(nil), turns...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return turnBefore(&sorted[i], &sorted[j])
+		})
+		transcripts = append(transcripts, ConversationTranscript{ConversationID: conversationID, Turns: sorted})
+	}
+	sort.Slice(transcripts, func(i, j int) bool {
+		first, second := &transcripts[i].Turns[0], &transcripts[j].Turns[0]
+		if turnBefore(first, second) != turnBefore(second, first) {
+			return turnBefore(first, second)
+		}
+		return transcripts[i].ConversationID < transcripts[j].ConversationID
+	})
+	return transcripts
+}
+
+func turnBefore(first *ConversationTurn, second *ConversationTurn) bool {
+	if !first.RequestTimestamp.IsZero() && !second.RequestTimestamp.IsZero() {
+		return first.RequestTimestamp.Before(second.RequestTimestamp)
+	}
+	return first.requestTimeString < second.requestTimeString
+}
+
+// Report computes intent, confidence and dialog node statistics over all logs added so far.
+func (analyzer *LogAnalyzer) Report() *LogReport {
+	report := &LogReport{
+		Conversations:      len(analyzer.conversations),
+		Intents:            []IntentCount{},
+		LowConfidenceTurns: []ConversationTurn{},
+		DialogNodes:        []DialogNodeCount{},
+	}
+	intents := make(map[string]*IntentCount)
+	confidences := make(map[string]float64)
+	nodes := make(map[string]*DialogNodeCount)
+
+	for _, transcript := range analyzer.Transcripts() {
+		for _, turn := range transcript.Turns {
+			report.TotalTurns++
+			if !turn.IntentRecognized {
+				report.IrrelevantTurns++
+			} else {
+				count, ok := intents[turn.Intent]
+				if !ok {
+					count = &IntentCount{Intent: turn.Intent}
+					intents[turn.Intent] = count
+				}
+				count.Count++
+				confidences[turn.Intent] += turn.Confidence
+				if turn.Confidence < analyzer.LowConfidenceThreshold {
+					report.LowConfidenceTurns = append(report.LowConfidenceTurns, turn)
+				}
+			}
+			if turn.AnythingElse {
+				report.AnythingElseTurns++
+			}
+			for _, node := range turn.NodesVisited {
+				count, ok := nodes[node]
+				if !ok {
+					count = &DialogNodeCount{DialogNode: node, Title: analyzer.nodeTitles[node]}
+					nodes[node] = count
+				}
+				count.Count++
+			}
+		}
+	}
+
+	for intent, count := range intents {
+		count.AverageConfidence = confidences[intent] / float64(count.Count)
+		report.Intents = append(report.Intents, *count)
+	}
+	sort.Slice(report.Intents, func(i, j int) bool {
+		if report.Intents[i].Count != report.Intents[j].Count {
+			return report.Intents[i].Count > report.Intents[j].Count
+		}
+		return report.Intents[i].Intent < report.Intents[j].Intent
+	})
+	for _, count := range nodes {
+		report.DialogNodes = append(report.DialogNodes, *count)
+	}
+	sort.Slice(report.DialogNodes, func(i, j int) bool {
+		if report.DialogNodes[i].Count != report.DialogNodes[j].Count {
+			return report.DialogNodes[i].Count > report.DialogNodes[j].Count
+		}
+		return report.DialogNodes[i].DialogNode < report.DialogNodes[j].DialogNode
+	})
+	sort.SliceStable(report.LowConfidenceTurns, func(i, j int) bool {
+		return turnBefore(&report.LowConfidenceTurns[i], &report.LowConfidenceTurns[j])
+	})
+	if report.TotalTurns > 0 {
+		report.IrrelevantRate = float64(report.IrrelevantTurns) / float64(report.TotalTurns)
+		report.AnythingElseRate = float64(report.AnythingElseTurns) / float64(report.TotalTurns)
+	}
+	return report
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	writer := csv.NewWriter(w)
+	err := writer.WriteAll(records)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteIntentsCSV writes the intent counts as CSV with the columns intent, count and average_confidence.
+func (report *LogReport) WriteIntentsCSV(w io.Writer) error {
+	records := [][]string{{"intent", "count", "average_confidence"}}
+	for _, intent := range report.Intents {
+		records = append(records, []string{intent.Intent, strconv.Itoa(intent.Count), formatFloat(intent.AverageConfidence)})
+	}
+	return writeCSV(w, records)
+}
+
+// WriteLowConfidenceCSV writes the low-confidence turns as CSV.
+func (report *LogReport) WriteLowConfidenceCSV(w io.Writer) error {
+	return writeTurnsCSV(w, report.LowConfidenceTurns)
+}
+
+// WriteDialogNodesCSV writes the dialog node visit counts as CSV with the columns dialog_node, title and count.
+func (report *LogReport) WriteDialogNodesCSV(w io.Writer) error {
+	records := [][]string{{"dialog_node", "title", "count"}}
+	for _, node := range report.DialogNodes {
+		records = append(records, []string{node.DialogNode, node.Title, strconv.Itoa(node.Count)})
+	}
+	return writeCSV(w, records)
+}
+
+// WriteSummaryCSV writes the overall turn counts and rates as metric/value CSV rows.
+func (report *LogReport) WriteSummaryCSV(w io.Writer) error {
+	return writeCSV(w, [][]string{
+		{"metric", "value"},
+		{"total_turns", strconv.Itoa(report.TotalTurns)},
+		{"conversations", strconv.Itoa(report.Conversations)},
+		{"irrelevant_turns", strconv.Itoa(report.IrrelevantTurns)},
+		{"irrelevant_rate", formatFloat(report.IrrelevantRate)},
+		{"anything_else_turns", strconv.Itoa(report.AnythingElseTurns)},
+		{"anything_else_rate", formatFloat(report.AnythingElseRate)},
+		{"low_confidence_turns", strconv.Itoa(len(report.LowConfidenceTurns))},
+	})
+}
+
+// WriteTranscriptsCSV writes every turn of the transcripts as one CSV row, grouped by conversation.
+func WriteTranscriptsCSV(w io.Writer, transcripts []ConversationTranscript) error {
+	var turns []ConversationTurn
+	for _, transcript := range transcripts {
+		turns = append(turns, transcript.Turns...)
+	}
+	return writeTurnsCSV(w, turns)
+}
+
+func writeTurnsCSV(w io.Writer, turns []ConversationTurn) error {
+	records := [][]string{{"conversation_id", "log_id", "request_timestamp", "input_text", "intent", "confidence", "response_text", "nodes_visited"}}
+	for _, turn := range turns {
+		records = append(records, []string{
+			turn.ConversationID,
+			turn.LogID,
+			turn.requestTimeString,
+			turn.InputText,
+			turn.Intent,
+			formatFloat(turn.Confidence),
+			strings.Join(turn.ResponseTexts, "\n"),
+			strings.Join(turn.NodesVisited, " "),
+		})
+	}
+	return writeCSV(w, records)
+}
+
+// ReadLogs reads logs exported as JSON. The input may contain a single JSON array of logs, LogCollection objects as
+// returned by ListLogs, individual log objects, or any sequence of these (such as newline-delimited JSON).
+func ReadLogs(r io.Reader) ([]Log, error) {
+	var logs []Log
+	decoder := json.NewDecoder(r)
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return logs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var rawLogs []map[string]json.RawMessage
+		if trimmed := strings.TrimSpace(string(value)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(value, &rawLogs)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var object map[string]json.RawMessage
+			err = json.Unmarshal(value, &object)
+			if err != nil {
+				return nil, err
+			}
+			if collection, ok := object["logs"]; ok {
+				err = json.Unmarshal(collection, &rawLogs)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				rawLogs = append(rawLogs, object)
+			}
+		}
+
+		for _, rawLog := range rawLogs {
+			var log *Log
+			err = core.UnmarshalModel(rawLog, "", &log, UnmarshalLog)
+			if err != nil {
+				return nil, fmt.Errorf("error unmarshalling log: %s", err.Error())
+			}
+			logs = append(logs, *log)
+		}
+	}
+}
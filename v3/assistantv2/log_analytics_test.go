@@ -0,0 +1,92 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`LogAnalyzer`, func() {
+	const exportedLogs = `[
+		{"log_id": "2", "session_id": "s1", "request_timestamp": "2022-03-01T10:00:05.000Z",
+		 "request": {"input": {"text": "I want pizza"}},
+		 "response": {"output": {"intents": [{"intent": "order", "confidence": 0.42}],
+			"generic": [{"response_type": "text", "text": "Which size?"}],
+			"debug": {"nodes_visited": [{"dialog_node": "node_order", "title": "Order", "conditions": "#order"}]}}}},
+		{"log_id": "1", "session_id": "s1", "request_timestamp": "2022-03-01T10:00:00.000Z",
+		 "request": {"input": {"text": "hello"}},
+		 "response": {"output": {"intents": [{"intent": "greeting", "confidence": 0.97}],
+			"generic": [{"response_type": "text", "text": "Hi!"}],
+			"debug": {"nodes_visited": [{"dialog_node": "node_welcome", "title": "Welcome", "conditions": "#greeting"}]}}}}
+	]
+	{"logs": [
+		{"log_id": "3", "session_id": "s2", "request_timestamp": "2022-03-01T11:00:00.000Z",
+		 "request": {"input": {"text": "blah"}},
+		 "response": {"output": {"intents": [],
+			"generic": [{"response_type": "text", "text": "I didn't understand."}],
+			"debug": {"nodes_visited": [{"dialog_node": "node_else", "title": "Anything else", "conditions": "anything_else"}]}}}},
+		{"log_id": "1", "session_id": "s1", "request_timestamp": "2022-03-01T10:00:00.000Z"}
+	], "pagination": {}}`
+
+	It(`Rebuilds transcripts and reports intent statistics`, func() {
+		logs, err := assistantv2.ReadLogs(strings.NewReader(exportedLogs))
+		Expect(err).To(BeNil())
+		Expect(logs).To(HaveLen(4))
+
+		analyzer := assistantv2.NewLogAnalyzer()
+		analyzer.AddLogs(logs)
+
+		transcripts := analyzer.Transcripts()
+		Expect(transcripts).To(HaveLen(2))
+		Expect(transcripts[0].ConversationID).To(Equal("s1"))
+		Expect(transcripts[0].Turns).To(HaveLen(2))
+		Expect(transcripts[0].Turns[0].InputText).To(Equal("hello"))
+		Expect(transcripts[0].Turns[1].ResponseTexts).To(Equal([]string{"Which size?"}))
+
+		report := analyzer.Report()
+		Expect(report.TotalTurns).To(Equal(3))
+		Expect(report.Conversations).To(Equal(2))
+		Expect(report.Intents).To(HaveLen(2))
+		Expect(report.LowConfidenceTurns).To(HaveLen(1))
+		Expect(report.LowConfidenceTurns[0].Intent).To(Equal("order"))
+		Expect(report.IrrelevantTurns).To(Equal(1))
+		Expect(report.AnythingElseTurns).To(Equal(1))
+		Expect(report.AnythingElseRate).To(BeNumerically("~", 1.0/3))
+		Expect(report.DialogNodes).To(HaveLen(3))
+
+		var buffer bytes.Buffer
+		Expect(report.WriteDialogNodesCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("node_else,Anything else,1\n"))
+
+		buffer.Reset()
+		Expect(report.WriteIntentsCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal("intent,count,average_confidence\ngreeting,1,0.97\norder,1,0.42\n"))
+
+		buffer.Reset()
+		Expect(assistantv2.WriteTranscriptsCSV(&buffer, transcripts)).To(Succeed())
+		Expect(strings.Count(buffer.String(), "\n")).To(Equal(4))
+	})
+	It(`Rejects malformed input`, func() {
+		_, err := assistantv2.ReadLogs(strings.NewReader(`{"logs": [`))
+		Expect(err).ToNot(BeNil())
+	})
+})
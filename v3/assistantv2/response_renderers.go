@@ -0,0 +1,679 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ResponseRenderer : Converts the generic output of an assistant into the format of a particular channel.
+type ResponseRenderer interface {
+	Render(responses []RuntimeResponseGenericIntf) (string, error)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func filterForChannel(responses []RuntimeResponseGenericIntf, channel string) []RuntimeResponseGenericIntf {
+	if channel == "" {
+		return responses
+	}
+	return FilterRuntimeResponseGenericByChannel(responses, channel)
+}
+
+func optionInputText(value *DialogNodeOutputOptionsElementValue) string {
+	if value == nil || value.Input == nil {
+		return ""
+	}
+	return stringValue(value.Input.Text)
+}
+
+func agentMessage(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent, agentAvailable bool) string {
+	message := response.AgentUnavailable
+	if agentAvailable {
+		message = response.AgentAvailable
+	}
+	if message == nil {
+		return ""
+	}
+	return stringValue(message.Message)
+}
+
+func transferURL(info *ChannelTransferInfo) string {
+	if info == nil || info.Target == nil || info.Target.Chat == nil {
+		return ""
+	}
+	return stringValue(info.Target.Chat.URL)
+}
+
+// TextRenderer : Renders responses as plain text, one paragraph per response. Options and suggestions become numbered
+// lists and media responses become their title followed by their URL.
+type TextRenderer struct {
+	// If set, only responses that apply to this channel are rendered.
+	Channel string
+
+	// Whether a human agent is available, which selects the message rendered for connect_to_agent responses.
+	AgentAvailable bool
+
+	// Renders user_defined responses. They are skipped when nil.
+	UserDefined func(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) (string, error)
+
+	// Renders responses of types the renderer does not know, such as types added to the service later. They are
+	// skipped when nil.
+	Unknown func(RuntimeResponseGenericIntf) (string, error)
+}
+
+// NewTextRenderer : Instantiate TextRenderer for the "chat" channel
+func NewTextRenderer() *TextRenderer {
+	return &TextRenderer{Channel: ResponseGenericChannelChannelChatConst}
+}
+
+// Render renders the responses as plain text.
+func (renderer *TextRenderer) Render(responses []RuntimeResponseGenericIntf) (string, error) {
+	var paragraphs []string
+	add := func(lines ...string) {
+		var nonEmpty []string
+		for _, line := range lines {
+			if line != "" {
+				nonEmpty = append(nonEmpty, line)
+			}
+		}
+		if len(nonEmpty) > 0 {
+			paragraphs = append(paragraphs, strings.Join(nonEmpty, "\n"))
+		}
+	}
+	media := func(title *string, source *string) error {
+		add(stringValue(title), stringValue(source))
+		return nil
+	}
+
+	err := VisitRuntimeResponseGeneric(filterForChannel(responses, renderer.Channel), RuntimeResponseGenericVisitorFuncs{
+		Text: func(response *RuntimeResponseGenericRuntimeResponseTypeText) error {
+			add(stringValue(response.Text))
+			return nil
+		},
+		Option: func(response *RuntimeResponseGenericRuntimeResponseTypeOption) error {
+			lines := []string{stringValue(response.Title), stringValue(response.Description)}
+			for i, option := range response.Options {
+				lines = append(lines, fmt.Sprintf("%d. %s", i+1, stringValue(option.Label)))
+			}
+			add(lines...)
+			return nil
+		},
+		Suggestion: func(response *RuntimeResponseGenericRuntimeResponseTypeSuggestion) error {
+			lines := []string{stringValue(response.Title)}
+			for i, suggestion := range response.Suggestions {
+				lines = append(lines, fmt.Sprintf("%d. %s", i+1, stringValue(suggestion.Label)))
+			}
+			add(lines...)
+			return nil
+		},
+		Search: func(response *RuntimeResponseGenericRuntimeResponseTypeSearch) error {
+			lines := []string{stringValue(response.Header)}
+			for _, result := range response.PrimaryResults {
+				lines = append(lines, "- "+strings.TrimSpace(stringValue(result.Title)+" "+stringValue(result.URL)))
+			}
+			add(lines...)
+			return nil
+		},
+		Image: func(response *RuntimeResponseGenericRuntimeResponseTypeImage) error {
+			return media(response.Title, response.Source)
+		},
+		Audio: func(response *RuntimeResponseGenericRuntimeResponseTypeAudio) error {
+			return media(response.Title, response.Source)
+		},
+		Video: func(response *RuntimeResponseGenericRuntimeResponseTypeVideo) error {
+			return media(response.Title, response.Source)
+		},
+		Iframe: func(response *RuntimeResponseGenericRuntimeResponseTypeIframe) error {
+			return media(response.Title, response.Source)
+		},
+		ChannelTransfer: func(response *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error {
+			add(stringValue(response.MessageToUser), transferURL(response.TransferInfo))
+			return nil
+		},
+		ConnectToAgent: func(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error {
+			add(agentMessage(response, renderer.AgentAvailable))
+			return nil
+		},
+		UserDefined: func(response *RuntimeResponseGenericRuntimeResponseTypeUserDefined) error {
+			if renderer.UserDefined == nil {
+				return nil
+			}
+			text, err := renderer.UserDefined(response)
+			add(text)
+			return err
+		},
+		Pause: func(*RuntimeResponseGenericRuntimeResponseTypePause) error {
+			return nil
+		},
+		Default: func(response RuntimeResponseGenericIntf) error {
+			if renderer.Unknown == nil {
+				return nil
+			}
+			text, err := renderer.Unknown(response)
+			add(text)
+			return err
+		},
+	})
+	return strings.Join(paragraphs, "\n\n"), err
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+)
+
+// markdownURLEscaper escapes the characters that would end the destination of a Markdown link or image.
+var markdownURLEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+
+// MarkdownRenderer : Renders responses as Markdown. Text responses are passed through unchanged, since dialog authors
+// commonly write them in Markdown; all other values are escaped.
+type MarkdownRenderer struct {
+	// If set, only responses that apply to this channel are rendered.
+	Channel string
+
+	// Whether a human agent is available, which selects the message rendered for connect_to_agent responses.
+	AgentAvailable bool
+
+	// Renders user_defined responses as Markdown. They are skipped when nil.
+	UserDefined func(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) (string, error)
+
+	// Renders responses of types the renderer does not know as Markdown. They are skipped when nil.
+	Unknown func(RuntimeResponseGenericIntf) (string, error)
+}
+
+// NewMarkdownRenderer : Instantiate MarkdownRenderer for the "chat" channel
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{Channel: ResponseGenericChannelChannelChatConst}
+}
+
+// Render renders the responses as Markdown.
+func (renderer *MarkdownRenderer) Render(responses []RuntimeResponseGenericIntf) (string, error) {
+	var blocks []string
+	add := func(block string) {
+		if block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	escape := func(s *string) string {
+		return markdownEscaper.Replace(stringValue(s))
+	}
+	link := func(title *string, source *string) string {
+		if source == nil {
+			return escape(title)
+		}
+		text := escape(title)
+		if text == "" {
+			text = escape(source)
+		}
+		return fmt.Sprintf("[%s](%s)", text, markdownURLEscaper.Replace(*source))
+	}
+	list := func(title string, items []string) {
+		lines := []string{}
+		if title != "" {
+			lines = append(lines, "**"+title+"**")
+		}
+		for _, item := range items {
+			lines = append(lines, "- "+item)
+		}
+		add(strings.Join(lines, "\n"))
+	}
+
+	err := VisitRuntimeResponseGeneric(filterForChannel(responses, renderer.Channel), RuntimeResponseGenericVisitorFuncs{
+		Text: func(response *RuntimeResponseGenericRuntimeResponseTypeText) error {
+			add(stringValue(response.Text))
+			return nil
+		},
+		Option: func(response *RuntimeResponseGenericRuntimeResponseTypeOption) error {
+			var items []string
+			for _, option := range response.Options {
+				items = append(items, escape(option.Label))
+			}
+			list(escape(response.Title), items)
+			return nil
+		},
+		Suggestion: func(response *RuntimeResponseGenericRuntimeResponseTypeSuggestion) error {
+			var items []string
+			for _, suggestion := range response.Suggestions {
+				items = append(items, escape(suggestion.Label))
+			}
+			list(escape(response.Title), items)
+			return nil
+		},
+		Search: func(response *RuntimeResponseGenericRuntimeResponseTypeSearch) error {
+			var items []string
+			for _, result := range response.PrimaryResults {
+				items = append(items, link(result.Title, result.URL))
+			}
+			list(escape(response.Header), items)
+			return nil
+		},
+		Image: func(response *RuntimeResponseGenericRuntimeResponseTypeImage) error {
+			alt := response.AltText
+			if alt == nil {
+				alt = response.Title
+			}
+			add(fmt.Sprintf("![%s](%s)", escape(alt), markdownURLEscaper.Replace(stringValue(response.Source))))
+			return nil
+		},
+		Audio: func(response *RuntimeResponseGenericRuntimeResponseTypeAudio) error {
+			add(link(response.Title, response.Source))
+			return nil
+		},
+		Video: func(response *RuntimeResponseGenericRuntimeResponseTypeVideo) error {
+			add(link(response.Title, response.Source))
+			return nil
+		},
+		Iframe: func(response *RuntimeResponseGenericRuntimeResponseTypeIframe) error {
+			add(link(response.Title, response.Source))
+			return nil
+		},
+		ChannelTransfer: func(response *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error {
+			url := transferURL(response.TransferInfo)
+			if url == "" {
+				add(escape(response.MessageToUser))
+			} else {
+				add(link(response.MessageToUser, &url))
+			}
+			return nil
+		},
+		ConnectToAgent: func(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error {
+			message := agentMessage(response, renderer.AgentAvailable)
+			add(escape(&message))
+			return nil
+		},
+		UserDefined: func(response *RuntimeResponseGenericRuntimeResponseTypeUserDefined) error {
+			if renderer.UserDefined == nil {
+				return nil
+			}
+			markdown, err := renderer.UserDefined(response)
+			add(markdown)
+			return err
+		},
+		Pause: func(*RuntimeResponseGenericRuntimeResponseTypePause) error {
+			return nil
+		},
+		Default: func(response RuntimeResponseGenericIntf) error {
+			if renderer.Unknown == nil {
+				return nil
+			}
+			markdown, err := renderer.Unknown(response)
+			add(markdown)
+			return err
+		},
+	})
+	return strings.Join(blocks, "\n\n"), err
+}
+
+// SlackBlock : A Slack Block Kit block, as sent in the "blocks" property of a Slack message.
+type SlackBlock map[string]interface{}
+
+// SlackRenderer : Renders responses as Slack Block Kit blocks. Options and suggestions become buttons (or a static
+// select when the option preference is "dropdown") whose values are the input text to send back to the assistant.
+// Text in mrkdwn sections is escaped, so that assistant output cannot add links or mentions.
+type SlackRenderer struct {
+	// If set, only responses that apply to this channel are rendered.
+	Channel string
+
+	// Whether a human agent is available, which selects the message rendered for connect_to_agent responses.
+	AgentAvailable bool
+
+	// Renders user_defined responses as blocks. They are skipped when nil.
+	UserDefined func(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) ([]SlackBlock, error)
+
+	// Renders responses of types the renderer does not know as blocks. They are skipped when nil.
+	Unknown func(RuntimeResponseGenericIntf) ([]SlackBlock, error)
+}
+
+// NewSlackRenderer : Instantiate SlackRenderer for the "slack" channel
+func NewSlackRenderer() *SlackRenderer {
+	return &SlackRenderer{Channel: ResponseGenericChannelChannelSlackConst}
+}
+
+// Defaults of the Slack fields that must not be empty.
+const (
+	slackDefaultPlaceholder = "Choose an option"
+	slackDefaultAltText     = "Image"
+)
+
+func slackText(textType string, text string) map[string]interface{} {
+	return map[string]interface{}{"type": textType, "text": text}
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeSlack(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+func slackSection(text string) SlackBlock {
+	return SlackBlock{"type": "section", "text": slackText("mrkdwn", text)}
+}
+
+func slackChoices(title string, labels []string, values []string, dropdown bool) []SlackBlock {
+	var blocks []SlackBlock
+	if title != "" {
+		blocks = append(blocks, slackSection(escapeSlack(title)))
+	}
+	if len(labels) == 0 {
+		return blocks
+	}
+	if dropdown {
+		// Slack rejects empty plain text.
+		placeholder := title
+		if placeholder == "" {
+			placeholder = slackDefaultPlaceholder
+		}
+		var options []map[string]interface{}
+		for i := range labels {
+			options = append(options, map[string]interface{}{"text": slackText("plain_text", labels[i]), "value": values[i]})
+		}
+		return append(blocks, SlackBlock{
+			"type": "actions",
+			"elements": []map[string]interface{}{{
+				"type":        "static_select",
+				"placeholder": slackText("plain_text", placeholder),
+				"options":     options,
+			}},
+		})
+	}
+	var elements []map[string]interface{}
+	for i := range labels {
+		elements = append(elements, map[string]interface{}{"type": "button", "text": slackText("plain_text", labels[i]), "value": values[i]})
+	}
+	return append(blocks, SlackBlock{"type": "actions", "elements": elements})
+}
+
+// RenderBlocks renders the responses as a list of Slack blocks.
+func (renderer *SlackRenderer) RenderBlocks(responses []RuntimeResponseGenericIntf) ([]SlackBlock, error) {
+	blocks := []SlackBlock{}
+	link := func(title *string, source *string) {
+		text := stringValue(title)
+		if text == "" {
+			text = stringValue(source)
+		}
+		blocks = append(blocks, slackSection(fmt.Sprintf("<%s|%s>", escapeSlack(stringValue(source)), escapeSlack(text))))
+	}
+
+	err := VisitRuntimeResponseGeneric(filterForChannel(responses, renderer.Channel), RuntimeResponseGenericVisitorFuncs{
+		Text: func(response *RuntimeResponseGenericRuntimeResponseTypeText) error {
+			blocks = append(blocks, slackSection(escapeSlack(stringValue(response.Text))))
+			return nil
+		},
+		Option: func(response *RuntimeResponseGenericRuntimeResponseTypeOption) error {
+			var labels, values []string
+			for _, option := range response.Options {
+				labels = append(labels, stringValue(option.Label))
+				values = append(values, optionInputText(option.Value))
+			}
+			dropdown := stringValue(response.Preference) == RuntimeResponseGenericRuntimeResponseTypeOptionPreferenceDropdownConst
+			blocks = append(blocks, slackChoices(stringValue(response.Title), labels, values, dropdown)...)
+			return nil
+		},
+		Suggestion: func(response *RuntimeResponseGenericRuntimeResponseTypeSuggestion) error {
+			var labels, values []string
+			for _, suggestion := range response.Suggestions {
+				labels = append(labels, stringValue(suggestion.Label))
+				value := ""
+				if suggestion.Value != nil && suggestion.Value.Input != nil {
+					value = stringValue(suggestion.Value.Input.Text)
+				}
+				values = append(values, value)
+			}
+			blocks = append(blocks, slackChoices(stringValue(response.Title), labels, values, false)...)
+			return nil
+		},
+		Search: func(response *RuntimeResponseGenericRuntimeResponseTypeSearch) error {
+			if response.Header != nil {
+				blocks = append(blocks, slackSection(escapeSlack(*response.Header)))
+			}
+			for _, result := range response.PrimaryResults {
+				if result.URL != nil {
+					link(result.Title, result.URL)
+				} else {
+					blocks = append(blocks, slackSection(escapeSlack(stringValue(result.Title))))
+				}
+			}
+			return nil
+		},
+		Image: func(response *RuntimeResponseGenericRuntimeResponseTypeImage) error {
+			alt := stringValue(response.AltText)
+			if alt == "" {
+				alt = stringValue(response.Title)
+			}
+			if alt == "" {
+				alt = slackDefaultAltText
+			}
+			block := SlackBlock{"type": "image", "image_url": stringValue(response.Source), "alt_text": alt}
+			if stringValue(response.Title) != "" {
+				block["title"] = slackText("plain_text", *response.Title)
+			}
+			blocks = append(blocks, block)
+			return nil
+		},
+		Audio: func(response *RuntimeResponseGenericRuntimeResponseTypeAudio) error {
+			link(response.Title, response.Source)
+			return nil
+		},
+		Video: func(response *RuntimeResponseGenericRuntimeResponseTypeVideo) error {
+			link(response.Title, response.Source)
+			return nil
+		},
+		Iframe: func(response *RuntimeResponseGenericRuntimeResponseTypeIframe) error {
+			link(response.Title, response.Source)
+			return nil
+		},
+		ChannelTransfer: func(response *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error {
+			if url := transferURL(response.TransferInfo); url != "" {
+				link(response.MessageToUser, &url)
+			} else {
+				blocks = append(blocks, slackSection(escapeSlack(stringValue(response.MessageToUser))))
+			}
+			return nil
+		},
+		ConnectToAgent: func(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error {
+			if message := agentMessage(response, renderer.AgentAvailable); message != "" {
+				blocks = append(blocks, slackSection(escapeSlack(message)))
+			}
+			return nil
+		},
+		UserDefined: func(response *RuntimeResponseGenericRuntimeResponseTypeUserDefined) error {
+			if renderer.UserDefined == nil {
+				return nil
+			}
+			userBlocks, err := renderer.UserDefined(response)
+			blocks = append(blocks, userBlocks...)
+			return err
+		},
+		Pause: func(*RuntimeResponseGenericRuntimeResponseTypePause) error {
+			return nil
+		},
+		Default: func(response RuntimeResponseGenericIntf) error {
+			if renderer.Unknown == nil {
+				return nil
+			}
+			unknownBlocks, err := renderer.Unknown(response)
+			blocks = append(blocks, unknownBlocks...)
+			return err
+		},
+	})
+	return blocks, err
+}
+
+// Render renders the responses as a JSON Slack message payload of the form {"blocks": [...]}.
+func (renderer *SlackRenderer) Render(responses []RuntimeResponseGenericIntf) (string, error) {
+	blocks, err := renderer.RenderBlocks(responses)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(map[string]interface{}{"blocks": blocks})
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// SSMLRenderer : Renders responses as a single SSML document for voice channels. The result can be used directly as
+// the text of a Text to Speech synthesize request. Pauses become breaks and options are read out as a list; visual
+// responses such as images are described by their alternative text, if any, and audio responses by their title.
+type SSMLRenderer struct {
+	// If set, only responses that apply to this channel are rendered.
+	Channel string
+
+	// Whether a human agent is available, which selects the message rendered for connect_to_agent responses.
+	AgentAvailable bool
+
+	// The word placed before the last option when options are read out. Defaults to "or".
+	OptionConjunction string
+
+	// Renders user_defined responses as SSML fragments, which are inserted without escaping. They are skipped when nil.
+	UserDefined func(*RuntimeResponseGenericRuntimeResponseTypeUserDefined) (string, error)
+
+	// Renders responses of types the renderer does not know as SSML fragments, which are inserted without escaping.
+	// They are skipped when nil.
+	Unknown func(RuntimeResponseGenericIntf) (string, error)
+}
+
+// NewSSMLRenderer : Instantiate SSMLRenderer for the "voice_telephony" channel
+func NewSSMLRenderer() *SSMLRenderer {
+	return &SSMLRenderer{Channel: ResponseGenericChannelChannelVoiceTelephonyConst}
+}
+
+func escapeSSML(s string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(s))
+	return builder.String()
+}
+
+// Render renders the responses as an SSML <speak> document.
+func (renderer *SSMLRenderer) Render(responses []RuntimeResponseGenericIntf) (string, error) {
+	conjunction := renderer.OptionConjunction
+	if conjunction == "" {
+		conjunction = "or"
+	}
+	var fragments []string
+	say := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			fragments = append(fragments, escapeSSML(s))
+		}
+	}
+	readList := func(title string, labels []string) {
+		say(title)
+		for i, label := range labels {
+			if i > 0 {
+				fragments = append(fragments, `<break strength="medium"/>`)
+			}
+			if i > 0 && i == len(labels)-1 {
+				label = conjunction + " " + label
+			}
+			say(label)
+		}
+	}
+
+	err := VisitRuntimeResponseGeneric(filterForChannel(responses, renderer.Channel), RuntimeResponseGenericVisitorFuncs{
+		Text: func(response *RuntimeResponseGenericRuntimeResponseTypeText) error {
+			say(stringValue(response.Text))
+			return nil
+		},
+		Pause: func(response *RuntimeResponseGenericRuntimeResponseTypePause) error {
+			if response.Time != nil && *response.Time > 0 {
+				fragments = append(fragments, fmt.Sprintf(`<break time="%dms"/>`, *response.Time))
+			}
+			return nil
+		},
+		Option: func(response *RuntimeResponseGenericRuntimeResponseTypeOption) error {
+			var labels []string
+			for _, option := range response.Options {
+				labels = append(labels, stringValue(option.Label))
+			}
+			readList(stringValue(response.Title), labels)
+			return nil
+		},
+		Suggestion: func(response *RuntimeResponseGenericRuntimeResponseTypeSuggestion) error {
+			var labels []string
+			for _, suggestion := range response.Suggestions {
+				labels = append(labels, stringValue(suggestion.Label))
+			}
+			readList(stringValue(response.Title), labels)
+			return nil
+		},
+		Search: func(response *RuntimeResponseGenericRuntimeResponseTypeSearch) error {
+			say(stringValue(response.Header))
+			if len(response.PrimaryResults) > 0 {
+				result := response.PrimaryResults[0]
+				if len(result.Answers) > 0 {
+					say(stringValue(result.Answers[0].Text))
+				} else {
+					say(stringValue(result.Body))
+				}
+			}
+			return nil
+		},
+		Image: func(response *RuntimeResponseGenericRuntimeResponseTypeImage) error {
+			say(stringValue(response.AltText))
+			return nil
+		},
+		Video: func(response *RuntimeResponseGenericRuntimeResponseTypeVideo) error {
+			say(stringValue(response.AltText))
+			return nil
+		},
+		Audio: func(response *RuntimeResponseGenericRuntimeResponseTypeAudio) error {
+			// Text to Speech does not play <audio> elements, so the title is read instead.
+			say(stringValue(response.Title))
+			return nil
+		},
+		Iframe: func(*RuntimeResponseGenericRuntimeResponseTypeIframe) error {
+			return nil
+		},
+		ChannelTransfer: func(response *RuntimeResponseGenericRuntimeResponseTypeChannelTransfer) error {
+			say(stringValue(response.MessageToUser))
+			return nil
+		},
+		ConnectToAgent: func(response *RuntimeResponseGenericRuntimeResponseTypeConnectToAgent) error {
+			say(agentMessage(response, renderer.AgentAvailable))
+			return nil
+		},
+		UserDefined: func(response *RuntimeResponseGenericRuntimeResponseTypeUserDefined) error {
+			if renderer.UserDefined == nil {
+				return nil
+			}
+			fragment, err := renderer.UserDefined(response)
+			if fragment != "" {
+				fragments = append(fragments, fragment)
+			}
+			return err
+		},
+		Default: func(response RuntimeResponseGenericIntf) error {
+			if renderer.Unknown == nil {
+				return nil
+			}
+			fragment, err := renderer.Unknown(response)
+			if fragment != "" {
+				fragments = append(fragments, fragment)
+			}
+			return err
+		},
+	})
+	return "<speak>" + strings.Join(fragments, " ") + "</speak>", err
+}
@@ -0,0 +1,121 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+var _ = Describe(`ResponseRenderer`, func() {
+	output := unmarshalTestMessageOutput(`{"generic": [
+		{"response_type": "text", "text": "Hi *there* & welcome"},
+		{"response_type": "pause", "time": 750, "typing": true},
+		{"response_type": "option", "title": "Pick a size", "options": [
+			{"label": "Small", "value": {"input": {"text": "small"}}},
+			{"label": "Medium", "value": {"input": {"text": "medium"}}},
+			{"label": "Large", "value": {"input": {"text": "large"}}}
+		]},
+		{"response_type": "image", "source": "https://example.com/a.png", "title": "A picture", "alt_text": "A cat"},
+		{"response_type": "text", "text": "Slack only", "channels": [{"channel": "slack"}]},
+		{"response_type": "user_defined", "user_defined": {"card": "receipt"}}
+	]}`)
+
+	It(`Renders plain text`, func() {
+		renderer := assistantv2.NewTextRenderer()
+		text, err := renderer.Render(output.Generic)
+		Expect(err).To(BeNil())
+		Expect(text).To(Equal("Hi *there* & welcome\n\nPick a size\n1. Small\n2. Medium\n3. Large\n\nA picture\nhttps://example.com/a.png"))
+	})
+	It(`Renders Markdown with user-defined extensions`, func() {
+		renderer := assistantv2.NewMarkdownRenderer()
+		renderer.UserDefined = func(response *assistantv2.RuntimeResponseGenericRuntimeResponseTypeUserDefined) (string, error) {
+			return fmt.Sprintf("_card: %s_", response.UserDefined["card"]), nil
+		}
+		markdown, err := renderer.Render(output.Generic)
+		Expect(err).To(BeNil())
+		Expect(markdown).To(Equal("Hi *there* & welcome\n\n**Pick a size**\n- Small\n- Medium\n- Large\n\n![A cat](https://example.com/a.png)\n\n_card: receipt_"))
+	})
+	It(`Renders Slack blocks`, func() {
+		renderer := assistantv2.NewSlackRenderer()
+		payload, err := renderer.Render(output.Generic)
+		Expect(err).To(BeNil())
+
+		var message struct {
+			Blocks []map[string]interface{} `json:"blocks"`
+		}
+		Expect(json.Unmarshal([]byte(payload), &message)).To(Succeed())
+		Expect(message.Blocks).To(HaveLen(5))
+		Expect(message.Blocks[0]["type"]).To(Equal("section"))
+		Expect(message.Blocks[0]["text"].(map[string]interface{})["text"]).To(Equal("Hi *there* &amp; welcome"))
+		Expect(message.Blocks[2]["type"]).To(Equal("actions"))
+		elements := message.Blocks[2]["elements"].([]interface{})
+		Expect(elements).To(HaveLen(3))
+		Expect(elements[1].(map[string]interface{})["value"]).To(Equal("medium"))
+		Expect(message.Blocks[3]["type"]).To(Equal("image"))
+		Expect(message.Blocks[3]["alt_text"]).To(Equal("A cat"))
+		Expect(message.Blocks[4]["text"].(map[string]interface{})["text"]).To(Equal("Slack only"))
+	})
+	It(`Escapes links`, func() {
+		links := unmarshalTestMessageOutput(`{"generic": [
+			{"response_type": "video", "source": "https://example.com/a (1).mp4", "title": "Intro"},
+			{"response_type": "audio", "source": "https://example.com/a.mp3?x=1&y=2", "title": "<@U123> listen"}
+		]}`)
+		markdown, err := (&assistantv2.MarkdownRenderer{}).Render(links.Generic)
+		Expect(err).To(BeNil())
+		Expect(markdown).To(Equal("[Intro](https://example.com/a%20%281%29.mp4)\n\n[\\<@U123\\> listen](https://example.com/a.mp3?x=1&y=2)"))
+
+		blocks, err := (&assistantv2.SlackRenderer{}).RenderBlocks(links.Generic)
+		Expect(err).To(BeNil())
+		Expect(blocks[1]["text"].(map[string]interface{})["text"]).To(Equal("<https://example.com/a.mp3?x=1&amp;y=2|&lt;@U123&gt; listen>"))
+	})
+	It(`Renders SSML`, func() {
+		renderer := assistantv2.NewSSMLRenderer()
+		ssml, err := renderer.Render(output.Generic)
+		Expect(err).To(BeNil())
+		Expect(ssml).To(Equal(`<speak>Hi *there* &amp; welcome <break time="750ms"/> Pick a size Small <break strength="medium"/> Medium <break strength="medium"/> or Large A cat</speak>`))
+	})
+	It(`Fills required Slack fields, reads audio titles and renders unknown types with a hook`, func() {
+		responses := unmarshalTestMessageOutput(`{"generic": [
+			{"response_type": "option", "preference": "dropdown", "options": [{"label": "Small", "value": {"input": {"text": "small"}}}]},
+			{"response_type": "image", "source": "https://example.com/a.png"},
+			{"response_type": "audio", "source": "https://example.com/a.mp3", "title": "Our jingle"}
+		]}`).Generic
+		responses = append(responses, &assistantv2.RuntimeResponseGeneric{ResponseType: core.StringPtr("date")})
+
+		blocks, err := (&assistantv2.SlackRenderer{}).RenderBlocks(responses)
+		Expect(err).To(BeNil())
+		Expect(blocks).To(HaveLen(3))
+		elements := blocks[0]["elements"].([]map[string]interface{})
+		Expect(elements[0]["placeholder"]).To(Equal(map[string]interface{}{"type": "plain_text", "text": "Choose an option"}))
+		Expect(blocks[1]["alt_text"]).To(Equal("Image"))
+		Expect(blocks[1]).ToNot(HaveKey("title"))
+
+		renderer := &assistantv2.SSMLRenderer{}
+		renderer.Unknown = func(response assistantv2.RuntimeResponseGenericIntf) (string, error) {
+			return "unknown " + assistantv2.GetRuntimeResponseGenericType(response), nil
+		}
+		ssml, err := renderer.Render(responses)
+		Expect(err).To(BeNil())
+		Expect(ssml).To(Equal(`<speak>Small Our jingle unknown date</speak>`))
+	})
+})
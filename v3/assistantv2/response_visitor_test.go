@@ -0,0 +1,103 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assistantv2_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+func unmarshalTestMessageOutput(data string) *assistantv2.MessageOutput {
+	var raw map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+	var output *assistantv2.MessageOutput
+	Expect(core.UnmarshalModel(raw, "", &output, assistantv2.UnmarshalMessageOutput)).To(Succeed())
+	return output
+}
+
+var _ = Describe(`RuntimeResponseGenericVisitor`, func() {
+	output := unmarshalTestMessageOutput(`{"generic": [
+		{"response_type": "text", "text": "Hello"},
+		{"response_type": "pause", "time": 500, "typing": true, "channels": [{"channel": "chat"}]},
+		{"response_type": "option", "title": "Pick one", "options": [{"label": "A", "value": {"input": {"text": "a"}}}]},
+		{"response_type": "text", "text": "Say it", "channels": [{"channel": "voice_telephony"}]},
+		{"response_type": "user_defined", "user_defined": {"card": "x"}}
+	]}`)
+
+	It(`Dispatches each response type to its function`, func() {
+		var visited []string
+		visitor := assistantv2.RuntimeResponseGenericVisitorFuncs{
+			Text: func(response *assistantv2.RuntimeResponseGenericRuntimeResponseTypeText) error {
+				visited = append(visited, "text:"+*response.Text)
+				return nil
+			},
+			Option: func(response *assistantv2.RuntimeResponseGenericRuntimeResponseTypeOption) error {
+				visited = append(visited, "option:"+*response.Options[0].Label)
+				return nil
+			},
+			Default: func(response assistantv2.RuntimeResponseGenericIntf) error {
+				visited = append(visited, "default:"+assistantv2.GetRuntimeResponseGenericType(response))
+				return nil
+			},
+		}
+		Expect(assistantv2.VisitRuntimeResponseGeneric(output.Generic, visitor)).To(Succeed())
+		Expect(visited).To(Equal([]string{"text:Hello", "default:pause", "option:A", "text:Say it", "default:user_defined"}))
+	})
+	It(`Passes models without a dedicated method to the default handler`, func() {
+		var unknown assistantv2.RuntimeResponseGenericIntf
+		visitor := assistantv2.RuntimeResponseGenericVisitorFuncs{
+			Default: func(response assistantv2.RuntimeResponseGenericIntf) error {
+				unknown = response
+				return nil
+			},
+		}
+		base := &assistantv2.RuntimeResponseGeneric{ResponseType: core.StringPtr("carousel")}
+		Expect(assistantv2.AcceptRuntimeResponseGeneric(base, visitor)).To(Succeed())
+		Expect(unknown).To(Equal(base))
+		Expect(assistantv2.GetRuntimeResponseGenericType(base)).To(Equal("carousel"))
+	})
+	It(`Stops at the first error`, func() {
+		calls := 0
+		visitor := assistantv2.RuntimeResponseGenericVisitorFuncs{
+			Default: func(assistantv2.RuntimeResponseGenericIntf) error {
+				calls++
+				return errors.New("stop")
+			},
+		}
+		Expect(assistantv2.VisitRuntimeResponseGeneric(output.Generic, visitor)).To(MatchError("stop"))
+		Expect(calls).To(Equal(1))
+	})
+	It(`Filters responses by channel`, func() {
+		Expect(assistantv2.FilterRuntimeResponseGenericByChannel(output.Generic, assistantv2.ResponseGenericChannelChannelSlackConst)).To(HaveLen(3))
+		Expect(assistantv2.FilterRuntimeResponseGenericByChannel(output.Generic, assistantv2.ResponseGenericChannelChannelChatConst)).To(HaveLen(4))
+
+		var texts []string
+		err := output.VisitForChannel(assistantv2.ResponseGenericChannelChannelVoiceTelephonyConst, assistantv2.RuntimeResponseGenericVisitorFuncs{
+			Text: func(response *assistantv2.RuntimeResponseGenericRuntimeResponseTypeText) error {
+				texts = append(texts, *response.Text)
+				return nil
+			},
+		})
+		Expect(err).To(BeNil())
+		Expect(texts).To(Equal([]string{"Hello", "Say it"}))
+	})
+})
@@ -0,0 +1,250 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoverysync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+// DefaultPageSize is the default number of documents requested per query when listing a collection.
+const DefaultPageSize = 100
+
+// syncFilter matches the documents that were uploaded by a sync.
+var syncFilter = discoveryv2.QueryAnd(
+	discoveryv2.NewQueryField("metadata."+MetadataPathField).Exists(),
+	discoveryv2.NewQueryField("metadata."+MetadataHashField).Exists(),
+).String()
+
+// remoteDocument returns the sync metadata of a query result, or false if the result was not uploaded by a sync.
+func remoteDocument(documentID *string, metadata map[string]interface{}) (RemoteDocument, bool) {
+	path, _ := metadata[MetadataPathField].(string)
+	hash, _ := metadata[MetadataHashField].(string)
+	if documentID == nil || path == "" || hash == "" {
+		return RemoteDocument{}, false
+	}
+	return RemoteDocument{DocumentID: *documentID, Path: path, Hash: hash}, true
+}
+
+func encodeMetadata(metadata map[string]interface{}) (string, error) {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func pageSize(size int64) int64 {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	return size
+}
+
+// errListTruncated is returned when a collection has more sync documents than a query can page through.
+var errListTruncated = fmt.Errorf("the collection has more than %d documents with sync metadata, which cannot be listed with a query; use a state file", discoveryv2.MaxQueryResultWindow)
+
+// remoteDocuments collects the sync documents of query results, skipping documents that were already seen on an
+// earlier page.
+type remoteDocuments struct {
+	documents []RemoteDocument
+	seen      map[string]bool
+}
+
+func (remote *remoteDocuments) add(documentID *string, metadata map[string]interface{}) {
+	document, ok := remoteDocument(documentID, metadata)
+	if !ok || remote.seen[document.DocumentID] {
+		return
+	}
+	if remote.seen == nil {
+		remote.seen = make(map[string]bool)
+	}
+	remote.seen[document.DocumentID] = true
+	remote.documents = append(remote.documents, document)
+}
+
+// V2Collection : A collection of a Discovery v2 project.
+type V2Collection struct {
+	Discovery    *discoveryv2.DiscoveryV2
+	ProjectID    string
+	CollectionID string
+
+	// When true, uploads are sent with the X-Watson-Discovery-Force header.
+	Force bool
+
+	// The number of documents requested per query. Defaults to DefaultPageSize.
+	PageSize int64
+}
+
+// List pages through the documents of the collection that have sync metadata, sorted by document ID so that pages
+// stay consistent. It returns an error when there are more than discoveryv2.MaxQueryResultWindow of them.
+func (collection *V2Collection) List(ctx context.Context) ([]RemoteDocument, error) {
+	options := collection.Discovery.NewQueryOptions(collection.ProjectID).
+		SetCollectionIds([]string{collection.CollectionID}).
+		SetFilter(syncFilter).
+		SetReturn([]string{"metadata"}).
+		SetSort("document_id")
+	iterator := collection.Discovery.NewQueryIterator(options)
+	iterator.PageSize = pageSize(collection.PageSize)
+	var remote remoteDocuments
+	for iterator.Next(ctx) {
+		result := iterator.Result()
+		remote.add(result.DocumentID, result.Metadata)
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	if iterator.Truncated() {
+		return nil, errListTruncated
+	}
+	return remote.documents, nil
+}
+
+// Upload adds a document, or updates it when request.DocumentID is set.
+func (collection *V2Collection) Upload(ctx context.Context, request *UploadRequest) (string, error) {
+	metadata, err := encodeMetadata(request.Metadata)
+	if err != nil {
+		return "", err
+	}
+	file := ioutil.NopCloser(request.Content)
+
+	var result *discoveryv2.DocumentAccepted
+	if request.DocumentID == "" {
+		options := collection.Discovery.NewAddDocumentOptions(collection.ProjectID, collection.CollectionID).
+			SetFile(file).
+			SetFilename(request.Filename).
+			SetMetadata(metadata).
+			SetXWatsonDiscoveryForce(collection.Force)
+		if request.ContentType != "" {
+			options.SetFileContentType(request.ContentType)
+		}
+		result, _, err = collection.Discovery.AddDocumentWithContext(ctx, options)
+	} else {
+		options := collection.Discovery.NewUpdateDocumentOptions(collection.ProjectID, collection.CollectionID, request.DocumentID).
+			SetFile(file).
+			SetFilename(request.Filename).
+			SetMetadata(metadata).
+			SetXWatsonDiscoveryForce(collection.Force)
+		if request.ContentType != "" {
+			options.SetFileContentType(request.ContentType)
+		}
+		result, _, err = collection.Discovery.UpdateDocumentWithContext(ctx, options)
+	}
+	if err != nil {
+		return "", err
+	}
+	return documentID(result.DocumentID, request.DocumentID), nil
+}
+
+// Delete deletes a document.
+func (collection *V2Collection) Delete(ctx context.Context, documentID string) error {
+	options := collection.Discovery.NewDeleteDocumentOptions(collection.ProjectID, collection.CollectionID, documentID).
+		SetXWatsonDiscoveryForce(collection.Force)
+	_, _, err := collection.Discovery.DeleteDocumentWithContext(ctx, options)
+	return err
+}
+
+// V1Collection : A collection of a Discovery v1 environment.
+type V1Collection struct {
+	Discovery     *discoveryv1.DiscoveryV1
+	EnvironmentID string
+	CollectionID  string
+
+	// The number of documents requested per query. Defaults to DefaultPageSize.
+	PageSize int64
+}
+
+// List pages through the documents of the collection that have sync metadata, sorted by document ID so that pages
+// stay consistent. It returns an error when there are more than discoveryv1.MaxQueryResultWindow of them.
+func (collection *V1Collection) List(ctx context.Context) ([]RemoteDocument, error) {
+	options := collection.Discovery.NewQueryOptions(collection.EnvironmentID, collection.CollectionID).
+		SetFilter(syncFilter).
+		SetReturn("metadata").
+		SetSort("id")
+	iterator := collection.Discovery.NewQueryIterator(options)
+	iterator.PageSize = pageSize(collection.PageSize)
+	var remote remoteDocuments
+	for iterator.Next(ctx) {
+		result := iterator.Result()
+		remote.add(result.ID, result.Metadata)
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	if iterator.Truncated() {
+		return nil, errListTruncated
+	}
+	return remote.documents, nil
+}
+
+// Upload adds a document, or updates it when request.DocumentID is set.
+func (collection *V1Collection) Upload(ctx context.Context, request *UploadRequest) (string, error) {
+	metadata, err := encodeMetadata(request.Metadata)
+	if err != nil {
+		return "", err
+	}
+	file := ioutil.NopCloser(request.Content)
+
+	var result *discoveryv1.DocumentAccepted
+	if request.DocumentID == "" {
+		options := collection.Discovery.NewAddDocumentOptions(collection.EnvironmentID, collection.CollectionID).
+			SetFile(file).
+			SetFilename(request.Filename).
+			SetMetadata(metadata)
+		if request.ContentType != "" {
+			options.SetFileContentType(request.ContentType)
+		}
+		result, _, err = collection.Discovery.AddDocumentWithContext(ctx, options)
+	} else {
+		options := collection.Discovery.NewUpdateDocumentOptions(collection.EnvironmentID, collection.CollectionID, request.DocumentID).
+			SetFile(file).
+			SetFilename(request.Filename).
+			SetMetadata(metadata)
+		if request.ContentType != "" {
+			options.SetFileContentType(request.ContentType)
+		}
+		result, _, err = collection.Discovery.UpdateDocumentWithContext(ctx, options)
+	}
+	if err != nil {
+		return "", err
+	}
+	return documentID(result.DocumentID, request.DocumentID), nil
+}
+
+// Delete deletes a document.
+func (collection *V1Collection) Delete(ctx context.Context, documentID string) error {
+	options := collection.Discovery.NewDeleteDocumentOptions(collection.EnvironmentID, collection.CollectionID, documentID)
+	_, _, err := collection.Discovery.DeleteDocumentWithContext(ctx, options)
+	return err
+}
+
+func documentID(accepted *string, requested string) string {
+	if accepted != nil && *accepted != "" {
+		return *accepted
+	}
+	return requested
+}
+
+var (
+	_ Collection = &V2Collection{}
+	_ Collection = &V1Collection{}
+)
@@ -0,0 +1,441 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package discoverysync mirrors a local folder into a Discovery collection. New files are uploaded, changed files are
+// uploaded again and documents whose files disappeared are deleted. Files are compared by a SHA-256 hash of their
+// content, which is stored with the path in the metadata of each document, or in a local state file.
+//
+// The engine works with any Collection; V2Collection and V1Collection implement it for discoveryv2 projects and
+// discoveryv1 environments.
+package discoverysync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The metadata fields that identify documents uploaded by a sync.
+const (
+	MetadataPathField = "sync_path"
+	MetadataHashField = "sync_sha256"
+)
+
+// Constants associated with the SyncAction.Action property.
+const (
+	SyncActionCreateConst = "create"
+	SyncActionUpdateConst = "update"
+	SyncActionDeleteConst = "delete"
+)
+
+// DefaultConcurrency is the default number of concurrent uploads and deletes.
+const DefaultConcurrency = 4
+
+// RemoteDocument : A document of the collection that was uploaded by a sync.
+type RemoteDocument struct {
+	DocumentID string `json:"document_id"`
+	Path       string `json:"path"`
+	Hash       string `json:"hash"`
+}
+
+// LocalFile : A file of the folder being synced.
+type LocalFile struct {
+	// The slash-separated path relative to the folder.
+	Path string
+
+	// The path on disk.
+	FullPath string
+
+	// The hex SHA-256 hash of the content.
+	Hash string
+
+	ContentType string
+}
+
+// UploadRequest : A document to upload.
+type UploadRequest struct {
+	// The ID of the document to replace; empty for a new document.
+	DocumentID string
+
+	Filename    string
+	ContentType string
+	Content     io.Reader
+	Metadata    map[string]interface{}
+}
+
+// Collection : The operations of a Discovery collection used by a sync.
+type Collection interface {
+	// List returns the documents of the collection that have the MetadataPathField and MetadataHashField metadata.
+	List(ctx context.Context) ([]RemoteDocument, error)
+
+	// Upload adds or replaces a document and returns its ID.
+	Upload(ctx context.Context, request *UploadRequest) (string, error)
+
+	// Delete deletes a document.
+	Delete(ctx context.Context, documentID string) error
+}
+
+// SyncAction : A change to the collection.
+type SyncAction struct {
+	// SyncActionCreateConst, SyncActionUpdateConst or SyncActionDeleteConst.
+	Action string
+
+	Path       string
+	DocumentID string
+	Hash       string
+
+	// The local file of a create or update.
+	File *LocalFile
+
+	// The error of the action when the plan was applied.
+	Err error
+}
+
+// String renders the action as a line of a plan: + for create, ~ for update and - for delete.
+func (action *SyncAction) String() string {
+	symbol := map[string]string{SyncActionCreateConst: "+", SyncActionUpdateConst: "~", SyncActionDeleteConst: "-"}[action.Action]
+	s := symbol + " " + action.Path
+	if action.DocumentID != "" {
+		s += " (" + action.DocumentID + ")"
+	}
+	if action.Err != nil {
+		s += ": " + action.Err.Error()
+	}
+	return s
+}
+
+// SyncPlan : The changes that make a collection mirror a folder.
+type SyncPlan struct {
+	Actions []*SyncAction
+
+	// The number of files that are unchanged.
+	Unchanged int
+
+	// The documents of the collection when the plan was made.
+	Remote []RemoteDocument
+}
+
+// IsEmpty returns whether the collection already mirrors the folder.
+func (plan *SyncPlan) IsEmpty() bool {
+	return len(plan.Actions) == 0
+}
+
+// Failed returns the actions that failed when the plan was applied.
+func (plan *SyncPlan) Failed() []*SyncAction {
+	var failed []*SyncAction
+	for _, action := range plan.Actions {
+		if action.Err != nil {
+			failed = append(failed, action)
+		}
+	}
+	return failed
+}
+
+// String renders the plan, one action per line followed by the counts.
+func (plan *SyncPlan) String() string {
+	var builder strings.Builder
+	counts := make(map[string]int)
+	for _, action := range plan.Actions {
+		builder.WriteString(action.String())
+		builder.WriteString("\n")
+		counts[action.Action]++
+	}
+	fmt.Fprintf(&builder, "Plan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		counts[SyncActionCreateConst], counts[SyncActionUpdateConst], counts[SyncActionDeleteConst], plan.Unchanged)
+	return builder.String()
+}
+
+// HashFile returns the hex SHA-256 hash of a file.
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ScanDirectory returns the files below dir, sorted by path. Hidden files and directories are skipped.
+func ScanDirectory(dir string) ([]LocalFile, error) {
+	var files []LocalFile
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hash, err := HashFile(path)
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if i := strings.Index(contentType, ";"); i >= 0 {
+			contentType = contentType[:i]
+		}
+		files = append(files, LocalFile{Path: filepath.ToSlash(rel), FullPath: path, Hash: hash, ContentType: contentType})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// LoadState reads a state file. A missing file is an empty state.
+func LoadState(path string) ([]RemoteDocument, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var documents []RemoteDocument
+	if err := json.Unmarshal(data, &documents); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	return documents, nil
+}
+
+// SaveState writes a state file, sorted by path.
+func SaveState(path string, documents []RemoteDocument) error {
+	sorted := append([]RemoteDocument{}, documents...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Syncer : Mirrors a folder into a collection.
+type Syncer struct {
+	Collection Collection
+
+	// If set, the documents of the collection are read from and recorded in this file instead of being listed with
+	// a query, which is faster but assumes that only this sync changes the collection.
+	StateFile string
+
+	// When true, Sync only plans the changes.
+	DryRun bool
+
+	// The number of concurrent uploads and deletes. Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// Called after every applied action, for example to report progress.
+	OnAction func(*SyncAction)
+}
+
+// NewSyncer : Instantiate Syncer
+func NewSyncer(collection Collection) *Syncer {
+	return &Syncer{Collection: collection, Concurrency: DefaultConcurrency}
+}
+
+// Plan compares the folder with the collection. Documents are matched to files by path; when several documents have
+// the same path, the extra ones are deleted. A document listed more than once is only considered once.
+func (syncer *Syncer) Plan(ctx context.Context, dir string) (*SyncPlan, error) {
+	files, err := ScanDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	var remote []RemoteDocument
+	if syncer.StateFile != "" {
+		remote, err = LoadState(syncer.StateFile)
+	} else {
+		remote, err = syncer.Collection.List(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{Remote: remote}
+	byPath := make(map[string]RemoteDocument)
+	seen := make(map[string]bool)
+	for _, document := range remote {
+		if seen[document.DocumentID] {
+			continue
+		}
+		seen[document.DocumentID] = true
+		if _, ok := byPath[document.Path]; ok {
+			plan.Actions = append(plan.Actions, &SyncAction{Action: SyncActionDeleteConst, Path: document.Path, DocumentID: document.DocumentID})
+			continue
+		}
+		byPath[document.Path] = document
+	}
+
+	local := make(map[string]bool)
+	for i := range files {
+		file := &files[i]
+		local[file.Path] = true
+		document, ok := byPath[file.Path]
+		switch {
+		case !ok:
+			plan.Actions = append(plan.Actions, &SyncAction{Action: SyncActionCreateConst, Path: file.Path, Hash: file.Hash, File: file})
+		case document.Hash != file.Hash:
+			plan.Actions = append(plan.Actions, &SyncAction{Action: SyncActionUpdateConst, Path: file.Path, DocumentID: document.DocumentID, Hash: file.Hash, File: file})
+		default:
+			plan.Unchanged++
+		}
+	}
+	for path, document := range byPath {
+		if !local[path] {
+			plan.Actions = append(plan.Actions, &SyncAction{Action: SyncActionDeleteConst, Path: path, DocumentID: document.DocumentID})
+		}
+	}
+
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return plan.Actions[i].Path < plan.Actions[j].Path
+	})
+	return plan, nil
+}
+
+// Apply applies the actions of a plan and records their errors in the plan. When a state file is used, it is updated
+// with the actions that succeeded. It returns an error when any action failed.
+func (syncer *Syncer) Apply(ctx context.Context, plan *SyncPlan) error {
+	concurrency := syncer.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	actions := make(chan *SyncAction)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for action := range actions {
+				action.Err = syncer.apply(ctx, action)
+				if syncer.OnAction != nil {
+					mutex.Lock()
+					syncer.OnAction(action)
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, action := range plan.Actions {
+		if ctx.Err() != nil {
+			action.Err = ctx.Err()
+			continue
+		}
+		actions <- action
+	}
+	close(actions)
+	wg.Wait()
+
+	if syncer.StateFile != "" {
+		if err := SaveState(syncer.StateFile, plan.state()); err != nil {
+			return err
+		}
+	}
+	if failed := plan.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d actions failed, first: %s", len(failed), len(plan.Actions), failed[0].String())
+	}
+	return nil
+}
+
+func (syncer *Syncer) apply(ctx context.Context, action *SyncAction) error {
+	if action.Action == SyncActionDeleteConst {
+		return syncer.Collection.Delete(ctx, action.DocumentID)
+	}
+
+	file, err := os.Open(action.File.FullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	documentID, err := syncer.Collection.Upload(ctx, &UploadRequest{
+		DocumentID:  action.DocumentID,
+		Filename:    filepath.Base(action.File.FullPath),
+		ContentType: action.File.ContentType,
+		Content:     file,
+		Metadata: map[string]interface{}{
+			MetadataPathField: action.Path,
+			MetadataHashField: action.Hash,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	action.DocumentID = documentID
+	return nil
+}
+
+// state returns the documents of the collection after the successful actions of the plan.
+func (plan *SyncPlan) state() []RemoteDocument {
+	documents := make(map[string]RemoteDocument)
+	for _, document := range plan.Remote {
+		documents[document.DocumentID] = document
+	}
+	for _, action := range plan.Actions {
+		if action.Err != nil {
+			continue
+		}
+		if action.Action == SyncActionDeleteConst {
+			delete(documents, action.DocumentID)
+		} else {
+			documents[action.DocumentID] = RemoteDocument{DocumentID: action.DocumentID, Path: action.Path, Hash: action.Hash}
+		}
+	}
+	state := make([]RemoteDocument, 0, len(documents))
+	for _, document := range documents {
+		state = append(state, document)
+	}
+	return state
+}
+
+// Sync plans the changes and, unless DryRun is set, applies them. The plan is returned in both cases.
+func (syncer *Syncer) Sync(ctx context.Context, dir string) (*SyncPlan, error) {
+	plan, err := syncer.Plan(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if syncer.DryRun {
+		return plan, nil
+	}
+	return plan, syncer.Apply(ctx, plan)
+}
@@ -0,0 +1,289 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoverysync_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoverysync"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+type fakeCollection struct {
+	mutex     sync.Mutex
+	documents map[string]discoverysync.RemoteDocument
+	contents  map[string]string
+	lists     int
+	nextID    int
+	failPath  string
+}
+
+func (collection *fakeCollection) List(ctx context.Context) ([]discoverysync.RemoteDocument, error) {
+	collection.mutex.Lock()
+	defer collection.mutex.Unlock()
+	collection.lists++
+	var documents []discoverysync.RemoteDocument
+	for _, document := range collection.documents {
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+func (collection *fakeCollection) Upload(ctx context.Context, request *discoverysync.UploadRequest) (string, error) {
+	content, err := ioutil.ReadAll(request.Content)
+	if err != nil {
+		return "", err
+	}
+	collection.mutex.Lock()
+	defer collection.mutex.Unlock()
+	path := request.Metadata[discoverysync.MetadataPathField].(string)
+	if path == collection.failPath {
+		return "", errors.New("rejected")
+	}
+	documentID := request.DocumentID
+	if documentID == "" {
+		collection.nextID++
+		documentID = "doc" + strconv.Itoa(collection.nextID)
+	}
+	collection.documents[documentID] = discoverysync.RemoteDocument{
+		DocumentID: documentID,
+		Path:       path,
+		Hash:       request.Metadata[discoverysync.MetadataHashField].(string),
+	}
+	collection.contents[documentID] = string(content)
+	return documentID, nil
+}
+
+func (collection *fakeCollection) Delete(ctx context.Context, documentID string) error {
+	collection.mutex.Lock()
+	defer collection.mutex.Unlock()
+	delete(collection.documents, documentID)
+	delete(collection.contents, documentID)
+	return nil
+}
+
+var _ = Describe(`Syncer`, func() {
+	var dir string
+	var collection *fakeCollection
+	var syncer *discoverysync.Syncer
+
+	write := func(name string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "sync")
+		Expect(err).To(BeNil())
+		write("a.txt", "a")
+		write("sub/b.html", "<p>b</p>")
+		write(".git/config", "ignored")
+		collection = &fakeCollection{
+			documents: make(map[string]discoverysync.RemoteDocument),
+			contents:  make(map[string]string),
+		}
+		syncer = discoverysync.NewSyncer(collection)
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It(`Creates, updates and deletes documents`, func() {
+		plan, err := syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.String()).To(Equal("+ a.txt (doc1)\n+ sub/b.html (doc2)\nPlan: 2 to create, 0 to update, 0 to delete, 0 unchanged.\n"))
+		Expect(collection.contents).To(Equal(map[string]string{"doc1": "a", "doc2": "<p>b</p>"}))
+
+		write("a.txt", "changed")
+		write("c.json", "{}")
+		Expect(os.Remove(filepath.Join(dir, "sub", "b.html"))).To(Succeed())
+		collection.documents["stale"] = discoverysync.RemoteDocument{DocumentID: "stale", Path: "a.txt", Hash: "old"}
+
+		plan, err = syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.Unchanged).To(Equal(0))
+		Expect(plan.Actions).To(HaveLen(4))
+		Expect(collection.contents).To(HaveLen(2))
+		Expect(collection.contents).To(ContainElement("changed"))
+		Expect(collection.contents).To(ContainElement("{}"))
+
+		plan, err = syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.IsEmpty()).To(BeTrue())
+		Expect(plan.Unchanged).To(Equal(2))
+	})
+	It(`Only plans in dry-run mode`, func() {
+		syncer.DryRun = true
+		syncer.StateFile = filepath.Join(dir, ".state.json")
+		plan, err := syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.Actions).To(HaveLen(2))
+		Expect(plan.Actions[0].Action).To(Equal(discoverysync.SyncActionCreateConst))
+		Expect(collection.documents).To(BeEmpty())
+		Expect(syncer.StateFile).ToNot(BeAnExistingFile())
+	})
+	It(`Uses a state file instead of listing the collection`, func() {
+		syncer.StateFile = filepath.Join(dir, ".state.json")
+		collection.failPath = "sub/b.html"
+		var applied []string
+		syncer.OnAction = func(action *discoverysync.SyncAction) {
+			applied = append(applied, action.Path)
+		}
+
+		plan, err := syncer.Sync(context.Background(), dir)
+		Expect(err).To(MatchError(ContainSubstring("1 of 2 actions failed")))
+		Expect(plan.Failed()).To(HaveLen(1))
+		Expect(applied).To(ConsistOf("a.txt", "sub/b.html"))
+
+		state, err := discoverysync.LoadState(syncer.StateFile)
+		Expect(err).To(BeNil())
+		Expect(state).To(HaveLen(1))
+		Expect(state[0].Path).To(Equal("a.txt"))
+
+		collection.failPath = ""
+		plan, err = syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.Actions).To(HaveLen(1))
+		Expect(plan.Actions[0].Path).To(Equal("sub/b.html"))
+		Expect(collection.lists).To(Equal(0))
+	})
+	It(`Does not delete a document that is listed twice`, func() {
+		hash, err := discoverysync.HashFile(filepath.Join(dir, "a.txt"))
+		Expect(err).To(BeNil())
+		syncer.StateFile = filepath.Join(dir, ".state.json")
+		document := discoverysync.RemoteDocument{DocumentID: "doc1", Path: "a.txt", Hash: hash}
+		Expect(discoverysync.SaveState(syncer.StateFile, []discoverysync.RemoteDocument{document, document})).To(Succeed())
+
+		plan, err := syncer.Plan(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.String()).To(Equal("+ sub/b.html\nPlan: 1 to create, 0 to update, 0 to delete, 1 unchanged.\n"))
+	})
+})
+
+var _ = Describe(`Collections`, func() {
+	var testServer *httptest.Server
+	var requests []*http.Request
+	var forms []map[string]string
+
+	BeforeEach(func() {
+		requests = nil
+		forms = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			requests = append(requests, req)
+			res.Header().Set("Content-type", "application/json")
+			switch req.Method {
+			case "POST":
+				if filepath.Base(req.URL.Path) == "query" {
+					var body map[string]interface{}
+					Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+					Expect(body["filter"]).To(Equal("metadata.sync_path:*,metadata.sync_sha256:*"))
+					offset := int(body["offset"].(float64))
+					id := "document_id"
+					if strings.HasPrefix(req.URL.Path, "/v1/") {
+						Expect(body["return"]).To(Equal("metadata"))
+						id = "id"
+					} else {
+						Expect(body["return"]).To(Equal([]interface{}{"metadata"}))
+					}
+					Expect(body["sort"]).To(Equal(id))
+					fmt.Fprintf(res, `{"matching_results": 3, "results": [{"%s": "d%d", "metadata": {"sync_path": "p%d", "sync_sha256": "h"}}, {"%s": "x", "metadata": {}}]}`,
+						id, offset, offset, id)
+					return
+				}
+				Expect(req.ParseMultipartForm(1 << 20)).To(Succeed())
+				forms = append(forms, map[string]string{"path": req.URL.Path, "metadata": req.FormValue("metadata")})
+				fmt.Fprint(res, `{"document_id": "new", "status": "processing"}`)
+			case "DELETE":
+				fmt.Fprint(res, `{"document_id": "d0", "status": "deleted"}`)
+			}
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Lists, uploads and deletes Discovery v2 documents`, func() {
+		service, err := discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2019-04-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		collection := &discoverysync.V2Collection{Discovery: service, ProjectID: "p1", CollectionID: "c1", PageSize: 2}
+
+		documents, err := collection.List(context.Background())
+		Expect(err).To(BeNil())
+		Expect(documents).To(Equal([]discoverysync.RemoteDocument{
+			{DocumentID: "d0", Path: "p0", Hash: "h"},
+			{DocumentID: "d2", Path: "p2", Hash: "h"},
+		}))
+
+		syncer := discoverysync.NewSyncer(collection)
+		dir, err := ioutil.TempDir("", "sync")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "p0"), []byte("new"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "p1"), []byte("new"), 0644)).To(Succeed())
+		syncer.Concurrency = 1
+		plan, err := syncer.Sync(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(plan.String()).To(Equal("~ p0 (new)\n+ p1 (new)\n- p2 (d2)\nPlan: 1 to create, 1 to update, 1 to delete, 0 unchanged.\n"))
+		Expect(forms).To(HaveLen(2))
+		Expect(forms[0]["path"]).To(Equal("/v2/projects/p1/collections/c1/documents/d0"))
+		Expect(forms[0]["metadata"]).To(ContainSubstring(`"sync_path":"p0"`))
+		Expect(requests[len(requests)-1].URL.Path).To(Equal("/v2/projects/p1/collections/c1/documents/d2"))
+	})
+	It(`Lists Discovery v1 documents`, func() {
+		service, err := discoveryv1.NewDiscoveryV1(&discoveryv1.DiscoveryV1Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2019-04-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		collection := &discoverysync.V1Collection{Discovery: service, EnvironmentID: "e1", CollectionID: "c1", PageSize: 2}
+		documents, err := collection.List(context.Background())
+		Expect(err).To(BeNil())
+		Expect(documents).To(HaveLen(2))
+		Expect(requests).To(HaveLen(2))
+
+		documentID, err := collection.Upload(context.Background(), &discoverysync.UploadRequest{
+			Filename: "a.txt",
+			Content:  strings.NewReader("a"),
+			Metadata: map[string]interface{}{discoverysync.MetadataPathField: "a.txt"},
+		})
+		Expect(err).To(BeNil())
+		Expect(documentID).To(Equal("new"))
+		Expect(forms[0]["path"]).To(Equal("/v1/environments/e1/collections/c1/documents"))
+	})
+})
@@ -0,0 +1,298 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv1
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FacetPathSeparator separates the segments of a facet path.
+const FacetPathSeparator = "/"
+
+// facetPathEscaper escapes the separator in a facet name or bucket key, and the escape character itself, so that a
+// key such as "TCP/IP" stays one segment of the path.
+var facetPathEscaper = strings.NewReplacer("%", "%25", FacetPathSeparator, "%2F")
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Facet : One aggregation of a query response, with its buckets and sub-aggregations.
+type Facet struct {
+	// The type of the aggregation, such as term, nested or max.
+	Type string
+
+	// The name of the aggregation if it has one, otherwise a description such as term(author).
+	Name string
+
+	// The names of the facets and bucket keys above this facet and the name of this facet, separated by
+	// FacetPathSeparator, for example nested(enriched_text.entities)/term(enriched_text.entities.text)/IBM/max(price).
+	// A % or FacetPathSeparator in a name or key is escaped as %25 or %2F; use FacetPath to build a path from them.
+	Path string
+
+	// The field of a term, histogram, timeslice or calculation aggregation, the path of a nested aggregation or the
+	// match of a filter aggregation.
+	Field string
+
+	// The number of documents matched by a nested, filter or top_hits aggregation.
+	MatchingResults *int64
+
+	// The value of a calculation aggregation: min, max, sum, average or unique_count.
+	Value *float64
+
+	// The results of a term, histogram or timeslice aggregation.
+	Buckets []*FacetBucket
+
+	// The documents of a top_hits aggregation.
+	Hits []map[string]interface{}
+
+	// The sub-aggregations of a nested or filter aggregation.
+	Children []*Facet
+
+	// The aggregation as returned by the service.
+	Aggregation QueryAggregationIntf
+}
+
+// FacetBucket : One result of a term, histogram or timeslice aggregation.
+type FacetBucket struct {
+	// The value of the field; for histograms and timeslices the formatted numeric key.
+	Key string
+
+	// The numeric key of a histogram or timeslice result.
+	NumericKey *int64
+
+	MatchingResults int64
+
+	// Relevancy statistics, returned only when relevancy is requested.
+	Relevancy                  *float64
+	TotalMatchingDocuments     *int64
+	EstimatedMatchingDocuments *int64
+
+	// The path of the bucket, which is the path of the facet and the key.
+	Path string
+
+	// The sub-aggregations computed for the bucket.
+	Children []*Facet
+}
+
+// Facets : The aggregations of a query response as a tree of facets, indexed by path.
+type Facets struct {
+	Roots []*Facet
+
+	// Every facet in the tree, keyed by Facet.Path.
+	ByPath map[string]*Facet
+}
+
+// NewFacets flattens aggregations into facets. Sibling facets that would have the same path get a #2, #3, ... suffix.
+func NewFacets(aggregations []QueryAggregationIntf) *Facets {
+	facets := &Facets{ByPath: make(map[string]*Facet)}
+	facets.Roots = facets.add("", aggregations)
+	return facets
+}
+
+// Facets returns the aggregations of the response as facets.
+func (response *QueryResponse) Facets() *Facets {
+	return NewFacets(response.Aggregations)
+}
+
+// Facets returns the aggregations of the response as facets.
+func (response *QueryNoticesResponse) Facets() *Facets {
+	return NewFacets(response.Aggregations)
+}
+
+// FacetPath returns the path of the facet or bucket with the given facet names and bucket keys, from the root down.
+func FacetPath(segments ...string) string {
+	path := ""
+	for _, segment := range segments {
+		path = joinFacetPath(path, segment)
+	}
+	return path
+}
+
+func joinFacetPath(parent string, segment string) string {
+	segment = facetPathEscaper.Replace(segment)
+	if parent == "" {
+		return segment
+	}
+	return parent + FacetPathSeparator + segment
+}
+
+func facetName(name *string, aggregationType string, field string) string {
+	if name != nil && *name != "" {
+		return *name
+	}
+	if field == "" {
+		return aggregationType
+	}
+	return aggregationType + "(" + field + ")"
+}
+
+// add converts aggregations into facets below the given path.
+func (facets *Facets) add(parent string, aggregations []QueryAggregationIntf) []*Facet {
+	var result []*Facet
+	for _, aggregation := range aggregations {
+		facet := facets.newFacet(aggregation)
+		if facet == nil {
+			continue
+		}
+		path := joinFacetPath(parent, facet.Name)
+		for n := 2; facets.ByPath[path] != nil; n++ {
+			path = joinFacetPath(parent, facet.Name+"#"+strconv.Itoa(n))
+		}
+		facet.Path = path
+		facets.ByPath[path] = facet
+		facets.addChildren(facet, aggregation)
+		result = append(result, facet)
+	}
+	return result
+}
+
+func (facets *Facets) newFacet(aggregation QueryAggregationIntf) *Facet {
+	switch a := aggregation.(type) {
+	case *QueryTermAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(a.Name, "term", stringValue(a.Field)), Field: stringValue(a.Field), Aggregation: a}
+	case *QueryHistogramAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(a.Name, "histogram", stringValue(a.Field)), Field: stringValue(a.Field), Aggregation: a}
+	case *QueryTimesliceAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(a.Name, "timeslice", stringValue(a.Field)), Field: stringValue(a.Field), Aggregation: a}
+	case *QueryNestedAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(nil, "nested", stringValue(a.Path)), Field: stringValue(a.Path), MatchingResults: a.MatchingResults, Aggregation: a}
+	case *QueryFilterAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(nil, "filter", stringValue(a.Match)), Field: stringValue(a.Match), MatchingResults: a.MatchingResults, Aggregation: a}
+	case *QueryCalculationAggregation:
+		return &Facet{Type: stringValue(a.Type), Name: facetName(nil, stringValue(a.Type), stringValue(a.Field)), Field: stringValue(a.Field), Value: a.Value, Aggregation: a}
+	case *QueryTopHitsAggregation:
+		facet := &Facet{Type: stringValue(a.Type), Name: facetName(a.Name, "top_hits", ""), Aggregation: a}
+		if a.Hits != nil {
+			facet.MatchingResults = a.Hits.MatchingResults
+			facet.Hits = a.Hits.Hits
+		}
+		return facet
+	}
+	return nil
+}
+
+// addChildren adds the buckets and sub-aggregations of an aggregation to its facet.
+func (facets *Facets) addChildren(facet *Facet, aggregation QueryAggregationIntf) {
+	addBucket := func(bucket *FacetBucket, aggregations []QueryAggregationIntf) {
+		bucket.Path = joinFacetPath(facet.Path, bucket.Key)
+		bucket.Children = facets.add(bucket.Path, aggregations)
+		facet.Buckets = append(facet.Buckets, bucket)
+	}
+
+	switch a := aggregation.(type) {
+	case *QueryTermAggregation:
+		for _, result := range a.Results {
+			addBucket(&FacetBucket{
+				Key:                        stringValue(result.Key),
+				MatchingResults:            int64Value(result.MatchingResults),
+				Relevancy:                  result.Relevancy,
+				TotalMatchingDocuments:     result.TotalMatchingDocuments,
+				EstimatedMatchingDocuments: result.EstimatedMatchingDocuments,
+			}, result.Aggregations)
+		}
+	case *QueryHistogramAggregation:
+		for _, result := range a.Results {
+			addBucket(&FacetBucket{
+				Key:             strconv.FormatInt(int64Value(result.Key), 10),
+				NumericKey:      result.Key,
+				MatchingResults: int64Value(result.MatchingResults),
+			}, result.Aggregations)
+		}
+	case *QueryTimesliceAggregation:
+		for _, result := range a.Results {
+			addBucket(&FacetBucket{
+				Key:             stringValue(result.KeyAsString),
+				NumericKey:      result.Key,
+				MatchingResults: int64Value(result.MatchingResults),
+			}, result.Aggregations)
+		}
+	case *QueryNestedAggregation:
+		facet.Children = facets.add(facet.Path, a.Aggregations)
+	case *QueryFilterAggregation:
+		facet.Children = facets.add(facet.Path, a.Aggregations)
+	}
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// Get returns the facet with a path, or nil if there is none.
+func (facets *Facets) Get(path string) *Facet {
+	return facets.ByPath[path]
+}
+
+// Walk calls fn for every facet, parents before children and in response order. It stops at the first error.
+func (facets *Facets) Walk(fn func(*Facet) error) error {
+	return walkFacets(facets.Roots, fn)
+}
+
+func walkFacets(facets []*Facet, fn func(*Facet) error) error {
+	for _, facet := range facets {
+		if err := fn(facet); err != nil {
+			return err
+		}
+		for _, bucket := range facet.Buckets {
+			if err := walkFacets(bucket.Children, fn); err != nil {
+				return err
+			}
+		}
+		if err := walkFacets(facet.Children, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Named returns the facets with a name, such as the name given to a term aggregation, in walk order.
+func (facets *Facets) Named(name string) []*Facet {
+	var result []*Facet
+	_ = facets.Walk(func(facet *Facet) error {
+		if facet.Name == name {
+			result = append(result, facet)
+		}
+		return nil
+	})
+	return result
+}
+
+// Bucket returns the bucket with a key, or nil if there is none.
+func (facet *Facet) Bucket(key string) *FacetBucket {
+	for _, bucket := range facet.Buckets {
+		if bucket.Key == key {
+			return bucket
+		}
+	}
+	return nil
+}
+
+// Counts returns the matching results of each bucket by key.
+func (facet *Facet) Counts() map[string]int64 {
+	counts := make(map[string]int64, len(facet.Buckets))
+	for _, bucket := range facet.Buckets {
+		counts[bucket.Key] = bucket.MatchingResults
+	}
+	return counts
+}
@@ -0,0 +1,52 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv1_test
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv1"
+)
+
+var _ = Describe(`Facets`, func() {
+	It(`Flattens the aggregations of a query response`, func() {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(`{"matching_results": 10, "aggregations": [
+			{"type": "filter", "match": "enriched_text.sentiment.document.label::positive", "matching_results": 4, "aggregations": [
+				{"type": "term", "field": "author", "results": [
+					{"key": "Poe", "matching_results": 3, "aggregations": [{"type": "average", "field": "rating", "value": 4.5}]}
+				]}
+			]},
+			{"type": "unique_count", "field": "author", "value": 7}
+		]}`), &raw)).To(Succeed())
+		var response *discoveryv1.QueryResponse
+		Expect(core.UnmarshalModel(raw, "", &response, discoveryv1.UnmarshalQueryResponse)).To(Succeed())
+
+		facets := response.Facets()
+		Expect(facets.Roots).To(HaveLen(2))
+		filter := facets.Get("filter(enriched_text.sentiment.document.label::positive)")
+		Expect(*filter.MatchingResults).To(BeEquivalentTo(4))
+		author := filter.Children[0]
+		Expect(author.Path).To(Equal("filter(enriched_text.sentiment.document.label::positive)/term(author)"))
+		Expect(author.Counts()).To(Equal(map[string]int64{"Poe": 3}))
+		Expect(*facets.Get(author.Bucket("Poe").Path + "/average(rating)").Value).To(Equal(4.5))
+		Expect(*facets.Get("unique_count(author)").Value).To(Equal(7.0))
+	})
+})
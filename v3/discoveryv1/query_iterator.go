@@ -0,0 +1,237 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// Defaults of QueryIterator.
+const (
+	DefaultQueryPageSize       = 100
+	DefaultQueryMaxRetries     = 3
+	DefaultQueryInitialBackoff = time.Second
+)
+
+// MaxQueryResultWindow is the largest Offset plus Count that the service accepts in a query.
+const MaxQueryResultWindow = 10000
+
+// QueryIterator : Iterates over the results of a query across pages, requesting each page with Count and Offset.
+// Results are requested in the order of options.Sort, so a sort on a unique field keeps pages consistent while the
+// collection changes.
+//
+// The service returns no results past MaxQueryResultWindow. The iterator ends there without an error and Truncated
+// reports that more results match; narrow the query, for example with a filter on the sort field, to reach them.
+//
+//	iterator := discovery.NewQueryIterator(options)
+//	for iterator.Next(ctx) {
+//		result := iterator.Result()
+//	}
+//	if err := iterator.Err(); err != nil {
+//	}
+type QueryIterator struct {
+	// The number of results requested per page. Defaults to DefaultQueryPageSize.
+	PageSize int64
+
+	// The largest number of results to return; 0 for all results.
+	MaxResults int64
+
+	// The number of retries of a page that is throttled (429), fails with a server error or is not answered, and the
+	// delay before the first retry, which doubles with every retry. Throttled requests wait for Retry-After when the
+	// service sends it.
+	MaxRetries     int
+	InitialBackoff time.Duration
+
+	discovery *DiscoveryV1
+	options   QueryOptions
+
+	page      []QueryResult
+	index     int
+	offset    int64
+	returned  int64
+	response  *QueryResponse
+	done      bool
+	truncated bool
+	err       error
+}
+
+// NewQueryIterator : Instantiate QueryIterator. The iterator starts at options.Offset and leaves options unchanged.
+func (discovery *DiscoveryV1) NewQueryIterator(queryOptions *QueryOptions) *QueryIterator {
+	iterator := &QueryIterator{
+		PageSize:       DefaultQueryPageSize,
+		MaxRetries:     DefaultQueryMaxRetries,
+		InitialBackoff: DefaultQueryInitialBackoff,
+		discovery:      discovery,
+		options:        *queryOptions,
+	}
+	if queryOptions.Offset != nil {
+		iterator.offset = *queryOptions.Offset
+	}
+	return iterator
+}
+
+// Next advances to the next result and returns whether there is one. It returns false when the results are exhausted,
+// MaxResults is reached or a page cannot be requested, in which case Err returns the error.
+func (iterator *QueryIterator) Next(ctx context.Context) bool {
+	if iterator.done {
+		return false
+	}
+	if iterator.MaxResults > 0 && iterator.returned >= iterator.MaxResults {
+		iterator.done = true
+		return false
+	}
+	iterator.index++
+	if iterator.index >= len(iterator.page) {
+		if !iterator.fetch(ctx) {
+			iterator.done = true
+			return false
+		}
+	}
+	iterator.returned++
+	return true
+}
+
+// fetch requests the next page and returns whether it has results.
+func (iterator *QueryIterator) fetch(ctx context.Context) bool {
+	if response := iterator.response; response != nil && response.MatchingResults != nil && iterator.offset >= *response.MatchingResults {
+		return false
+	}
+	count := iterator.PageSize
+	if count <= 0 {
+		count = DefaultQueryPageSize
+	}
+	if remaining := iterator.MaxResults - iterator.returned; iterator.MaxResults > 0 && remaining < count {
+		count = remaining
+	}
+	if iterator.offset >= MaxQueryResultWindow {
+		iterator.truncated = true
+		return false
+	}
+	if remaining := MaxQueryResultWindow - iterator.offset; remaining < count {
+		count = remaining
+	}
+
+	options := iterator.options
+	options.Count = core.Int64Ptr(count)
+	options.Offset = core.Int64Ptr(iterator.offset)
+
+	backoff := iterator.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		result, response, err := iterator.discovery.QueryWithContext(ctx, &options)
+		if err == nil {
+			iterator.response = result
+			iterator.page = result.Results
+			iterator.index = 0
+			iterator.offset += int64(len(result.Results))
+			return len(result.Results) > 0
+		}
+		if attempt >= iterator.MaxRetries || ctx.Err() != nil || !retryableError(response, err) {
+			iterator.err = err
+			return false
+		}
+		delay := backoff
+		if wait := retryAfter(response); wait > 0 {
+			delay = wait
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			iterator.err = err
+			return false
+		}
+		backoff *= 2
+	}
+}
+
+// retryableError returns whether a failed request is worth retrying: it was throttled, failed with a server error or
+// failed to reach the service. Other errors without a response, such as invalid options, are not retried.
+func retryableError(response *core.DetailedResponse, err error) bool {
+	if response != nil {
+		return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Result returns the current result.
+func (iterator *QueryIterator) Result() *QueryResult {
+	if iterator.index >= len(iterator.page) {
+		return nil
+	}
+	return &iterator.page[iterator.index]
+}
+
+// Response returns the last page, which holds the matching results, aggregations and other properties of the query.
+func (iterator *QueryIterator) Response() *QueryResponse {
+	return iterator.response
+}
+
+// Truncated returns whether the iterator ended at MaxQueryResultWindow while more results match the query.
+func (iterator *QueryIterator) Truncated() bool {
+	return iterator.truncated
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (iterator *QueryIterator) Err() error {
+	return iterator.err
+}
+
+// WriteNDJSON writes the remaining results to w as newline-delimited JSON, one result per line, and returns the number
+// of results written.
+func (iterator *QueryIterator) WriteNDJSON(ctx context.Context, w io.Writer) (int64, error) {
+	encoder := json.NewEncoder(w)
+	var written int64
+	for iterator.Next(ctx) {
+		if err := encoder.Encode(iterator.Result()); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, iterator.Err()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func retryAfter(response *core.DetailedResponse) time.Duration {
+	if response == nil || response.Headers == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(response.Headers.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
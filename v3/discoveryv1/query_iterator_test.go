@@ -0,0 +1,77 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv1"
+)
+
+var _ = Describe(`QueryIterator`, func() {
+	It(`Pages through results, retries throttling and streams NDJSON`, func() {
+		throttled := false
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/v1/environments/e1/collections/c1/query"))
+			var body struct {
+				Count  int `json:"count"`
+				Offset int `json:"offset"`
+			}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			res.Header().Set("Content-type", "application/json")
+			if body.Offset == 2 && !throttled {
+				throttled = true
+				res.WriteHeader(429)
+				fmt.Fprint(res, `{"error": "Too many requests", "code": 429}`)
+				return
+			}
+			var results []string
+			for i := body.Offset; i < body.Offset+body.Count && i < 3; i++ {
+				results = append(results, fmt.Sprintf(`{"id": "d%d"}`, i))
+			}
+			fmt.Fprintf(res, `{"matching_results": 3, "results": [%s]}`, strings.Join(results, ","))
+		}))
+		defer testServer.Close()
+		service, err := discoveryv1.NewDiscoveryV1(&discoveryv1.DiscoveryV1Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2019-04-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+
+		iterator := service.NewQueryIterator(service.NewQueryOptions("e1", "c1"))
+		iterator.PageSize = 2
+		iterator.InitialBackoff = time.Millisecond
+		var buffer bytes.Buffer
+		written, err := iterator.WriteNDJSON(context.Background(), &buffer)
+		Expect(err).To(BeNil())
+		Expect(written).To(Equal(int64(3)))
+		Expect(throttled).To(BeTrue())
+		Expect(buffer.String()).To(Equal("{\"id\":\"d0\"}\n{\"id\":\"d1\"}\n{\"id\":\"d2\"}\n"))
+	})
+})
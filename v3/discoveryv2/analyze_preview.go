@@ -0,0 +1,662 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// Constants associated with the AnalyzedAnnotation.Kind property.
+const (
+	AnnotationKindEntityConst            = "entity"
+	AnnotationKindKeywordConst           = "keyword"
+	AnnotationKindSentimentConst         = "sentiment"
+	AnnotationKindDictionaryConst        = "dictionary"
+	AnnotationKindRegularExpressionConst = "regular_expression"
+)
+
+// AnalyzedFieldPrefix is the prefix of the fields that hold the enrichments of a field in an analyzed document. The
+// enrichments of text are found in enriched_text.
+const AnalyzedFieldPrefix = "enriched_"
+
+// AnalyzedAnnotation : An enrichment of a field of an analyzed document.
+type AnalyzedAnnotation struct {
+	// The enriched field, such as text.
+	Field string
+
+	// AnnotationKindEntityConst, AnnotationKindKeywordConst, AnnotationKindSentimentConst, AnnotationKindDictionaryConst
+	// or AnnotationKindRegularExpressionConst. Dictionary and regular expression enrichments annotate entities, which
+	// are told apart by the model that found them.
+	Kind string
+
+	// The entity type of entities and dictionary and regular expression matches.
+	Type string
+
+	Text  string
+	Model string
+
+	// The relevance of entities and keywords, or the score of the sentiment.
+	Score *float64
+
+	// The label of the sentiment.
+	Label string
+
+	Mentions int
+}
+
+// key identifies the annotation when previews are compared.
+func (annotation *AnalyzedAnnotation) key() string {
+	return strings.Join([]string{annotation.Field, annotation.Kind, annotation.Type, annotation.Text, annotation.Label}, "\x00")
+}
+
+// String returns the kind, field, type and text of the annotation.
+func (annotation *AnalyzedAnnotation) String() string {
+	s := annotation.Kind + " " + annotation.Field
+	if annotation.Type != "" {
+		s += " " + annotation.Type
+	}
+	if annotation.Kind == AnnotationKindSentimentConst {
+		return s + " " + annotation.Label
+	}
+	return s + " " + strconv.Quote(annotation.Text)
+}
+
+// AnalyzedDocumentReport : The enrichments of one analyzed document as a flat list.
+type AnalyzedDocumentReport struct {
+	// Identifies the document, such as its path.
+	Key string
+
+	// Sorted by field and kind, and otherwise in the order of the service.
+	Annotations []AnalyzedAnnotation
+
+	Notices []Notice
+
+	// The error of a document that could not be analyzed.
+	Error      string
+	StatusCode int
+}
+
+// NewAnalyzedDocumentReport normalizes the entities, keywords and sentiment of every enriched field of an analyzed
+// document. Entities found by dictionary and regular expression enrichments, whose model names start with
+// "Dictionary:" and "Regex:", are reported as such.
+func NewAnalyzedDocumentReport(key string, document *AnalyzedDocument) *AnalyzedDocumentReport {
+	report := &AnalyzedDocumentReport{Key: key, Notices: document.Notices}
+	if document.Result == nil {
+		return report
+	}
+	for name, value := range document.Result.GetProperties() {
+		if !strings.HasPrefix(name, AnalyzedFieldPrefix) {
+			continue
+		}
+		field := strings.TrimPrefix(name, AnalyzedFieldPrefix)
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, value := range values {
+			if enrichments, ok := value.(map[string]interface{}); ok {
+				report.Annotations = append(report.Annotations, analyzedAnnotations(field, enrichments)...)
+			}
+		}
+	}
+	kinds := map[string]int{
+		AnnotationKindEntityConst:            0,
+		AnnotationKindDictionaryConst:        1,
+		AnnotationKindRegularExpressionConst: 2,
+		AnnotationKindKeywordConst:           3,
+		AnnotationKindSentimentConst:         4,
+	}
+	sort.SliceStable(report.Annotations, func(i, j int) bool {
+		a, b := &report.Annotations[i], &report.Annotations[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return kinds[a.Kind] < kinds[b.Kind]
+	})
+	return report
+}
+
+func analyzedAnnotations(field string, enrichments map[string]interface{}) []AnalyzedAnnotation {
+	var annotations []AnalyzedAnnotation
+	entities, _ := enrichments["entities"].([]interface{})
+	for _, value := range entities {
+		entity, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		annotation := analyzedAnnotation(field, AnnotationKindEntityConst, entity)
+		annotation.Type, _ = entity["type"].(string)
+		annotation.Model, _ = entity["model_name"].(string)
+		switch {
+		case strings.HasPrefix(annotation.Model, "Dictionary:"):
+			annotation.Kind = AnnotationKindDictionaryConst
+		case strings.HasPrefix(annotation.Model, "Regex:"):
+			annotation.Kind = AnnotationKindRegularExpressionConst
+		}
+		if annotation.Score == nil {
+			annotation.Score = jsonNumber(entity["confidence"])
+		}
+		annotations = append(annotations, annotation)
+	}
+	keywords, _ := enrichments["keywords"].([]interface{})
+	for _, value := range keywords {
+		if keyword, ok := value.(map[string]interface{}); ok {
+			annotations = append(annotations, analyzedAnnotation(field, AnnotationKindKeywordConst, keyword))
+		}
+	}
+	if sentiment, ok := enrichments["sentiment"].(map[string]interface{}); ok {
+		if document, ok := sentiment["document"].(map[string]interface{}); ok {
+			annotation := AnalyzedAnnotation{Field: field, Kind: AnnotationKindSentimentConst, Score: jsonNumber(document["score"])}
+			annotation.Label, _ = document["label"].(string)
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+func analyzedAnnotation(field string, kind string, enrichment map[string]interface{}) AnalyzedAnnotation {
+	annotation := AnalyzedAnnotation{Field: field, Kind: kind, Score: jsonNumber(enrichment["relevance"])}
+	annotation.Text, _ = enrichment["text"].(string)
+	if mentions, ok := enrichment["mentions"].([]interface{}); ok {
+		annotation.Mentions = len(mentions)
+	}
+	return annotation
+}
+
+func jsonNumber(value interface{}) *float64 {
+	switch number := value.(type) {
+	case float64:
+		return core.Float64Ptr(number)
+	case json.Number:
+		if f, err := number.Float64(); err == nil {
+			return core.Float64Ptr(f)
+		}
+	}
+	return nil
+}
+
+// Count returns the number of annotations of a kind.
+func (report *AnalyzedDocumentReport) Count(kind string) int {
+	count := 0
+	for i := range report.Annotations {
+		if report.Annotations[i].Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// AnalyzePreviewReport : The enrichments of a batch of documents analyzed with a collection.
+type AnalyzePreviewReport struct {
+	CollectionID string
+
+	// In the order of the analyzed items.
+	Documents []AnalyzedDocumentReport
+}
+
+// Failed returns the number of documents that could not be analyzed.
+func (report *AnalyzePreviewReport) Failed() int {
+	failed := 0
+	for i := range report.Documents {
+		if report.Documents[i].Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteSummary writes the counts, and one line per document with its counts of annotations or its error.
+func (report *AnalyzePreviewReport) WriteSummary(w io.Writer) error {
+	failed := report.Failed()
+	if _, err := fmt.Fprintf(w, "%d analyzed, %d failed\n", len(report.Documents)-failed, failed); err != nil {
+		return err
+	}
+	for i := range report.Documents {
+		document := &report.Documents[i]
+		line := fmt.Sprintf("%s: %d entities, %d dictionary, %d regular expression, %d keywords",
+			document.Key, document.Count(AnnotationKindEntityConst), document.Count(AnnotationKindDictionaryConst),
+			document.Count(AnnotationKindRegularExpressionConst), document.Count(AnnotationKindKeywordConst))
+		for _, annotation := range document.Annotations {
+			if annotation.Kind == AnnotationKindSentimentConst {
+				line += ", " + annotation.Field + " sentiment " + annotation.Label
+			}
+		}
+		if document.Error != "" {
+			line = document.Key + ": " + document.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes one row per annotation of every document, with a header row.
+func (report *AnalyzePreviewReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"document", "field", "kind", "type", "text", "model", "score", "label", "mentions"}); err != nil {
+		return err
+	}
+	for i := range report.Documents {
+		document := &report.Documents[i]
+		for _, annotation := range document.Annotations {
+			score := ""
+			if annotation.Score != nil {
+				score = strconv.FormatFloat(*annotation.Score, 'f', -1, 64)
+			}
+			row := []string{document.Key, annotation.Field, annotation.Kind, annotation.Type, annotation.Text, annotation.Model,
+				score, annotation.Label, strconv.Itoa(annotation.Mentions)}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// AnalyzePreview : Runs local files through the enrichments of a collection with AnalyzeDocument, without indexing
+// them, and reports their enrichments. Throttled (429) requests, server errors and network errors are retried.
+type AnalyzePreview struct {
+	Discovery    *DiscoveryV2
+	ProjectID    string
+	CollectionID string
+
+	// The number of concurrent requests. Defaults to DefaultIngestConcurrency.
+	Concurrency int
+
+	// The number of retries of a request, and the delay before the first retry, which doubles with every retry.
+	// Throttled requests wait for Retry-After when the service sends it.
+	MaxRetries     int
+	InitialBackoff time.Duration
+
+	// Called after every document, for example to report progress.
+	OnResult func(*AnalyzedDocumentReport)
+}
+
+// NewAnalyzePreview : Instantiate AnalyzePreview
+func (discovery *DiscoveryV2) NewAnalyzePreview(projectID string, collectionID string) *AnalyzePreview {
+	return &AnalyzePreview{
+		Discovery:      discovery,
+		ProjectID:      projectID,
+		CollectionID:   collectionID,
+		Concurrency:    DefaultIngestConcurrency,
+		MaxRetries:     DefaultIngestMaxRetries,
+		InitialBackoff: DefaultIngestInitialBackoff,
+	}
+}
+
+// Analyze analyzes the items and returns their reports in order. Items that cannot be analyzed, or are not sent
+// because ctx is done, are reported with their error; the error is only set when ctx is done.
+func (preview *AnalyzePreview) Analyze(ctx context.Context, items []*IngestItem) (*AnalyzePreviewReport, error) {
+	concurrency := preview.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultIngestConcurrency
+	}
+	report := &AnalyzePreviewReport{CollectionID: preview.CollectionID, Documents: make([]AnalyzedDocumentReport, len(items))}
+	indexes := make(chan int)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				document := preview.analyze(ctx, items[i])
+				report.Documents[i] = *document
+				if preview.OnResult != nil {
+					mutex.Lock()
+					preview.OnResult(document)
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for i := range items {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			for j := i; j < len(items); j++ {
+				report.Documents[j] = AnalyzedDocumentReport{Key: items[j].Key, Error: ctx.Err().Error()}
+			}
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return report, ctx.Err()
+}
+
+// AnalyzeDirectory analyzes the files below a directory, which are found and keyed as described for
+// BulkIngester.IngestDirectory.
+func (preview *AnalyzePreview) AnalyzeDirectory(ctx context.Context, dir string) (*AnalyzePreviewReport, error) {
+	var items []*IngestItem
+	err := WalkIngestDirectory(dir, func(item *IngestItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview.Analyze(ctx, items)
+}
+
+func (preview *AnalyzePreview) analyze(ctx context.Context, item *IngestItem) *AnalyzedDocumentReport {
+	var metadata *string
+	if item.Metadata != nil {
+		data, err := json.Marshal(item.Metadata)
+		if err != nil {
+			return &AnalyzedDocumentReport{Key: item.Key, Error: err.Error()}
+		}
+		metadata = core.StringPtr(string(data))
+	}
+
+	backoff := preview.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		document, response, err := preview.send(ctx, item, metadata)
+		if err == nil {
+			return NewAnalyzedDocumentReport(item.Key, document)
+		}
+		report := &AnalyzedDocumentReport{Key: item.Key, Error: err.Error()}
+		if response != nil {
+			report.StatusCode = response.StatusCode
+		}
+		if attempt >= preview.MaxRetries || ctx.Err() != nil || !retryableError(response, err) {
+			return report
+		}
+		delay := backoff
+		if wait := retryAfter(response); wait > 0 {
+			delay = wait
+		}
+		if sleepContext(ctx, delay) != nil {
+			return report
+		}
+		backoff *= 2
+	}
+}
+
+func (preview *AnalyzePreview) send(ctx context.Context, item *IngestItem, metadata *string) (*AnalyzedDocument, *core.DetailedResponse, error) {
+	file, err := item.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	options := preview.Discovery.NewAnalyzeDocumentOptions(preview.ProjectID, preview.CollectionID)
+	options.File = file
+	if item.Filename != "" {
+		options.Filename = core.StringPtr(item.Filename)
+	}
+	if item.ContentType != "" {
+		options.FileContentType = core.StringPtr(item.ContentType)
+	}
+	options.Metadata = metadata
+	return preview.Discovery.AnalyzeDocumentWithContext(ctx, options)
+}
+
+// AnalyzedAnnotationDiff : The annotations of a document that differ between two previews.
+type AnalyzedAnnotationDiff struct {
+	Key string
+
+	// The annotations found only by the candidate, and only by the baseline.
+	Added   []AnalyzedAnnotation
+	Removed []AnalyzedAnnotation
+}
+
+// AnalyzePreviewComparison : The differences between the enrichments of the same documents analyzed with two
+// collections.
+type AnalyzePreviewComparison struct {
+	Baseline  *AnalyzePreviewReport
+	Candidate *AnalyzePreviewReport
+
+	// The documents whose annotations differ, in the order of the baseline and then of the candidate.
+	Diffs []AnalyzedAnnotationDiff
+}
+
+// CompareAnalyzePreviews compares the annotations of the documents of two previews by key. Annotations are compared
+// by field, kind, type, text and label, so changes of scores and mentions are ignored. Documents that failed in
+// either preview are left out.
+func CompareAnalyzePreviews(baseline *AnalyzePreviewReport, candidate *AnalyzePreviewReport) *AnalyzePreviewComparison {
+	comparison := &AnalyzePreviewComparison{Baseline: baseline, Candidate: candidate}
+	candidates := make(map[string]*AnalyzedDocumentReport, len(candidate.Documents))
+	for i := range candidate.Documents {
+		candidates[candidate.Documents[i].Key] = &candidate.Documents[i]
+	}
+	empty := &AnalyzedDocumentReport{}
+	compared := make(map[string]bool)
+	for i := range baseline.Documents {
+		document := &baseline.Documents[i]
+		other, ok := candidates[document.Key]
+		if !ok {
+			other = empty
+		}
+		compared[document.Key] = true
+		if diff := diffAnalyzedAnnotations(document, other); diff != nil {
+			comparison.Diffs = append(comparison.Diffs, *diff)
+		}
+	}
+	for i := range candidate.Documents {
+		document := &candidate.Documents[i]
+		if compared[document.Key] {
+			continue
+		}
+		if diff := diffAnalyzedAnnotations(empty, document); diff != nil {
+			diff.Key = document.Key
+			comparison.Diffs = append(comparison.Diffs, *diff)
+		}
+	}
+	return comparison
+}
+
+func diffAnalyzedAnnotations(baseline *AnalyzedDocumentReport, candidate *AnalyzedDocumentReport) *AnalyzedAnnotationDiff {
+	if baseline.Error != "" || candidate.Error != "" {
+		return nil
+	}
+	remaining := make(map[string]int)
+	for i := range candidate.Annotations {
+		remaining[candidate.Annotations[i].key()]++
+	}
+	diff := &AnalyzedAnnotationDiff{Key: baseline.Key}
+	for _, annotation := range baseline.Annotations {
+		if key := annotation.key(); remaining[key] > 0 {
+			remaining[key]--
+		} else {
+			diff.Removed = append(diff.Removed, annotation)
+		}
+	}
+	for _, annotation := range candidate.Annotations {
+		if key := annotation.key(); remaining[key] > 0 {
+			remaining[key]--
+			diff.Added = append(diff.Added, annotation)
+		}
+	}
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// WriteSummary writes one line per document that differs, followed by its removed and added annotations.
+func (comparison *AnalyzePreviewComparison) WriteSummary(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%d of %d documents differ\n", len(comparison.Diffs), len(comparison.Baseline.Documents)); err != nil {
+		return err
+	}
+	for _, diff := range comparison.Diffs {
+		if _, err := fmt.Fprintf(w, "%s: %d added, %d removed\n", diff.Key, len(diff.Added), len(diff.Removed)); err != nil {
+			return err
+		}
+		for i := range diff.Removed {
+			if _, err := fmt.Fprintf(w, "  - %s\n", diff.Removed[i].String()); err != nil {
+				return err
+			}
+		}
+		for i := range diff.Added {
+			if _, err := fmt.Fprintf(w, "  + %s\n", diff.Added[i].String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CollectionEnrichmentDiff : The fields to which two collections apply an enrichment.
+type CollectionEnrichmentDiff struct {
+	EnrichmentID string
+	Name         string
+	Type         string
+
+	// Nil when the collection does not use the enrichment.
+	BaselineFields  []string
+	CandidateFields []string
+}
+
+// Changed returns whether the collections apply the enrichment to different fields.
+func (diff *CollectionEnrichmentDiff) Changed() bool {
+	if (diff.BaselineFields == nil) != (diff.CandidateFields == nil) || len(diff.BaselineFields) != len(diff.CandidateFields) {
+		return true
+	}
+	fields := make(map[string]bool, len(diff.BaselineFields))
+	for _, field := range diff.BaselineFields {
+		fields[field] = true
+	}
+	for _, field := range diff.CandidateFields {
+		if !fields[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectionEnrichmentComparison : The enrichment configurations of two collections, side by side.
+type CollectionEnrichmentComparison struct {
+	Baseline  *CollectionDetails
+	Candidate *CollectionDetails
+
+	// The enrichments of the baseline in its order, followed by those only used by the candidate.
+	Enrichments []CollectionEnrichmentDiff
+}
+
+// CompareCollectionEnrichments compares the enrichments of two collections of a project. The names and types of the
+// enrichments are those listed for the project.
+func (discovery *DiscoveryV2) CompareCollectionEnrichments(ctx context.Context, projectID string, baselineID string, candidateID string) (*CollectionEnrichmentComparison, error) {
+	baseline, _, err := discovery.GetCollectionWithContext(ctx, discovery.NewGetCollectionOptions(projectID, baselineID))
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %s", baselineID, err.Error())
+	}
+	candidate, _, err := discovery.GetCollectionWithContext(ctx, discovery.NewGetCollectionOptions(projectID, candidateID))
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %s", candidateID, err.Error())
+	}
+	enrichments, _, err := discovery.ListEnrichmentsWithContext(ctx, discovery.NewListEnrichmentsOptions(projectID))
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]*Enrichment, len(enrichments.Enrichments))
+	for i := range enrichments.Enrichments {
+		definitions[stringValue(enrichments.Enrichments[i].EnrichmentID)] = &enrichments.Enrichments[i]
+	}
+
+	comparison := &CollectionEnrichmentComparison{Baseline: baseline, Candidate: candidate}
+	byID := make(map[string]int)
+	add := func(enrichment CollectionEnrichment, candidate bool) {
+		id := stringValue(enrichment.EnrichmentID)
+		i, ok := byID[id]
+		if !ok {
+			diff := CollectionEnrichmentDiff{EnrichmentID: id}
+			if definition, ok := definitions[id]; ok {
+				diff.Name = stringValue(definition.Name)
+				diff.Type = stringValue(definition.Type)
+			}
+			i = len(comparison.Enrichments)
+			byID[id] = i
+			comparison.Enrichments = append(comparison.Enrichments, diff)
+		}
+		fields := enrichment.Fields
+		if fields == nil {
+			fields = []string{}
+		}
+		if candidate {
+			comparison.Enrichments[i].CandidateFields = fields
+		} else {
+			comparison.Enrichments[i].BaselineFields = fields
+		}
+	}
+	for _, enrichment := range baseline.Enrichments {
+		add(enrichment, false)
+	}
+	for _, enrichment := range candidate.Enrichments {
+		add(enrichment, true)
+	}
+	return comparison, nil
+}
+
+// Changed returns the enrichments that the collections apply differently.
+func (comparison *CollectionEnrichmentComparison) Changed() []CollectionEnrichmentDiff {
+	var changed []CollectionEnrichmentDiff
+	for i := range comparison.Enrichments {
+		if comparison.Enrichments[i].Changed() {
+			changed = append(changed, comparison.Enrichments[i])
+		}
+	}
+	return changed
+}
+
+// WriteTable writes the enrichments with the fields each collection applies them to, or a dash when it does not use
+// them. Enrichments applied differently are marked with an asterisk.
+func (comparison *CollectionEnrichmentComparison) WriteTable(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "enrichment\ttype\t%s\t%s\n", collectionLabel(comparison.Baseline), collectionLabel(comparison.Candidate))
+	for i := range comparison.Enrichments {
+		diff := &comparison.Enrichments[i]
+		name := diff.Name
+		if name == "" {
+			name = diff.EnrichmentID
+		}
+		if diff.Changed() {
+			name = "* " + name
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", name, diff.Type, enrichmentFields(diff.BaselineFields), enrichmentFields(diff.CandidateFields))
+	}
+	return writer.Flush()
+}
+
+func collectionLabel(collection *CollectionDetails) string {
+	if collection.Name != nil {
+		return *collection.Name
+	}
+	return stringValue(collection.CollectionID)
+}
+
+func enrichmentFields(fields []string) string {
+	if fields == nil {
+		return "-"
+	}
+	return strings.Join(fields, ",")
+}
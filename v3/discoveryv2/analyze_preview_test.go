@@ -0,0 +1,203 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`AnalyzePreview`, func() {
+	const baselineResult = `{"result": {"metadata": {}, "text": ["IBM builds Watson."], "enriched_text": [{
+		"entities": [
+			{"text": "IBM", "type": "Organization", "model_name": "natural_language_understanding", "relevance": 0.9, "mentions": [{}, {}]},
+			{"text": "Watson", "type": "product", "model_name": "Dictionary:.products", "mentions": [{}]}
+		],
+		"keywords": [{"text": "Watson", "relevance": 0.8, "mentions": [{}]}],
+		"sentiment": {"document": {"label": "neutral", "score": 0}}
+	}]}}`
+	const candidateResult = `{"result": {"metadata": {}, "text": ["IBM builds Watson."], "enriched_text": [{
+		"entities": [
+			{"text": "IBM", "type": "Organization", "model_name": "natural_language_understanding", "relevance": 0.7, "mentions": [{}, {}]},
+			{"text": "IBM-1", "type": "id", "model_name": "Regex:.ids", "mentions": [{}]}
+		],
+		"sentiment": {"document": {"label": "neutral", "score": 0.1}}
+	}]}}`
+
+	var server *httptest.Server
+	var service *discoveryv2.DiscoveryV2
+	var mutex sync.Mutex
+	var attempts map[string]int
+
+	BeforeEach(func() {
+		attempts = make(map[string]int)
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/analyze"):
+				Expect(req.ParseMultipartForm(1 << 20)).To(Succeed())
+				_, header, err := req.FormFile("file")
+				Expect(err).To(BeNil())
+				mutex.Lock()
+				attempts[header.Filename]++
+				attempt := attempts[header.Filename]
+				mutex.Unlock()
+				switch {
+				case header.Filename == "busy.txt" && attempt == 1:
+					res.WriteHeader(429)
+					fmt.Fprint(res, `{"error": "Too many requests", "code": 429}`)
+				case header.Filename == "bad.txt":
+					res.WriteHeader(400)
+					fmt.Fprint(res, `{"error": "Unsupported file", "code": 400}`)
+				case strings.Contains(req.URL.Path, "/collections/c2/"):
+					fmt.Fprint(res, candidateResult)
+				default:
+					fmt.Fprint(res, baselineResult)
+				}
+			case req.URL.Path == "/v2/projects/p1/collections/c1":
+				fmt.Fprint(res, `{"collection_id": "c1", "name": "current", "enrichments": [
+					{"enrichment_id": "e1", "fields": ["text"]}, {"enrichment_id": "e2", "fields": ["text"]}]}`)
+			case req.URL.Path == "/v2/projects/p1/collections/c2":
+				fmt.Fprint(res, `{"collection_id": "c2", "name": "next", "enrichments": [
+					{"enrichment_id": "e1", "fields": ["text"]}, {"enrichment_id": "e3", "fields": ["text", "title"]}]}`)
+			case req.URL.Path == "/v2/projects/p1/enrichments":
+				fmt.Fprint(res, `{"enrichments": [
+					{"enrichment_id": "e1", "name": "Entities", "type": "natural_language_understanding"},
+					{"enrichment_id": "e2", "name": "products", "type": "dictionary"},
+					{"enrichment_id": "e3", "name": "ids", "type": "regular_expression"}]}`)
+			default:
+				res.WriteHeader(404)
+				fmt.Fprint(res, `{"error": "Not found", "code": 404}`)
+			}
+		}))
+		var err error
+		service, err = discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           server.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It(`Analyzes a directory into flat reports`, func() {
+		dir, err := ioutil.TempDir("", "analyze")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		for _, name := range []string{"a.txt", "bad.txt", "busy.txt"} {
+			Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte("IBM builds Watson."), 0644)).To(Succeed())
+		}
+
+		preview := service.NewAnalyzePreview("p1", "c1")
+		preview.InitialBackoff = 0
+		report, err := preview.AnalyzeDirectory(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(attempts["busy.txt"]).To(Equal(2))
+		Expect(report.Failed()).To(Equal(1))
+		Expect(report.Documents[1].StatusCode).To(Equal(400))
+
+		document := report.Documents[0]
+		Expect(document.Key).To(Equal("a.txt"))
+		Expect(document.Count(discoveryv2.AnnotationKindEntityConst)).To(Equal(1))
+		Expect(document.Count(discoveryv2.AnnotationKindDictionaryConst)).To(Equal(1))
+		Expect(document.Count(discoveryv2.AnnotationKindKeywordConst)).To(Equal(1))
+		Expect(document.Annotations[0].Mentions).To(Equal(2))
+
+		var summary bytes.Buffer
+		Expect(report.WriteSummary(&summary)).To(Succeed())
+		Expect(summary.String()).To(Equal("2 analyzed, 1 failed\n" +
+			"a.txt: 1 entities, 1 dictionary, 0 regular expression, 1 keywords, text sentiment neutral\n" +
+			"bad.txt: Unsupported file\n" +
+			"busy.txt: 1 entities, 1 dictionary, 0 regular expression, 1 keywords, text sentiment neutral\n"))
+
+		var table bytes.Buffer
+		Expect(report.WriteCSV(&table)).To(Succeed())
+		Expect(strings.SplitN(table.String(), "\n", 6)[:5]).To(Equal([]string{
+			"document,field,kind,type,text,model,score,label,mentions",
+			"a.txt,text,entity,Organization,IBM,natural_language_understanding,0.9,,2",
+			"a.txt,text,dictionary,product,Watson,Dictionary:.products,,,1",
+			"a.txt,text,keyword,,Watson,,0.8,,1",
+			"a.txt,text,sentiment,,,,0,neutral,0",
+		}))
+	})
+
+	It(`Compares previews and enrichment configurations`, func() {
+		item, err := discoveryv2.NewIngestItem("a.txt", strings.NewReader("IBM builds Watson."), "text/plain", nil)
+		Expect(err).To(BeNil())
+		baseline, err := service.NewAnalyzePreview("p1", "c1").Analyze(context.Background(), []*discoveryv2.IngestItem{item})
+		Expect(err).To(BeNil())
+		candidate, err := service.NewAnalyzePreview("p1", "c2").Analyze(context.Background(), []*discoveryv2.IngestItem{item})
+		Expect(err).To(BeNil())
+
+		var summary bytes.Buffer
+		Expect(discoveryv2.CompareAnalyzePreviews(baseline, candidate).WriteSummary(&summary)).To(Succeed())
+		Expect(summary.String()).To(Equal("1 of 1 documents differ\n" +
+			"a.txt: 1 added, 2 removed\n" +
+			"  - dictionary text product \"Watson\"\n" +
+			"  - keyword text \"Watson\"\n" +
+			"  + regular_expression text id \"IBM-1\"\n"))
+
+		comparison, err := service.CompareCollectionEnrichments(context.Background(), "p1", "c1", "c2")
+		Expect(err).To(BeNil())
+		Expect(comparison.Changed()).To(HaveLen(2))
+		var table bytes.Buffer
+		Expect(comparison.WriteTable(&table)).To(Succeed())
+		Expect(table.String()).To(Equal("" +
+			"enrichment  type                            current  next\n" +
+			"Entities    natural_language_understanding  text     text\n" +
+			"* products  dictionary                      text     -\n" +
+			"* ids       regular_expression              -        text,title\n"))
+
+		_, err = service.CompareCollectionEnrichments(context.Background(), "p1", "c1", "c9")
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Reports the items that were not analyzed after cancellation`, func() {
+		var items []*discoveryv2.IngestItem
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			item, err := discoveryv2.NewIngestItem(name, strings.NewReader("IBM builds Watson."), "text/plain", nil)
+			Expect(err).To(BeNil())
+			items = append(items, item)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		preview := service.NewAnalyzePreview("p1", "c1")
+		preview.Concurrency = 1
+		report, err := preview.Analyze(ctx, items)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(report.Failed()).To(Equal(3))
+		for i, document := range report.Documents {
+			Expect(document.Key).To(Equal(items[i].Key))
+			Expect(document.Error).ToNot(BeEmpty())
+		}
+	})
+})
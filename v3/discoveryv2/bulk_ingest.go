@@ -0,0 +1,585 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// Defaults of BulkIngester.
+const (
+	DefaultIngestConcurrency    = 4
+	DefaultIngestMaxRetries     = 5
+	DefaultIngestInitialBackoff = time.Second
+	DefaultIngestMaxInterval    = 30 * time.Second
+)
+
+// IngestStatusFailedConst is the manifest status of a document that could not be uploaded. Uploaded documents have the
+// status returned by the service, such as DocumentAcceptedStatusPendingConst.
+const IngestStatusFailedConst = "failed"
+
+// IngestMetadataSuffix is the suffix of the files that hold the metadata of a file in a directory being ingested.
+// The metadata of report.pdf is read from report.pdf.metadata.json.
+const IngestMetadataSuffix = ".metadata.json"
+
+// IngestItem : A document to upload.
+type IngestItem struct {
+	// Identifies the item in the manifest, such as the path of a file. Required.
+	Key string
+
+	// The ID of the document. When set, the document is uploaded with UpdateDocument and replaces any document with
+	// this ID; otherwise AddDocument assigns an ID. Set it to make retries safe: see BulkIngester.
+	DocumentID string
+
+	Filename    string
+	ContentType string
+	Metadata    map[string]interface{}
+
+	// Opens the content of the document. It is called for every attempt, so that uploads can be retried.
+	Open func() (io.ReadCloser, error)
+}
+
+// NewIngestItem : Instantiate IngestItem from a reader, which is read into memory so that the upload can be retried
+func NewIngestItem(key string, reader io.Reader, contentType string, metadata map[string]interface{}) (*IngestItem, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestItem{
+		Key:         key,
+		Filename:    key,
+		ContentType: contentType,
+		Metadata:    metadata,
+		Open: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		},
+	}, nil
+}
+
+// IngestManifestEntry : The outcome of uploading one item.
+type IngestManifestEntry struct {
+	Key        string    `json:"key"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Attempts   int       `json:"attempts"`
+	Time       time.Time `json:"time"`
+}
+
+// Succeeded returns whether the document was accepted by the service.
+func (entry IngestManifestEntry) Succeeded() bool {
+	return entry.Status != "" && entry.Status != IngestStatusFailedConst
+}
+
+// IngestManifest : A record of uploaded documents, kept in a file of JSON lines so that an interrupted ingestion can be
+// resumed. Each line is an IngestManifestEntry; later lines override earlier ones with the same key.
+type IngestManifest struct {
+	mutex   sync.Mutex
+	file    *os.File
+	entries map[string]IngestManifestEntry
+}
+
+// OpenIngestManifest opens or creates the manifest at path and loads its entries.
+func OpenIngestManifest(path string) (*IngestManifest, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &IngestManifest{file: file, entries: make(map[string]IngestManifestEntry)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry IngestManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s:%d: %s", path, line, err.Error())
+		}
+		manifest.entries[entry.Key] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Get returns the entry of a key and whether there is one.
+func (manifest *IngestManifest) Get(key string) (IngestManifestEntry, bool) {
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+	entry, ok := manifest.entries[key]
+	return entry, ok
+}
+
+// Entries returns all entries, sorted by key.
+func (manifest *IngestManifest) Entries() []IngestManifestEntry {
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+	entries := make([]IngestManifestEntry, 0, len(manifest.entries))
+	for _, entry := range manifest.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Record appends an entry to the manifest file.
+func (manifest *IngestManifest) Record(entry IngestManifestEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+	if _, err := manifest.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	manifest.entries[entry.Key] = entry
+	return nil
+}
+
+// Close closes the manifest file.
+func (manifest *IngestManifest) Close() error {
+	return manifest.file.Close()
+}
+
+// IngestReport : The summary of an ingestion.
+type IngestReport struct {
+	Uploaded int                   `json:"uploaded"`
+	Skipped  int                   `json:"skipped"`
+	Failed   int                   `json:"failed"`
+	Failures []IngestManifestEntry `json:"failures,omitempty"`
+}
+
+// WriteSummary writes the counts and one line per failure.
+func (report *IngestReport) WriteSummary(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%d uploaded, %d skipped, %d failed\n", report.Uploaded, report.Skipped, report.Failed); err != nil {
+		return err
+	}
+	for _, failure := range report.Failures {
+		if _, err := fmt.Fprintf(w, "%s: %s (after %d attempts)\n", failure.Key, failure.Error, failure.Attempts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adaptiveLimiter spaces out requests. The interval between requests doubles when the service throttles and shrinks
+// again as requests succeed.
+type adaptiveLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+	min      time.Duration
+	max      time.Duration
+}
+
+func (limiter *adaptiveLimiter) wait(ctx context.Context) error {
+	limiter.mutex.Lock()
+	now := time.Now()
+	start := limiter.next
+	if start.Before(now) {
+		start = now
+	}
+	limiter.next = start.Add(limiter.interval)
+	limiter.mutex.Unlock()
+	return sleepContext(ctx, start.Sub(now))
+}
+
+func (limiter *adaptiveLimiter) throttled(retryAfter time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.interval *= 2
+	if limiter.interval < limiter.min {
+		limiter.interval = limiter.min
+	}
+	if limiter.interval > limiter.max {
+		limiter.interval = limiter.max
+	}
+	if resume := time.Now().Add(retryAfter); resume.After(limiter.next) {
+		limiter.next = resume
+	}
+}
+
+func (limiter *adaptiveLimiter) succeeded() {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.interval = limiter.interval * 9 / 10
+	if limiter.interval < limiter.min/2 {
+		limiter.interval = 0
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func retryAfter(response *core.DetailedResponse) time.Duration {
+	if response == nil || response.Headers == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(response.Headers.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// retryableError returns whether a failed request is worth retrying: it was throttled, failed with a server error or
+// failed to reach the service. Other errors without a response, such as invalid options, are not retried.
+func retryableError(response *core.DetailedResponse, err error) bool {
+	if response != nil {
+		return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// BulkIngester : Uploads many documents to a collection with bounded concurrency. Uploads that are throttled (429) or
+// fail to reach the service are retried, and the rate of requests adapts to throttling. Uploads of items with a
+// DocumentID are also retried after server errors; other items are not, because the service may have added the
+// document before failing and a retry would add it again. The same can happen, more rarely, when the connection fails
+// after the service received the document. Results are recorded in an
+// optional manifest; items that the manifest records as uploaded are skipped, so an interrupted ingestion can be
+// resumed.
+type BulkIngester struct {
+	Discovery    *DiscoveryV2
+	ProjectID    string
+	CollectionID string
+
+	// The number of concurrent uploads. Defaults to DefaultIngestConcurrency.
+	Concurrency int
+
+	// Sets X-Watson-Discovery-Force, which is required to upload to a collection that shares its data with other
+	// collections.
+	Force bool
+
+	// The number of retries of a throttled or failed upload, and the delay before the first retry of a server or
+	// network error, which doubles with every retry. Throttled uploads wait for Retry-After when the service sends it.
+	MaxRetries     int
+	InitialBackoff time.Duration
+
+	// The largest interval between requests when the service throttles. Defaults to DefaultIngestMaxInterval.
+	MaxInterval time.Duration
+
+	// Records the result of every item and skips items uploaded before. Optional.
+	Manifest *IngestManifest
+
+	// Called after every item, for example to report progress.
+	OnResult func(IngestManifestEntry)
+}
+
+// NewBulkIngester : Instantiate BulkIngester
+func (discovery *DiscoveryV2) NewBulkIngester(projectID string, collectionID string) *BulkIngester {
+	return &BulkIngester{
+		Discovery:      discovery,
+		ProjectID:      projectID,
+		CollectionID:   collectionID,
+		Concurrency:    DefaultIngestConcurrency,
+		MaxRetries:     DefaultIngestMaxRetries,
+		InitialBackoff: DefaultIngestInitialBackoff,
+		MaxInterval:    DefaultIngestMaxInterval,
+	}
+}
+
+// Ingest uploads the items received from the channel until it is closed. It returns early with the context's error
+// when ctx is done, or when the manifest cannot be written.
+func (ingester *BulkIngester) Ingest(ctx context.Context, items <-chan *IngestItem) (*IngestReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := ingester.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultIngestConcurrency
+	}
+	maxInterval := ingester.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultIngestMaxInterval
+	}
+	minInterval := ingester.InitialBackoff / 10
+	if minInterval <= 0 {
+		minInterval = time.Millisecond
+	}
+	limiter := &adaptiveLimiter{min: minInterval, max: maxInterval}
+
+	report := &IngestReport{}
+	var mutex sync.Mutex
+	var failOnce sync.Once
+	var failure error
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var item *IngestItem
+				var ok bool
+				select {
+				case item, ok = <-items:
+				case <-ctx.Done():
+					return
+				}
+				if !ok {
+					return
+				}
+
+				if ingester.Manifest != nil {
+					if entry, found := ingester.Manifest.Get(item.Key); found && entry.Succeeded() {
+						mutex.Lock()
+						report.Skipped++
+						mutex.Unlock()
+						continue
+					}
+				}
+
+				// The entry is recorded even when ctx is done, so that a document the service accepted is not
+				// uploaded again when the ingestion is resumed.
+				entry := ingester.upload(ctx, limiter, item)
+				if ingester.Manifest != nil {
+					if err := ingester.Manifest.Record(entry); err != nil {
+						failOnce.Do(func() {
+							failure = err
+							cancel()
+						})
+						return
+					}
+				}
+				if ctx.Err() != nil && !entry.Succeeded() {
+					return
+				}
+				mutex.Lock()
+				if entry.Succeeded() {
+					report.Uploaded++
+				} else {
+					report.Failed++
+					report.Failures = append(report.Failures, entry)
+				}
+				mutex.Unlock()
+				if ingester.OnResult != nil {
+					ingester.OnResult(entry)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Key < report.Failures[j].Key
+	})
+	if failure != nil {
+		return report, failure
+	}
+	return report, ctx.Err()
+}
+
+// upload uploads one item, retrying throttled requests, network errors and, for updates, server errors.
+func (ingester *BulkIngester) upload(ctx context.Context, limiter *adaptiveLimiter, item *IngestItem) IngestManifestEntry {
+	entry := IngestManifestEntry{Key: item.Key, DocumentID: item.DocumentID, Status: IngestStatusFailedConst}
+	var metadata *string
+	if item.Metadata != nil {
+		data, err := json.Marshal(item.Metadata)
+		if err != nil {
+			entry.Error = err.Error()
+			entry.Time = time.Now()
+			return entry
+		}
+		metadata = core.StringPtr(string(data))
+	}
+
+	backoff := ingester.InitialBackoff
+	for entry.Attempts <= ingester.MaxRetries {
+		if err := limiter.wait(ctx); err != nil {
+			entry.Error = err.Error()
+			break
+		}
+		entry.Attempts++
+		accepted, response, err := ingester.send(ctx, item, metadata)
+		if err == nil {
+			limiter.succeeded()
+			entry.DocumentID = stringValue(accepted.DocumentID)
+			entry.Status = stringValue(accepted.Status)
+			entry.Error = ""
+			entry.StatusCode = response.StatusCode
+			break
+		}
+		entry.Error = err.Error()
+		entry.StatusCode = 0
+		if response != nil {
+			entry.StatusCode = response.StatusCode
+		}
+		if entry.StatusCode == http.StatusTooManyRequests {
+			limiter.throttled(retryAfter(response))
+			continue
+		}
+		if ctx.Err() != nil || !retryableError(response, err) || (response != nil && item.DocumentID == "") {
+			break
+		}
+		if sleepContext(ctx, backoff) != nil {
+			break
+		}
+		backoff *= 2
+	}
+	entry.Time = time.Now()
+	return entry
+}
+
+func (ingester *BulkIngester) send(ctx context.Context, item *IngestItem, metadata *string) (*DocumentAccepted, *core.DetailedResponse, error) {
+	file, err := item.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var filename, contentType *string
+	if item.Filename != "" {
+		filename = core.StringPtr(item.Filename)
+	}
+	if item.ContentType != "" {
+		contentType = core.StringPtr(item.ContentType)
+	}
+	var force *bool
+	if ingester.Force {
+		force = core.BoolPtr(true)
+	}
+
+	if item.DocumentID != "" {
+		options := ingester.Discovery.NewUpdateDocumentOptions(ingester.ProjectID, ingester.CollectionID, item.DocumentID)
+		options.File = file
+		options.Filename = filename
+		options.FileContentType = contentType
+		options.Metadata = metadata
+		options.XWatsonDiscoveryForce = force
+		return ingester.Discovery.UpdateDocumentWithContext(ctx, options)
+	}
+	options := ingester.Discovery.NewAddDocumentOptions(ingester.ProjectID, ingester.CollectionID)
+	options.File = file
+	options.Filename = filename
+	options.FileContentType = contentType
+	options.Metadata = metadata
+	options.XWatsonDiscoveryForce = force
+	return ingester.Discovery.AddDocumentWithContext(ctx, options)
+}
+
+// IngestDirectory uploads the files below a directory. Items are keyed by their slash-separated path relative to dir,
+// and the content type is derived from the file extension. Files named after another file plus IngestMetadataSuffix
+// hold the metadata of that file; hidden files and directories are skipped.
+func (ingester *BulkIngester) IngestDirectory(ctx context.Context, dir string) (*IngestReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan *IngestItem)
+	var walkErr error
+	go func() {
+		defer close(items)
+		walkErr = WalkIngestDirectory(dir, func(item *IngestItem) error {
+			select {
+			case items <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	report, err := ingester.Ingest(ctx, items)
+	cancel()
+	for range items {
+	}
+	if err == nil && walkErr != nil && walkErr != context.Canceled {
+		err = walkErr
+	}
+	return report, err
+}
+
+// WalkIngestDirectory calls fn with an item for every file below dir, as described for IngestDirectory.
+func WalkIngestDirectory(dir string, fn func(*IngestItem) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || strings.HasSuffix(info.Name(), IngestMetadataSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if i := strings.Index(contentType, ";"); i >= 0 {
+			contentType = contentType[:i]
+		}
+		item := &IngestItem{
+			Key:         filepath.ToSlash(rel),
+			Filename:    info.Name(),
+			ContentType: contentType,
+			Open: func() (io.ReadCloser, error) {
+				return os.Open(path)
+			},
+		}
+		data, err := ioutil.ReadFile(path + IngestMetadataSuffix)
+		if err == nil {
+			if err := json.Unmarshal(data, &item.Metadata); err != nil {
+				return fmt.Errorf("%s: %s", path+IngestMetadataSuffix, err.Error())
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		return fn(item)
+	})
+}
@@ -0,0 +1,214 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`BulkIngester`, func() {
+	var testServer *httptest.Server
+	var ingester *discoveryv2.BulkIngester
+	var mutex sync.Mutex
+	var attempts map[string]int
+	var metadata map[string]string
+	var forced []string
+	var dir string
+
+	BeforeEach(func() {
+		attempts = make(map[string]int)
+		metadata = make(map[string]string)
+		forced = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.Method).To(Equal("POST"))
+			_, header, err := req.FormFile("file")
+			Expect(err).To(BeNil())
+			name := header.Filename
+
+			mutex.Lock()
+			attempts[name]++
+			attempt := attempts[name]
+			metadata[name] = req.FormValue("metadata")
+			if req.Header.Get("X-Watson-Discovery-Force") == "true" {
+				forced = append(forced, name)
+			}
+			mutex.Unlock()
+
+			if strings.HasPrefix(name, "dropped") && attempt == 1 {
+				conn, _, err := res.(http.Hijacker).Hijack()
+				Expect(err).To(BeNil())
+				conn.Close()
+				return
+			}
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case strings.HasPrefix(name, "throttled") && attempt == 1:
+				res.Header().Set("Retry-After", "0")
+				res.WriteHeader(429)
+				fmt.Fprint(res, `{"error": "Too many requests", "code": 429}`)
+			case strings.HasPrefix(name, "broken"):
+				res.WriteHeader(500)
+				fmt.Fprint(res, `{"error": "Internal error", "code": 500}`)
+			case strings.HasPrefix(name, "invalid"):
+				res.WriteHeader(400)
+				fmt.Fprint(res, `{"error": "Unsupported file", "code": 400}`)
+			default:
+				documentID := "id-" + name
+				if strings.HasSuffix(req.URL.Path, "/documents/fixed") {
+					documentID = "fixed"
+				}
+				res.WriteHeader(202)
+				fmt.Fprintf(res, `{"document_id": "%s", "status": "processing"}`, documentID)
+			}
+		}))
+		service, err := discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		ingester = service.NewBulkIngester("p1", "c1")
+		ingester.InitialBackoff = time.Millisecond
+		ingester.MaxRetries = 2
+
+		dir, err = ioutil.TempDir("", "ingest")
+		Expect(err).To(BeNil())
+		Expect(os.MkdirAll(filepath.Join(dir, "sub", ".hidden"), 0755)).To(Succeed())
+		for name, content := range map[string]string{
+			"a.txt":                    "a",
+			"a.txt.metadata.json":      `{"author": "Poe"}`,
+			"sub/throttled.html":       "<p>b</p>",
+			"sub/broken.txt":           "c",
+			"sub/.hidden/skipped.txt":  "d",
+			"invalid.bin":              "e",
+			"sub/.ignored.txt":         "f",
+			"sub/throttled2.json":      "{}",
+			"sub/throttled2.json.orig": "{}",
+		} {
+			Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(Succeed())
+		}
+	})
+	AfterEach(func() {
+		testServer.Close()
+		os.RemoveAll(dir)
+	})
+
+	It(`Uploads a directory, retries and records a resumable manifest`, func() {
+		manifest, err := discoveryv2.OpenIngestManifest(filepath.Join(dir, "..", filepath.Base(dir)+".manifest"))
+		Expect(err).To(BeNil())
+		defer os.Remove(filepath.Join(dir, "..", filepath.Base(dir)+".manifest"))
+		ingester.Manifest = manifest
+
+		report, err := ingester.IngestDirectory(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(report.Uploaded).To(Equal(4))
+		Expect(report.Failed).To(Equal(2))
+		Expect(report.Failures[0].Key).To(Equal("invalid.bin"))
+		Expect(report.Failures[0].Attempts).To(Equal(1))
+		Expect(report.Failures[0].StatusCode).To(Equal(400))
+		Expect(report.Failures[1].Key).To(Equal("sub/broken.txt"))
+		Expect(report.Failures[1].Attempts).To(Equal(1))
+
+		Expect(attempts).To(HaveKeyWithValue("throttled.html", 2))
+		Expect(attempts).ToNot(HaveKey("skipped.txt"))
+		Expect(attempts).ToNot(HaveKey(".ignored.txt"))
+		Expect(metadata["a.txt"]).To(MatchJSON(`{"author": "Poe"}`))
+
+		entry, ok := manifest.Get("sub/throttled.html")
+		Expect(ok).To(BeTrue())
+		Expect(entry.DocumentID).To(Equal("id-throttled.html"))
+		Expect(entry.Status).To(Equal(discoveryv2.DocumentAcceptedStatusProcessingConst))
+		Expect(manifest.Close()).To(Succeed())
+
+		var summary bytes.Buffer
+		Expect(report.WriteSummary(&summary)).To(Succeed())
+		Expect(summary.String()).To(HavePrefix("4 uploaded, 0 skipped, 2 failed\ninvalid.bin: "))
+
+		// A second run skips the uploaded files and retries the failures.
+		manifest, err = discoveryv2.OpenIngestManifest(filepath.Join(dir, "..", filepath.Base(dir)+".manifest"))
+		Expect(err).To(BeNil())
+		Expect(manifest.Entries()).To(HaveLen(6))
+		ingester.Manifest = manifest
+		report, err = ingester.IngestDirectory(context.Background(), dir)
+		Expect(err).To(BeNil())
+		Expect(report.Skipped).To(Equal(4))
+		Expect(report.Failed).To(Equal(2))
+		Expect(attempts["a.txt"]).To(Equal(1))
+		Expect(manifest.Close()).To(Succeed())
+	})
+	It(`Uploads a stream of items with forced and fixed IDs`, func() {
+		ingester.Force = true
+		ingester.Concurrency = 2
+		var results []string
+		ingester.OnResult = func(entry discoveryv2.IngestManifestEntry) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			results = append(results, entry.Key)
+		}
+
+		items := make(chan *discoveryv2.IngestItem, 2)
+		item, err := discoveryv2.NewIngestItem("one.txt", strings.NewReader("one"), "text/plain", map[string]interface{}{"n": 1})
+		Expect(err).To(BeNil())
+		items <- item
+		item, err = discoveryv2.NewIngestItem("two.txt", strings.NewReader("two"), "text/plain", nil)
+		Expect(err).To(BeNil())
+		item.DocumentID = "fixed"
+		items <- item
+		close(items)
+
+		report, err := ingester.Ingest(context.Background(), items)
+		Expect(err).To(BeNil())
+		Expect(report.Uploaded).To(Equal(2))
+		Expect(results).To(ConsistOf("one.txt", "two.txt"))
+		Expect(forced).To(ConsistOf("one.txt", "two.txt"))
+		Expect(metadata["one.txt"]).To(MatchJSON(`{"n": 1}`))
+		Expect(metadata["two.txt"]).To(BeEmpty())
+	})
+	It(`Retries network errors, and server errors only for documents with an ID`, func() {
+		items := make(chan *discoveryv2.IngestItem, 3)
+		for _, name := range []string{"dropped.txt", "broken.txt", "broken-fixed.txt"} {
+			item, err := discoveryv2.NewIngestItem(name, strings.NewReader(name), "text/plain", nil)
+			Expect(err).To(BeNil())
+			if name == "broken-fixed.txt" {
+				item.DocumentID = "fixed"
+			}
+			items <- item
+		}
+		close(items)
+
+		report, err := ingester.Ingest(context.Background(), items)
+		Expect(err).To(BeNil())
+		Expect(report.Uploaded).To(Equal(1))
+		Expect(attempts).To(Equal(map[string]int{"dropped.txt": 2, "broken.txt": 1, "broken-fixed.txt": 3}))
+	})
+})
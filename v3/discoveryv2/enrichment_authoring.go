@@ -0,0 +1,630 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// DefaultDictionaryPartOfSpeech is the part of speech code of dictionary terms that do not set one, which is the code
+// of nouns.
+const DefaultDictionaryPartOfSpeech = "3"
+
+// EnrichmentSource : An enrichment that can be created with CreateEnrichmentFromSource. Enrichment validates the
+// enrichment and returns its definition with the file uploaded with it, which is nil for enrichments without a file.
+type EnrichmentSource interface {
+	Enrichment() (*CreateEnrichment, io.ReadCloser, error)
+}
+
+// DictionaryTerm : A term of a dictionary enrichment with its synonyms.
+type DictionaryTerm struct {
+	Lemma    string
+	Synonyms []string
+
+	// Defaults to DefaultDictionaryPartOfSpeech.
+	PartOfSpeech string
+}
+
+// DictionaryEnrichment : Builds a dictionary enrichment, which annotates the terms of a dictionary and their synonyms
+// with an entity type.
+type DictionaryEnrichment struct {
+	Name        string
+	Description string
+	EntityType  string
+	Languages   []string
+	Terms       []DictionaryTerm
+}
+
+// NewDictionaryEnrichment : Instantiate DictionaryEnrichment
+func NewDictionaryEnrichment(name string, entityType string, languages ...string) *DictionaryEnrichment {
+	return &DictionaryEnrichment{Name: name, EntityType: entityType, Languages: languages}
+}
+
+// AddTerm adds a term with its synonyms. The synonyms of a term that was already added are appended to it.
+func (dictionary *DictionaryEnrichment) AddTerm(lemma string, synonyms ...string) *DictionaryEnrichment {
+	for i := range dictionary.Terms {
+		if dictionary.Terms[i].Lemma == lemma {
+			dictionary.Terms[i].Synonyms = append(dictionary.Terms[i].Synonyms, synonyms...)
+			return dictionary
+		}
+	}
+	dictionary.Terms = append(dictionary.Terms, DictionaryTerm{Lemma: lemma, Synonyms: synonyms})
+	return dictionary
+}
+
+// Validate returns an error if the dictionary cannot be created. The surface forms of a term are listed in a single
+// comma-separated column, so they cannot contain commas.
+func (dictionary *DictionaryEnrichment) Validate() error {
+	if err := validateEnrichment(dictionary.Name, dictionary.EntityType, dictionary.Languages); err != nil {
+		return err
+	}
+	if len(dictionary.Terms) == 0 {
+		return fmt.Errorf("dictionary %q has no terms", dictionary.Name)
+	}
+	for i, term := range dictionary.Terms {
+		if strings.TrimSpace(term.Lemma) == "" {
+			return fmt.Errorf("term %d of dictionary %q is empty", i+1, dictionary.Name)
+		}
+		for _, form := range append([]string{term.Lemma}, term.Synonyms...) {
+			if strings.ContainsAny(form, ",\r\n") {
+				return fmt.Errorf("term %q of dictionary %q contains a comma or line break: %q", term.Lemma, dictionary.Name, form)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes the dictionary file, which has a lemma, poscode and surface column. The surface forms of a term are
+// its lemma and its synonyms, without duplicates or empty forms.
+func (dictionary *DictionaryEnrichment) WriteCSV(w io.Writer) error {
+	if err := dictionary.Validate(); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"lemma", "poscode", "surface"}); err != nil {
+		return err
+	}
+	for _, term := range dictionary.Terms {
+		lemma := strings.TrimSpace(term.Lemma)
+		forms := []string{lemma}
+		seen := map[string]bool{lemma: true}
+		for _, synonym := range term.Synonyms {
+			synonym = strings.TrimSpace(synonym)
+			if synonym != "" && !seen[synonym] {
+				seen[synonym] = true
+				forms = append(forms, synonym)
+			}
+		}
+		partOfSpeech := term.PartOfSpeech
+		if partOfSpeech == "" {
+			partOfSpeech = DefaultDictionaryPartOfSpeech
+		}
+		if err := writer.Write([]string{lemma, partOfSpeech, strings.Join(forms, ",")}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Enrichment returns the definition of the dictionary enrichment with its CSV file.
+func (dictionary *DictionaryEnrichment) Enrichment() (*CreateEnrichment, io.ReadCloser, error) {
+	var buffer bytes.Buffer
+	if err := dictionary.WriteCSV(&buffer); err != nil {
+		return nil, nil, err
+	}
+	enrichment := &CreateEnrichment{
+		Name: core.StringPtr(dictionary.Name),
+		Type: core.StringPtr(CreateEnrichmentTypeDictionaryConst),
+		Options: &EnrichmentOptions{
+			Languages:  dictionary.Languages,
+			EntityType: core.StringPtr(dictionary.EntityType),
+		},
+	}
+	if dictionary.Description != "" {
+		enrichment.Description = core.StringPtr(dictionary.Description)
+	}
+	return enrichment, ioutil.NopCloser(&buffer), nil
+}
+
+// RegularExpressionEnrichment : Builds a regular expression enrichment, which annotates the matches of a regular
+// expression with an entity type.
+type RegularExpressionEnrichment struct {
+	Name        string
+	Description string
+	EntityType  string
+	Languages   []string
+
+	// A regular expression in the syntax of Java.
+	Expression string
+}
+
+// NewRegularExpressionEnrichment : Instantiate RegularExpressionEnrichment
+func NewRegularExpressionEnrichment(name string, entityType string, expression string, languages ...string) *RegularExpressionEnrichment {
+	return &RegularExpressionEnrichment{Name: name, EntityType: entityType, Expression: expression, Languages: languages}
+}
+
+// Validate returns an error if the enrichment cannot be created.
+func (enrichment *RegularExpressionEnrichment) Validate() error {
+	if err := validateEnrichment(enrichment.Name, enrichment.EntityType, enrichment.Languages); err != nil {
+		return err
+	}
+	return ValidateRegularExpression(enrichment.Expression)
+}
+
+// Enrichment returns the definition of the regular expression enrichment, which has no file.
+func (enrichment *RegularExpressionEnrichment) Enrichment() (*CreateEnrichment, io.ReadCloser, error) {
+	if err := enrichment.Validate(); err != nil {
+		return nil, nil, err
+	}
+	definition := &CreateEnrichment{
+		Name: core.StringPtr(enrichment.Name),
+		Type: core.StringPtr(CreateEnrichmentTypeRegularExpressionConst),
+		Options: &EnrichmentOptions{
+			Languages:         enrichment.Languages,
+			EntityType:        core.StringPtr(enrichment.EntityType),
+			RegularExpression: core.StringPtr(enrichment.Expression),
+		},
+	}
+	if enrichment.Description != "" {
+		definition.Description = core.StringPtr(enrichment.Description)
+	}
+	return definition, nil, nil
+}
+
+// ValidateRegularExpression returns an error if a regular expression is not valid or matches the empty string. The
+// service uses the syntax of Java, so constructs that Go does not support are rewritten before the expression is
+// checked: lookarounds, atomic groups, possessive quantifiers and back references are accepted and only the expression
+// around them is checked, Java escapes such as \h, \R, \Z, \G, \Q...\E and \uXXXX are translated, Java character
+// properties such as \p{javaLowerCase} and \p{Lower} are accepted, and nested and intersected classes such as
+// [a-z&&[^e]] are checked part by part.
+func ValidateRegularExpression(expression string) error {
+	if expression == "" {
+		return fmt.Errorf("the regular expression is empty")
+	}
+	compiled, err := regexp.Compile(portableRegularExpression(expression))
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %q: %s", expression, err.Error())
+	}
+	if compiled.MatchString("") {
+		return fmt.Errorf("the regular expression %q matches the empty string", expression)
+	}
+	return nil
+}
+
+// repetitionPattern matches a counted repetition such as {2} or {2,5}.
+var repetitionPattern = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}`)
+
+// portableRegularExpression rewrites the constructs of Java regular expressions that Go does not support: lookarounds
+// and atomic groups become non-capturing groups, back references become empty groups, possessive quantifiers become
+// greedy ones, named groups use the syntax of Go, and escapes and classes are rewritten by portableEscape and
+// portableClass. As in Java, a back reference takes as many digits as make up the number of a group opened before it.
+func portableRegularExpression(expression string) string {
+	var builder strings.Builder
+	groups := 0
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		rest := expression[i:]
+		switch {
+		case c == '\\' && i+1 < len(expression):
+			next := expression[i+1]
+			end := strings.IndexByte(rest, '>')
+			if next >= '1' && next <= '9' {
+				builder.WriteString("(?:)")
+				group := int(next - '0')
+				for i+2 < len(expression) && expression[i+2] >= '0' && expression[i+2] <= '9' {
+					longer := group*10 + int(expression[i+2]-'0')
+					if longer > groups {
+						break
+					}
+					group = longer
+					i++
+				}
+				i++
+			} else if next == 'k' && strings.HasPrefix(expression[i+2:], "<") && end > 0 {
+				builder.WriteString("(?:)")
+				i += end
+			} else {
+				escape, length := portableEscape(rest, false)
+				builder.WriteString(escape)
+				i += length - 1
+			}
+		case c == '[':
+			class, length := portableClass(rest)
+			builder.WriteString(class)
+			i += length - 1
+		case strings.HasPrefix(rest, "(?<=") || strings.HasPrefix(rest, "(?<!"):
+			builder.WriteString("(?:")
+			i += 3
+		case strings.HasPrefix(rest, "(?=") || strings.HasPrefix(rest, "(?!") || strings.HasPrefix(rest, "(?>"):
+			builder.WriteString("(?:")
+			i += 2
+		case strings.HasPrefix(rest, "(?<"):
+			groups++
+			builder.WriteString("(?P<")
+			i += 2
+		case c == '(' && !strings.HasPrefix(rest, "(?"):
+			groups++
+			builder.WriteByte(c)
+		case c == '*' || c == '+' || c == '?' || c == '{' && repetitionPattern.MatchString(rest):
+			quantifier := repetitionPattern.FindString(rest)
+			if quantifier == "" {
+				quantifier = rest[:1]
+			}
+			builder.WriteString(quantifier)
+			i += len(quantifier) - 1
+			if strings.HasPrefix(expression[i+1:], "+") {
+				i++
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// portableClass rewrites the character class at the start of expression and returns it with the length of the Java
+// class. Java classes can be nested, as in [a-d[m-p]], and intersected, as in [a-z&&[^e]], which Go classes cannot, so
+// such a class becomes an alternation of its parts. The alternation matches other characters than the class, but it
+// is valid where the class is and, like the class, matches one character.
+func portableClass(expression string) (string, int) {
+	negation := ""
+	i := 1
+	if strings.HasPrefix(expression, "[^") {
+		negation = "^"
+		i++
+	}
+	var parts []string
+	var body strings.Builder
+	// A closing bracket right after the opening one, or after a negation, is a literal.
+	if strings.HasPrefix(expression[i:], "]") {
+		body.WriteByte(']')
+		i++
+	}
+	endBody := func() {
+		if body.Len() > 0 {
+			parts = append(parts, "["+negation+body.String()+"]")
+			body.Reset()
+		}
+	}
+	for ; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case c == '\\' && i+1 < len(expression):
+			escape, length := portableEscape(expression[i:], true)
+			body.WriteString(escape)
+			i += length - 1
+		case c == '[':
+			endBody()
+			class, length := portableClass(expression[i:])
+			parts = append(parts, class)
+			i += length - 1
+		case strings.HasPrefix(expression[i:], "&&"):
+			endBody()
+			i++
+		case c == ']':
+			endBody()
+			if len(parts) == 1 {
+				return parts[0], i + 1
+			}
+			if len(parts) == 0 {
+				return `[^\x00-\x{10FFFF}]`, i + 1
+			}
+			return "(?:" + strings.Join(parts, "|") + ")", i + 1
+		default:
+			body.WriteByte(c)
+		}
+	}
+	// An unclosed class is left for Go to report.
+	return "[" + negation + body.String(), len(expression)
+}
+
+// Java whitespace classes, as the contents of Go classes.
+const (
+	javaHorizontalWhitespace    = `\t \x{A0}\x{1680}\x{180E}\x{2000}-\x{200A}\x{202F}\x{205F}\x{3000}`
+	javaNotHorizontalWhitespace = `\x00-\x08\x{0A}-\x{1F}\x{21}-\x{9F}\x{A1}-\x{167F}\x{1681}-\x{180D}\x{180F}-\x{1FFF}` +
+		`\x{200B}-\x{202E}\x{2030}-\x{205E}\x{2060}-\x{2FFF}\x{3001}-\x{10FFFF}`
+	javaVerticalWhitespace    = `\n-\r\x{85}\x{2028}\x{2029}`
+	javaNotVerticalWhitespace = `\x00-\x09\x{0E}-\x{84}\x{86}-\x{2027}\x{202A}-\x{10FFFF}`
+)
+
+// portableEscape rewrites the escape at the start of expression and returns it with the length of the Java escape.
+// Escapes that Go supports are returned as they are.
+func portableEscape(expression string, inClass bool) (string, int) {
+	class := func(contents string) string {
+		if inClass {
+			return contents
+		}
+		return "[" + contents + "]"
+	}
+	switch next := expression[1]; {
+	case next == 'h':
+		return class(javaHorizontalWhitespace), 2
+	case next == 'H':
+		return class(javaNotHorizontalWhitespace), 2
+	case next == 'v':
+		return class(javaVerticalWhitespace), 2
+	case next == 'V':
+		return class(javaNotVerticalWhitespace), 2
+	case next == 'R' && !inClass:
+		return `(?:\r\n|[` + javaVerticalWhitespace + `])`, 2
+	case next == 'Z' && !inClass:
+		return `(?:\n?\z)`, 2
+	case next == 'G' && !inClass:
+		return `\A`, 2
+	case next == 'e':
+		return `\x1B`, 2
+	case next == 'c' && len(expression) > 2:
+		return fmt.Sprintf(`\x{%X}`, expression[2]^64), 3
+	case next == 'u' && len(expression) >= 6 && isHexadecimal(expression[2:6]):
+		return `\x{` + expression[2:6] + `}`, 6
+	case next == 'Q':
+		quoted, length := expression[2:], len(expression)
+		if end := strings.Index(expression, `\E`); end >= 0 {
+			quoted, length = expression[2:end], end+2
+		}
+		quoted = regexp.QuoteMeta(quoted)
+		if inClass {
+			quoted = strings.Replace(quoted, "-", `\-`, -1)
+		}
+		return quoted, length
+	case (next == 'p' || next == 'P') && strings.HasPrefix(expression[2:], "{"):
+		if end := strings.IndexByte(expression, '}'); end > 0 {
+			return portableProperty(next == 'P', expression[3:end], inClass), end + 1
+		}
+	}
+	return expression[:2], 2
+}
+
+// posixProperties maps the POSIX character properties of Java to the ASCII classes of Go.
+var posixProperties = map[string]string{
+	"Lower": "lower", "Upper": "upper", "ASCII": "ascii", "Alpha": "alpha", "Digit": "digit", "Alnum": "alnum",
+	"Punct": "punct", "Graph": "graph", "Print": "print", "Blank": "blank", "Cntrl": "cntrl", "XDigit": "xdigit",
+	"Space": "space",
+}
+
+// javaBinaryProperties are the binary Unicode properties that Java accepts with the prefix Is, as in \p{IsAlphabetic}.
+var javaBinaryProperties = map[string]bool{
+	"alphabetic": true, "ideographic": true, "letter": true, "lowercase": true, "uppercase": true, "titlecase": true,
+	"punctuation": true, "control": true, "white_space": true, "whitespace": true, "digit": true, "hex_digit": true,
+	"hexdigit": true, "join_control": true, "joincontrol": true, "noncharacter_code_point": true,
+	"noncharactercodepoint": true, "assigned": true, "emoji": true, "emoji_presentation": true, "emoji_modifier": true,
+	"emoji_modifier_base": true, "emoji_component": true, "extended_pictographic": true,
+}
+
+// portableProperty rewrites the character property \p{name}, or \P{name} when negated. Categories and scripts that Go
+// knows are kept, POSIX properties become ASCII classes, and the Java properties, blocks and binary properties that Go
+// does not know become \p{Any}, so that the expression around them can be checked. Other names are left for Go to
+// report.
+func portableProperty(negated bool, name string, inClass bool) string {
+	escape := `\p`
+	if negated {
+		escape = `\P`
+	}
+	property := strings.TrimPrefix(name, "Is")
+	if _, ok := unicode.Categories[property]; ok {
+		return escape + "{" + property + "}"
+	}
+	if _, ok := unicode.Scripts[property]; ok && property != name {
+		return escape + "{" + property + "}"
+	}
+	if posix, ok := posixProperties[name]; ok {
+		if negated {
+			posix = "^" + posix
+		}
+		if inClass {
+			return "[:" + posix + ":]"
+		}
+		return "[[:" + posix + ":]]"
+	}
+	if strings.HasPrefix(name, "java") || strings.HasPrefix(name, "In") || strings.Contains(name, "=") ||
+		(property != name && javaBinaryProperties[strings.ToLower(property)]) {
+		return `\p{Any}`
+	}
+	return escape + "{" + name + "}"
+}
+
+func isHexadecimal(text string) bool {
+	for _, c := range text {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// RuleBasedEnrichment : Builds a rule-based enrichment from the files of a rule-based model exported from Watson
+// Knowledge Studio, which are packed into a zip file.
+type RuleBasedEnrichment struct {
+	Name        string
+	Description string
+	Languages   []string
+
+	// The field of the annotations of the model.
+	ResultField string
+
+	// The directory of the files of the model.
+	Directory string
+}
+
+// NewRuleBasedEnrichment : Instantiate RuleBasedEnrichment
+func NewRuleBasedEnrichment(name string, resultField string, directory string) *RuleBasedEnrichment {
+	return &RuleBasedEnrichment{Name: name, ResultField: resultField, Directory: directory}
+}
+
+// Enrichment returns the definition of the rule-based enrichment with the zip file of its model.
+func (enrichment *RuleBasedEnrichment) Enrichment() (*CreateEnrichment, io.ReadCloser, error) {
+	if enrichment.Name == "" {
+		return nil, nil, fmt.Errorf("the enrichment has no name")
+	}
+	if enrichment.ResultField == "" {
+		return nil, nil, fmt.Errorf("rule-based enrichment %q has no result field", enrichment.Name)
+	}
+	var buffer bytes.Buffer
+	if err := PackRuleBasedModel(enrichment.Directory, &buffer); err != nil {
+		return nil, nil, err
+	}
+	definition := &CreateEnrichment{
+		Name: core.StringPtr(enrichment.Name),
+		Type: core.StringPtr(CreateEnrichmentTypeRuleBasedConst),
+		Options: &EnrichmentOptions{
+			Languages:   enrichment.Languages,
+			ResultField: core.StringPtr(enrichment.ResultField),
+		},
+	}
+	if enrichment.Description != "" {
+		definition.Description = core.StringPtr(enrichment.Description)
+	}
+	return definition, ioutil.NopCloser(&buffer), nil
+}
+
+// PackRuleBasedModel writes the files of a directory to w as a zip file, with paths relative to the directory. Hidden
+// files and directories are left out.
+func PackRuleBasedModel(directory string, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	files := 0
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != directory && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		header.Method = zip.Deflate
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(writer, file); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if files == 0 {
+		return fmt.Errorf("the rule-based model directory %q has no files", directory)
+	}
+	return archive.Close()
+}
+
+func validateEnrichment(name string, entityType string, languages []string) error {
+	if name == "" {
+		return fmt.Errorf("the enrichment has no name")
+	}
+	if entityType == "" {
+		return fmt.Errorf("enrichment %q has no entity type", name)
+	}
+	if len(languages) == 0 {
+		return fmt.Errorf("enrichment %q has no languages", name)
+	}
+	return nil
+}
+
+// CreateEnrichmentFromSource validates an enrichment and creates it in a project with its file.
+func (discovery *DiscoveryV2) CreateEnrichmentFromSource(ctx context.Context, projectID string, source EnrichmentSource) (*Enrichment, *core.DetailedResponse, error) {
+	definition, file, err := source.Enrichment()
+	if err != nil {
+		return nil, nil, err
+	}
+	options := discovery.NewCreateEnrichmentOptions(projectID, definition)
+	if file != nil {
+		defer file.Close()
+		options.SetFile(file)
+	}
+	return discovery.CreateEnrichmentWithContext(ctx, options)
+}
+
+// AttachEnrichment applies an enrichment to fields of collections. The enrichments of each collection are updated
+// with the enrichment, whose fields are replaced if the collection already has it. Collections are updated in order
+// and the first error is returned.
+func (discovery *DiscoveryV2) AttachEnrichment(ctx context.Context, projectID string, enrichmentID string, fields []string, collectionIds ...string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("enrichment %s is attached to no fields", enrichmentID)
+	}
+	for _, collectionID := range collectionIds {
+		collection, _, err := discovery.GetCollectionWithContext(ctx, discovery.NewGetCollectionOptions(projectID, collectionID))
+		if err != nil {
+			return fmt.Errorf("collection %s: %s", collectionID, err.Error())
+		}
+		enrichments := make([]CollectionEnrichment, 0, len(collection.Enrichments)+1)
+		attached := false
+		for _, enrichment := range collection.Enrichments {
+			if stringValue(enrichment.EnrichmentID) == enrichmentID {
+				enrichment.Fields = fields
+				attached = true
+			}
+			enrichments = append(enrichments, enrichment)
+		}
+		if !attached {
+			enrichments = append(enrichments, CollectionEnrichment{EnrichmentID: core.StringPtr(enrichmentID), Fields: fields})
+		}
+		options := discovery.NewUpdateCollectionOptions(projectID, collectionID).SetEnrichments(enrichments)
+		if _, _, err := discovery.UpdateCollectionWithContext(ctx, options); err != nil {
+			return fmt.Errorf("collection %s: %s", collectionID, err.Error())
+		}
+	}
+	return nil
+}
+
+// CreateAndAttachEnrichment creates an enrichment and applies it to fields of collections, see AttachEnrichment. The
+// enrichment is returned once created, also when it could not be attached.
+func (discovery *DiscoveryV2) CreateAndAttachEnrichment(ctx context.Context, projectID string, source EnrichmentSource, fields []string, collectionIds ...string) (*Enrichment, error) {
+	enrichment, _, err := discovery.CreateEnrichmentFromSource(ctx, projectID, source)
+	if err != nil {
+		return nil, err
+	}
+	if enrichment.EnrichmentID == nil {
+		return enrichment, fmt.Errorf("the created enrichment has no ID")
+	}
+	return enrichment, discovery.AttachEnrichment(ctx, projectID, *enrichment.EnrichmentID, fields, collectionIds...)
+}
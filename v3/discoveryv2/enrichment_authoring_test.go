@@ -0,0 +1,177 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`Enrichment authoring`, func() {
+	It(`Writes dictionary CSVs`, func() {
+		dictionary := discoveryv2.NewDictionaryEnrichment("products", "product", "en").
+			AddTerm("Watson", "IBM Watson", " Watson ").
+			AddTerm("Discovery", "Watson Discovery").
+			AddTerm("Watson", "Watson AI")
+		var buffer bytes.Buffer
+		Expect(dictionary.WriteCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal("lemma,poscode,surface\n" +
+			"Watson,3,\"Watson,IBM Watson,Watson AI\"\n" +
+			"Discovery,3,\"Discovery,Watson Discovery\"\n"))
+
+		enrichment, file, err := dictionary.Enrichment()
+		Expect(err).To(BeNil())
+		Expect(*enrichment.Type).To(Equal(discoveryv2.CreateEnrichmentTypeDictionaryConst))
+		Expect(*enrichment.Options.EntityType).To(Equal("product"))
+		Expect(enrichment.Options.Languages).To(Equal([]string{"en"}))
+		contents, err := ioutil.ReadAll(file)
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal(buffer.String()))
+
+		Expect(discoveryv2.NewDictionaryEnrichment("products", "product").AddTerm("a").Validate()).To(MatchError(`enrichment "products" has no languages`))
+		Expect(discoveryv2.NewDictionaryEnrichment("products", "product", "en").Validate()).To(MatchError(`dictionary "products" has no terms`))
+		Expect(discoveryv2.NewDictionaryEnrichment("products", "product", "en").AddTerm("a", "b,c").Validate()).ToNot(BeNil())
+	})
+
+	It(`Validates regular expressions`, func() {
+		Expect(discoveryv2.ValidateRegularExpression(`[A-Z]{2}-\d{4}`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(?<=ID: )\d+(?!\d)`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(?<word>\w+) \k<word>`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(a)\1`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`[(]\d+`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`a*+b\d++c?+[+]{2,3}+`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(a)?\12`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(a)?(b)?(c)?(d)?(e)?(f)?(g)?(h)?(i)?(j)?(k)?(l)?\12`)).To(MatchError(ContainSubstring("matches the empty string")))
+		Expect(discoveryv2.ValidateRegularExpression(`a{2}+`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`\h+\R\Z`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`\G\Q(a*\E[\Qz-a\E]\u00e9\cJ`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`\p{javaLowerCase}\p{Lower}\p{IsAlphabetic}\p{InGreek}\p{IsLatin}[\P{Upper}]`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`[a-z&&[^e]][[a-d][m-p]]`)).To(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`[a-z&&[^e]]*`)).To(MatchError(ContainSubstring("matches the empty string")))
+		Expect(discoveryv2.ValidateRegularExpression(`[a-z&&[z-a]]`)).ToNot(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`\p{Lowr}`)).ToNot(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`a**`)).ToNot(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`(\d+`)).ToNot(Succeed())
+		Expect(discoveryv2.ValidateRegularExpression(`\d*`)).To(MatchError(`the regular expression "\\d*" matches the empty string`))
+		Expect(discoveryv2.ValidateRegularExpression(``)).ToNot(Succeed())
+
+		_, _, err := discoveryv2.NewRegularExpressionEnrichment("ids", "id", `(\d+`, "en").Enrichment()
+		Expect(err).ToNot(BeNil())
+		enrichment, file, err := discoveryv2.NewRegularExpressionEnrichment("ids", "id", `\d+`, "en").Enrichment()
+		Expect(err).To(BeNil())
+		Expect(file).To(BeNil())
+		Expect(*enrichment.Options.RegularExpression).To(Equal(`\d+`))
+	})
+
+	It(`Packs rule-based models`, func() {
+		directory, err := ioutil.TempDir("", "rule-based")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(directory)
+		Expect(os.MkdirAll(filepath.Join(directory, "rules", ".git"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "model.json"), []byte(`{}`), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "rules", "rule.aql"), []byte(`create view A;`), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "rules", ".git", "HEAD"), []byte(`ref`), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, ".DS_Store"), []byte(`x`), 0644)).To(Succeed())
+
+		enrichment, file, err := discoveryv2.NewRuleBasedEnrichment("rules", "entities", directory).Enrichment()
+		Expect(err).To(BeNil())
+		Expect(*enrichment.Type).To(Equal(discoveryv2.CreateEnrichmentTypeRuleBasedConst))
+		Expect(*enrichment.Options.ResultField).To(Equal("entities"))
+		contents, err := ioutil.ReadAll(file)
+		Expect(err).To(BeNil())
+		archive, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+		Expect(err).To(BeNil())
+		var names []string
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+		}
+		Expect(names).To(Equal([]string{"model.json", "rules/rule.aql"}))
+
+		empty, err := ioutil.TempDir("", "rule-based")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(empty)
+		Expect(discoveryv2.PackRuleBasedModel(empty, &bytes.Buffer{})).ToNot(Succeed())
+	})
+
+	It(`Creates enrichments and attaches them to collections`, func() {
+		var updates []string
+		var uploaded string
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case req.Method == "POST" && req.URL.Path == "/v2/projects/p1/enrichments":
+				Expect(req.ParseMultipartForm(1 << 20)).To(Succeed())
+				var enrichment map[string]interface{}
+				Expect(json.Unmarshal([]byte(req.FormValue("enrichment")), &enrichment)).To(Succeed())
+				Expect(enrichment["type"]).To(Equal("dictionary"))
+				file, _, err := req.FormFile("file")
+				Expect(err).To(BeNil())
+				contents, _ := ioutil.ReadAll(file)
+				uploaded = string(contents)
+				res.WriteHeader(201)
+				fmt.Fprint(res, `{"enrichment_id": "e1", "name": "products", "type": "dictionary"}`)
+			case req.Method == "GET" && req.URL.Path == "/v2/projects/p1/collections/c1":
+				fmt.Fprint(res, `{"collection_id": "c1", "enrichments": [{"enrichment_id": "e0", "fields": ["text"]}]}`)
+			case req.Method == "GET" && req.URL.Path == "/v2/projects/p1/collections/c2":
+				fmt.Fprint(res, `{"collection_id": "c2", "enrichments": [{"enrichment_id": "e1", "fields": ["title"]}]}`)
+			case req.Method == "POST":
+				body, _ := ioutil.ReadAll(req.Body)
+				updates = append(updates, req.URL.Path+" "+strings.TrimSpace(string(body)))
+				fmt.Fprint(res, `{"collection_id": "c1"}`)
+			default:
+				res.WriteHeader(404)
+				fmt.Fprint(res, `{"error": "Not found", "code": 404}`)
+			}
+		}))
+		defer server.Close()
+		service, err := discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           server.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+
+		dictionary := discoveryv2.NewDictionaryEnrichment("products", "product", "en").AddTerm("Watson")
+		enrichment, err := service.CreateAndAttachEnrichment(context.Background(), "p1", dictionary, []string{"text"}, "c1", "c2")
+		Expect(err).To(BeNil())
+		Expect(*enrichment.EnrichmentID).To(Equal("e1"))
+		Expect(uploaded).To(Equal("lemma,poscode,surface\nWatson,3,Watson\n"))
+		Expect(updates).To(Equal([]string{
+			`/v2/projects/p1/collections/c1 {"enrichments":[{"enrichment_id":"e0","fields":["text"]},{"enrichment_id":"e1","fields":["text"]}]}`,
+			`/v2/projects/p1/collections/c2 {"enrichments":[{"enrichment_id":"e1","fields":["text"]}]}`,
+		}))
+
+		Expect(service.AttachEnrichment(context.Background(), "p1", "e1", []string{"text"}, "c3")).ToNot(Succeed())
+		_, err = service.CreateAndAttachEnrichment(context.Background(), "p1", discoveryv2.NewDictionaryEnrichment("empty", "product", "en"), []string{"text"}, "c1")
+		Expect(err).To(MatchError(`dictionary "empty" has no terms`))
+	})
+})
@@ -0,0 +1,127 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+func unmarshalTestQueryResponse(data string) *discoveryv2.QueryResponse {
+	var raw map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+	var response *discoveryv2.QueryResponse
+	Expect(core.UnmarshalModel(raw, "", &response, discoveryv2.UnmarshalQueryResponse)).To(Succeed())
+	return response
+}
+
+var _ = Describe(`Facets`, func() {
+	response := unmarshalTestQueryResponse(`{"matching_results": 10, "aggregations": [
+		{"type": "nested", "path": "enriched_text.entities", "matching_results": 8, "aggregations": [
+			{"type": "term", "field": "enriched_text.entities.text", "name": "companies", "results": [
+				{"key": "IBM", "matching_results": 5, "aggregations": [
+					{"type": "max", "field": "price", "value": 12.5},
+					{"type": "top_hits", "size": 1, "hits": {"matching_results": 5, "hits": [{"document_id": "d1"}]}}
+				]},
+				{"key": "Acme", "matching_results": 2}
+			]}
+		]},
+		{"type": "timeslice", "field": "date", "interval": "1day", "results": [
+			{"key_as_string": "2022-01-01", "key": 1640995200000, "matching_results": 3}
+		]},
+		{"type": "histogram", "field": "price", "interval": 10, "results": [{"key": 10, "matching_results": 4}]},
+		{"type": "filter", "match": "year>2020", "matching_results": 6},
+		{"type": "term", "field": "author", "results": []},
+		{"type": "term", "field": "author", "results": [{"key": "Poe", "matching_results": 1}]},
+		{"type": "group_by", "results": [{"key": "g1", "matching_results": 7, "relevancy": 1.5}]}
+	]}`)
+	facets := response.Facets()
+
+	It(`Indexes facets by path`, func() {
+		Expect(facets.Roots).To(HaveLen(7))
+		nested := facets.Get("nested(enriched_text.entities)")
+		Expect(*nested.MatchingResults).To(BeEquivalentTo(8))
+		Expect(nested.Children).To(HaveLen(1))
+
+		companies := facets.Get("nested(enriched_text.entities)/companies")
+		Expect(companies.Field).To(Equal("enriched_text.entities.text"))
+		Expect(companies.Counts()).To(Equal(map[string]int64{"IBM": 5, "Acme": 2}))
+		Expect(companies.Bucket("Acme").MatchingResults).To(BeEquivalentTo(2))
+		Expect(companies.Bucket("missing")).To(BeNil())
+
+		max := facets.Get("nested(enriched_text.entities)/companies/IBM/max(price)")
+		Expect(*max.Value).To(Equal(12.5))
+		Expect(companies.Bucket("IBM").Children[0]).To(BeIdenticalTo(max))
+		hits := facets.Get("nested(enriched_text.entities)/companies/IBM/top_hits")
+		Expect(hits.Hits).To(Equal([]map[string]interface{}{{"document_id": "d1"}}))
+
+		timeslice := facets.Get("timeslice(date)")
+		Expect(timeslice.Buckets[0].Key).To(Equal("2022-01-01"))
+		Expect(*timeslice.Buckets[0].NumericKey).To(BeEquivalentTo(1640995200000))
+		Expect(facets.Get("histogram(price)").Buckets[0].Key).To(Equal("10"))
+		Expect(*facets.Get("filter(year>2020)").MatchingResults).To(BeEquivalentTo(6))
+		Expect(facets.Get("term(author)#2").Counts()).To(Equal(map[string]int64{"Poe": 1}))
+		Expect(*facets.Get("group_by").Buckets[0].Relevancy).To(Equal(1.5))
+	})
+	It(`Walks facets in order`, func() {
+		var paths []string
+		Expect(facets.Walk(func(facet *discoveryv2.Facet) error {
+			paths = append(paths, facet.Path)
+			return nil
+		})).To(Succeed())
+		Expect(paths).To(Equal([]string{
+			"nested(enriched_text.entities)",
+			"nested(enriched_text.entities)/companies",
+			"nested(enriched_text.entities)/companies/IBM/max(price)",
+			"nested(enriched_text.entities)/companies/IBM/top_hits",
+			"timeslice(date)",
+			"histogram(price)",
+			"filter(year>2020)",
+			"term(author)",
+			"term(author)#2",
+			"group_by",
+		}))
+		Expect(facets.Named("companies")).To(HaveLen(1))
+
+		stop := errors.New("stop")
+		count := 0
+		Expect(facets.Walk(func(facet *discoveryv2.Facet) error {
+			count++
+			return stop
+		})).To(Equal(stop))
+		Expect(count).To(Equal(1))
+	})
+	It(`Escapes the separator in names and keys`, func() {
+		escaped := unmarshalTestQueryResponse(`{"matching_results": 2, "aggregations": [
+			{"type": "term", "field": "protocol", "name": "a/b", "results": [
+				{"key": "TCP/IP", "matching_results": 2, "aggregations": [{"type": "max", "field": "port", "value": 80}]},
+				{"key": "100%", "matching_results": 1}
+			]}
+		]}`).Facets()
+		terms := escaped.Get(discoveryv2.FacetPath("a/b"))
+		Expect(terms.Path).To(Equal("a%2Fb"))
+		Expect(terms.Bucket("TCP/IP").Path).To(Equal("a%2Fb/TCP%2FIP"))
+		Expect(terms.Bucket("100%").Path).To(Equal("a%2Fb/100%25"))
+		Expect(*escaped.Get(discoveryv2.FacetPath("a/b", "TCP/IP", "max(port)")).Value).To(Equal(80.0))
+		Expect(escaped.Get("a/b/TCP/IP/max(port)")).To(BeNil())
+	})
+})
@@ -0,0 +1,323 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// Constants associated with the FederatedQuery.Normalization property.
+const (
+	// The confidences returned by each source are compared as they are.
+	FederatedNormalizationNoneConst = "none"
+	// Confidences are divided by the highest confidence of their source.
+	FederatedNormalizationMaxConst = "max"
+	// Confidences are scaled so the lowest of their source is 0 and the highest is 1.
+	FederatedNormalizationMinMaxConst = "min_max"
+)
+
+// DefaultFederatedCount is the number of merged results returned when the query options do not set Count.
+const DefaultFederatedCount = 10
+
+// FederatedSource : A project queried by a FederatedQuery.
+type FederatedSource struct {
+	// Identifies the source in FederatedQueryResponse.ResultSources and errors. Defaults to the project ID.
+	Name string
+
+	Discovery *DiscoveryV2
+	ProjectID string
+
+	// The collections to query. If empty, the collection IDs of the query options are used.
+	CollectionIds []string
+
+	// Multiplies the normalized confidences of the source. Defaults to 1.
+	Weight float64
+}
+
+// FederatedSourceError : The error of a source that could not be queried.
+type FederatedSourceError struct {
+	Source     string
+	StatusCode int
+	Err        error
+}
+
+func (err *FederatedSourceError) Error() string {
+	return err.Source + ": " + err.Err.Error()
+}
+
+// FederatedQueryResponse : The merged response of a FederatedQuery.
+type FederatedQueryResponse struct {
+	// The merged response. MatchingResults is the sum over the sources, which counts documents found by several sources
+	// more than once. Results are ordered by normalized confidence, which replaces the confidence of each result.
+	// Aggregations holds the combined term aggregations.
+	*QueryResponse
+
+	// The name of the source of each result.
+	ResultSources []string
+
+	// The response of each source that answered, by name.
+	Responses map[string]*QueryResponse
+
+	// The sources that failed.
+	Errors []*FederatedSourceError
+}
+
+// FederatedQuery : Runs the same query concurrently against several projects, possibly in different service instances,
+// and merges the responses. Documents with the same ID are returned once, from the source that ranks them highest.
+type FederatedQuery struct {
+	Sources []FederatedSource
+
+	// FederatedNormalizationNoneConst, FederatedNormalizationMaxConst or FederatedNormalizationMinMaxConst. Defaults to
+	// FederatedNormalizationMaxConst.
+	Normalization string
+}
+
+// NewFederatedQuery : Instantiate FederatedQuery
+func NewFederatedQuery(sources ...FederatedSource) *FederatedQuery {
+	return &FederatedQuery{Sources: sources, Normalization: FederatedNormalizationMaxConst}
+}
+
+// Query queries every source with a copy of queryOptions in which the project and collections are those of the source.
+// Every source returns the first Offset+Count results, which are merged before Offset and Count are applied. The
+// merged response is returned with the errors of the sources that failed; the error is only set when every source
+// failed.
+func (federated *FederatedQuery) Query(ctx context.Context, queryOptions *QueryOptions) (*FederatedQueryResponse, error) {
+	count := int64(DefaultFederatedCount)
+	if queryOptions.Count != nil {
+		count = *queryOptions.Count
+	}
+	var offset int64
+	if queryOptions.Offset != nil {
+		offset = *queryOptions.Offset
+	}
+
+	responses := make([]*QueryResponse, len(federated.Sources))
+	errs := make([]*FederatedSourceError, len(federated.Sources))
+	var wg sync.WaitGroup
+	for i := range federated.Sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			source := federated.Sources[i]
+			options := *queryOptions
+			options.ProjectID = core.StringPtr(source.ProjectID)
+			if len(source.CollectionIds) > 0 {
+				options.CollectionIds = source.CollectionIds
+			}
+			options.Count = core.Int64Ptr(offset + count)
+			options.Offset = nil
+			result, response, err := source.Discovery.QueryWithContext(ctx, &options)
+			if err != nil {
+				errs[i] = &FederatedSourceError{Source: federatedSourceName(source), Err: err}
+				if response != nil {
+					errs[i].StatusCode = response.StatusCode
+				}
+				return
+			}
+			responses[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	merged := &FederatedQueryResponse{QueryResponse: &QueryResponse{}, Responses: make(map[string]*QueryResponse)}
+	type candidate struct {
+		result QueryResult
+		source string
+		score  float64
+	}
+	var candidates []*candidate
+	byDocument := make(map[string]*candidate)
+	var aggregations [][]QueryAggregationIntf
+	var matchingResults int64
+	for i, response := range responses {
+		if response == nil {
+			merged.Errors = append(merged.Errors, errs[i])
+			continue
+		}
+		source := federated.Sources[i]
+		name := federatedSourceName(source)
+		merged.Responses[name] = response
+		if response.MatchingResults != nil {
+			matchingResults += *response.MatchingResults
+		}
+		aggregations = append(aggregations, response.Aggregations)
+
+		scores := federated.normalize(response.Results)
+		weight := source.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		for j, result := range response.Results {
+			c := &candidate{result: result, source: name, score: scores[j] * weight}
+			if result.DocumentID != nil {
+				if existing, ok := byDocument[*result.DocumentID]; ok {
+					if c.score > existing.score {
+						*existing = *c
+					}
+					continue
+				}
+				byDocument[*result.DocumentID] = c
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	if len(merged.Errors) == len(federated.Sources) && len(federated.Sources) > 0 {
+		return merged, fmt.Errorf("every source failed, first: %s", merged.Errors[0].Error())
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if offset > int64(len(candidates)) {
+		offset = int64(len(candidates))
+	}
+	candidates = candidates[offset:]
+	if count < int64(len(candidates)) {
+		candidates = candidates[:count]
+	}
+	for _, c := range candidates {
+		result := c.result
+		metadata := QueryResultMetadata{}
+		if result.ResultMetadata != nil {
+			metadata = *result.ResultMetadata
+		}
+		metadata.Confidence = core.Float64Ptr(c.score)
+		result.ResultMetadata = &metadata
+		merged.Results = append(merged.Results, result)
+		merged.ResultSources = append(merged.ResultSources, c.source)
+	}
+	merged.MatchingResults = core.Int64Ptr(matchingResults)
+	merged.Aggregations = mergeTermAggregations(aggregations)
+	return merged, nil
+}
+
+func federatedSourceName(source FederatedSource) string {
+	if source.Name != "" {
+		return source.Name
+	}
+	return source.ProjectID
+}
+
+// normalize returns the normalized confidences of the results of one source.
+func (federated *FederatedQuery) normalize(results []QueryResult) []float64 {
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		if result.ResultMetadata != nil && result.ResultMetadata.Confidence != nil {
+			scores[i] = *result.ResultMetadata.Confidence
+		}
+	}
+	if len(scores) == 0 || federated.Normalization == FederatedNormalizationNoneConst {
+		return scores
+	}
+	low, high := scores[0], scores[0]
+	for _, score := range scores {
+		if score < low {
+			low = score
+		}
+		if score > high {
+			high = score
+		}
+	}
+	for i, score := range scores {
+		switch {
+		case federated.Normalization == FederatedNormalizationMinMaxConst && high > low:
+			scores[i] = (score - low) / (high - low)
+		case federated.Normalization == FederatedNormalizationMinMaxConst:
+			scores[i] = 1
+		case high > 0:
+			scores[i] = score / high
+		}
+	}
+	return scores
+}
+
+// mergeTermAggregations combines the term aggregations of several responses. Term aggregations with the same field and
+// name are merged by adding the matching results of buckets with the same key, and the sub-aggregations of the buckets
+// are merged in the same way. Other aggregations cannot be combined and are left out.
+func mergeTermAggregations(lists [][]QueryAggregationIntf) []QueryAggregationIntf {
+	type termBucket struct {
+		result       QueryTermAggregationResult
+		aggregations [][]QueryAggregationIntf
+	}
+	type termGroup struct {
+		aggregation *QueryTermAggregation
+		buckets     []*termBucket
+		byKey       map[string]*termBucket
+	}
+	var groups []*termGroup
+	byID := make(map[string]*termGroup)
+	for _, list := range lists {
+		for _, aggregation := range list {
+			term, ok := aggregation.(*QueryTermAggregation)
+			if !ok {
+				continue
+			}
+			id := strings.Join([]string{stringValue(term.Field), stringValue(term.Name)}, "\x00")
+			group, ok := byID[id]
+			if !ok {
+				group = &termGroup{aggregation: &QueryTermAggregation{Type: term.Type, Field: term.Field, Name: term.Name}, byKey: make(map[string]*termBucket)}
+				groups = append(groups, group)
+				byID[id] = group
+			}
+			if term.Count != nil && (group.aggregation.Count == nil || *term.Count > *group.aggregation.Count) {
+				group.aggregation.Count = term.Count
+			}
+			for _, result := range term.Results {
+				key := stringValue(result.Key)
+				bucket, ok := group.byKey[key]
+				if !ok {
+					bucket = &termBucket{result: QueryTermAggregationResult{Key: result.Key, MatchingResults: core.Int64Ptr(0)}}
+					group.buckets = append(group.buckets, bucket)
+					group.byKey[key] = bucket
+				}
+				*bucket.result.MatchingResults += int64Value(result.MatchingResults)
+				bucket.result.TotalMatchingDocuments = addInt64(bucket.result.TotalMatchingDocuments, result.TotalMatchingDocuments)
+				bucket.result.EstimatedMatchingDocuments = addInt64(bucket.result.EstimatedMatchingDocuments, result.EstimatedMatchingDocuments)
+				bucket.aggregations = append(bucket.aggregations, result.Aggregations)
+			}
+		}
+	}
+
+	var merged []QueryAggregationIntf
+	for _, group := range groups {
+		sort.SliceStable(group.buckets, func(i, j int) bool {
+			return *group.buckets[i].result.MatchingResults > *group.buckets[j].result.MatchingResults
+		})
+		if count := group.aggregation.Count; count != nil && int64(len(group.buckets)) > *count {
+			group.buckets = group.buckets[:*count]
+		}
+		for _, bucket := range group.buckets {
+			bucket.result.Aggregations = mergeTermAggregations(bucket.aggregations)
+			group.aggregation.Results = append(group.aggregation.Results, bucket.result)
+		}
+		merged = append(merged, group.aggregation)
+	}
+	return merged
+}
+
+func addInt64(sum *int64, i *int64) *int64 {
+	if i == nil {
+		return sum
+	}
+	return core.Int64Ptr(int64Value(sum) + *i)
+}
@@ -0,0 +1,147 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`FederatedQuery`, func() {
+	var servers []*httptest.Server
+	var counts map[string]float64
+	var mutex sync.Mutex
+
+	newService := func(responses map[string]string) *discoveryv2.DiscoveryV2 {
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			var body map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			Expect(body["query"]).To(Equal("watson"))
+			Expect(body).ToNot(HaveKey("offset"))
+			mutex.Lock()
+			counts[req.URL.Path] = body["count"].(float64)
+			mutex.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			response, ok := responses[req.URL.Path]
+			if !ok {
+				res.WriteHeader(404)
+				fmt.Fprint(res, `{"error": "Project not found", "code": 404}`)
+				return
+			}
+			fmt.Fprint(res, response)
+		}))
+		servers = append(servers, server)
+		service, err := discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           server.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		return service
+	}
+
+	var federated *discoveryv2.FederatedQuery
+
+	BeforeEach(func() {
+		servers = nil
+		counts = make(map[string]float64)
+		first := newService(map[string]string{
+			"/v2/projects/p1/query": `{"matching_results": 3, "results": [
+				{"document_id": "a", "result_metadata": {"collection_id": "c1", "confidence": 0.8}},
+				{"document_id": "shared", "result_metadata": {"collection_id": "c1", "confidence": 0.4}},
+				{"document_id": "b", "result_metadata": {"collection_id": "c1", "confidence": 0.2}}],
+				"aggregations": [
+					{"type": "term", "field": "author", "count": 2, "results": [
+						{"key": "Poe", "matching_results": 2, "aggregations": [{"type": "term", "field": "year", "results": [{"key": "1845", "matching_results": 2}]}]},
+						{"key": "Twain", "matching_results": 1}]},
+					{"type": "max", "field": "year", "value": 1900}]}`,
+		})
+		second := newService(map[string]string{
+			"/v2/projects/p2/query": `{"matching_results": 2, "results": [
+				{"document_id": "shared", "result_metadata": {"collection_id": "c2", "confidence": 0.1}},
+				{"document_id": "c", "result_metadata": {"collection_id": "c2", "confidence": 0.05}}],
+				"aggregations": [
+					{"type": "term", "field": "author", "results": [
+						{"key": "Twain", "matching_results": 4},
+						{"key": "Poe", "matching_results": 1, "aggregations": [{"type": "term", "field": "year", "results": [{"key": "1845", "matching_results": 1}]}]}]}]}`,
+		})
+		federated = discoveryv2.NewFederatedQuery(
+			discoveryv2.FederatedSource{Name: "first", Discovery: first, ProjectID: "p1"},
+			discoveryv2.FederatedSource{Discovery: second, ProjectID: "p2"},
+			discoveryv2.FederatedSource{Name: "missing", Discovery: second, ProjectID: "p3"},
+		)
+	})
+	AfterEach(func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	})
+
+	It(`Merges normalized results and term aggregations and reports failed sources`, func() {
+		response, err := federated.Query(context.Background(), (&discoveryv2.DiscoveryV2{}).NewQueryOptions("").SetQuery("watson").SetCount(4))
+		Expect(err).To(BeNil())
+		Expect(counts).To(HaveKeyWithValue("/v2/projects/p1/query", 4.0))
+
+		var ids []string
+		var confidences []float64
+		for _, result := range response.Results {
+			ids = append(ids, *result.DocumentID)
+			confidences = append(confidences, *result.ResultMetadata.Confidence)
+		}
+		Expect(ids).To(Equal([]string{"a", "shared", "c", "b"}))
+		Expect(confidences).To(Equal([]float64{1, 1, 0.5, 0.25}))
+		Expect(response.ResultSources).To(Equal([]string{"first", "p2", "p2", "first"}))
+		Expect(*response.MatchingResults).To(Equal(int64(5)))
+		Expect(response.Responses).To(HaveLen(2))
+
+		Expect(response.Errors).To(HaveLen(1))
+		Expect(response.Errors[0].Source).To(Equal("missing"))
+		Expect(response.Errors[0].StatusCode).To(Equal(404))
+		Expect(response.Errors[0].Error()).To(Equal("missing: Project not found"))
+
+		Expect(response.Aggregations).To(HaveLen(1))
+		author := response.Facets().Get("term(author)")
+		Expect(author.Counts()).To(Equal(map[string]int64{"Twain": 5, "Poe": 3}))
+		Expect(author.Buckets[0].Key).To(Equal("Twain"))
+		Expect(response.Facets().Get("term(author)/Poe/term(year)").Counts()).To(Equal(map[string]int64{"1845": 3}))
+	})
+	It(`Pages merged results without normalization`, func() {
+		federated.Normalization = discoveryv2.FederatedNormalizationNoneConst
+		federated.Sources[1].Weight = 10
+		response, err := federated.Query(context.Background(), (&discoveryv2.DiscoveryV2{}).NewQueryOptions("").SetQuery("watson").SetCount(2).SetOffset(1))
+		Expect(err).To(BeNil())
+		Expect(counts).To(HaveKeyWithValue("/v2/projects/p2/query", 3.0))
+		Expect(response.Results).To(HaveLen(2))
+		Expect(*response.Results[0].DocumentID).To(Equal("a"))
+		Expect(*response.Results[1].DocumentID).To(Equal("c"))
+	})
+	It(`Fails when every source fails`, func() {
+		federated.Sources = federated.Sources[2:]
+		_, err := federated.Query(context.Background(), (&discoveryv2.DiscoveryV2{}).NewQueryOptions("").SetQuery("watson"))
+		Expect(err).To(MatchError("every source failed, first: missing: Project not found"))
+	})
+})
@@ -0,0 +1,301 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Constants associated with the PassageRenderer.Format property.
+const (
+	PassageFormatHTMLConst = "html"
+	PassageFormatANSIConst = "ansi"
+)
+
+// The escape sequences of ANSI terminal highlights.
+const (
+	ANSIMatchStyle  = "\x1b[1m"
+	ANSIAnswerStyle = "\x1b[1;4;33m"
+	ANSIResetStyle  = "\x1b[0m"
+)
+
+// PassageSpan : A highlighted range of a text, in characters.
+type PassageSpan struct {
+	Begin int
+	End   int
+
+	// Whether the span is an answer rather than a match of the query.
+	Answer bool
+}
+
+// MergePassageSpans sorts spans and merges those that overlap or touch. A merged span is an answer if any of its spans
+// is. Empty spans are dropped.
+func MergePassageSpans(spans []PassageSpan) []PassageSpan {
+	sorted := make([]PassageSpan, 0, len(spans))
+	for _, span := range spans {
+		if span.End > span.Begin {
+			sorted = append(sorted, span)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Begin < sorted[j].Begin
+	})
+	var merged []PassageSpan
+	for _, span := range sorted {
+		if n := len(merged); n > 0 && span.Begin <= merged[n-1].End {
+			last := &merged[n-1]
+			if span.End > last.End {
+				last.End = span.End
+			}
+			last.Answer = last.Answer || span.Answer
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// ParseHighlightMarkup removes the <em> tags with which the service marks query matches in passages and highlights,
+// and returns the text with the marked spans. Any other markup is kept as text, so that it is escaped when rendered.
+func ParseHighlightMarkup(markup string) (string, []PassageSpan) {
+	var text []rune
+	var spans []PassageSpan
+	open := -1
+	for len(markup) > 0 {
+		lower := strings.ToLower(markup)
+		switch {
+		case strings.HasPrefix(lower, "<em>"):
+			if open < 0 {
+				open = len(text)
+			}
+			markup = markup[len("<em>"):]
+		case strings.HasPrefix(lower, "</em>"):
+			if open >= 0 {
+				spans = append(spans, PassageSpan{Begin: open, End: len(text)})
+				open = -1
+			}
+			markup = markup[len("</em>"):]
+		default:
+			i := strings.IndexByte(markup[1:], '<') + 1
+			if i == 0 {
+				i = len(markup)
+			}
+			text = append(text, []rune(markup[:i])...)
+			markup = markup[i:]
+		}
+	}
+	if open >= 0 {
+		spans = append(spans, PassageSpan{Begin: open, End: len(text)})
+	}
+	return string(text), spans
+}
+
+// PassageRenderer : Renders passages and highlights as HTML or for ANSI terminals. Texts are escaped, highlighted
+// spans are merged and passages can be shown within a window of the text of their field.
+type PassageRenderer struct {
+	// PassageFormatHTMLConst or PassageFormatANSIConst.
+	Format string
+
+	// The number of characters of the field text shown before and after a passage, extended to whole words.
+	ContextCharacters int
+
+	// Marks text left out before or after a passage. Defaults to "…".
+	Ellipsis string
+
+	// The class attributes of the mark elements of query matches and answers in HTML.
+	MatchClass  string
+	AnswerClass string
+}
+
+// NewPassageRenderer : Instantiate PassageRenderer
+func NewPassageRenderer(format string) *PassageRenderer {
+	return &PassageRenderer{Format: format, Ellipsis: "…", AnswerClass: "answer"}
+}
+
+// Render renders a text with highlighted spans, given in characters.
+func (renderer *PassageRenderer) Render(text string, spans []PassageSpan) string {
+	runes := []rune(text)
+	var builder strings.Builder
+	position := 0
+	for _, span := range MergePassageSpans(spans) {
+		if span.Begin < position {
+			span.Begin = position
+		}
+		if span.End > len(runes) {
+			span.End = len(runes)
+		}
+		if span.Begin >= span.End {
+			continue
+		}
+		builder.WriteString(renderer.escape(string(runes[position:span.Begin])))
+		builder.WriteString(renderer.open(span.Answer))
+		builder.WriteString(renderer.escape(string(runes[span.Begin:span.End])))
+		builder.WriteString(renderer.close())
+		position = span.End
+	}
+	if position < len(runes) {
+		builder.WriteString(renderer.escape(string(runes[position:])))
+	}
+	return builder.String()
+}
+
+func (renderer *PassageRenderer) escape(s string) string {
+	if renderer.Format == PassageFormatANSIConst {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\n' && r != '\t' {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	return html.EscapeString(s)
+}
+
+func (renderer *PassageRenderer) open(answer bool) string {
+	if renderer.Format == PassageFormatANSIConst {
+		if answer {
+			return ANSIAnswerStyle
+		}
+		return ANSIMatchStyle
+	}
+	class := renderer.MatchClass
+	if answer {
+		class = renderer.AnswerClass
+	}
+	if class == "" {
+		return "<mark>"
+	}
+	return `<mark class="` + html.EscapeString(class) + `">`
+}
+
+func (renderer *PassageRenderer) close() string {
+	if renderer.Format == PassageFormatANSIConst {
+		return ANSIResetStyle
+	}
+	return "</mark>"
+}
+
+// RenderPassage renders the text of a passage with its query matches and answers highlighted. When the text of the
+// field the passage was extracted from is given, the passage is shown with ContextCharacters of it on either side.
+func (renderer *PassageRenderer) RenderPassage(passageText string, startOffset int64, answers []ResultPassageAnswer, fieldText string) string {
+	text, spans := ParseHighlightMarkup(passageText)
+	begin, end := int(startOffset), int(startOffset)+len([]rune(text))
+	field := []rune(fieldText)
+	if begin < 0 || end > len(field) || string(field[begin:end]) != text {
+		// Without the field text, the passage is its own window.
+		field = []rune(text)
+		begin, end = 0, len(field)
+	}
+	for i := range spans {
+		spans[i].Begin += begin
+		spans[i].End += begin
+	}
+	for _, answer := range answers {
+		if answer.StartOffset == nil || answer.EndOffset == nil {
+			continue
+		}
+		shift := begin - int(startOffset)
+		spans = append(spans, PassageSpan{Begin: int(*answer.StartOffset) + shift, End: int(*answer.EndOffset) + shift, Answer: true})
+	}
+
+	windowBegin, windowEnd := begin, end
+	if len(field) > end-begin && renderer.ContextCharacters > 0 {
+		windowBegin = begin - renderer.ContextCharacters
+		if windowBegin <= 0 {
+			windowBegin = 0
+		} else {
+			for windowBegin < begin && !unicode.IsSpace(field[windowBegin-1]) {
+				windowBegin++
+			}
+		}
+		windowEnd = end + renderer.ContextCharacters
+		if windowEnd >= len(field) {
+			windowEnd = len(field)
+		} else {
+			for windowEnd > end && !unicode.IsSpace(field[windowEnd]) {
+				windowEnd--
+			}
+		}
+	}
+
+	var windowSpans []PassageSpan
+	for _, span := range spans {
+		span.Begin -= windowBegin
+		span.End -= windowBegin
+		if span.Begin < 0 {
+			span.Begin = 0
+		}
+		windowSpans = append(windowSpans, span)
+	}
+	rendered := renderer.Render(string(field[windowBegin:windowEnd]), windowSpans)
+	if windowBegin > 0 {
+		rendered = renderer.escape(renderer.Ellipsis) + rendered
+	}
+	if windowEnd < len(field) {
+		rendered += renderer.escape(renderer.Ellipsis)
+	}
+	return rendered
+}
+
+// RenderResultPassage renders a passage of a query result. The field text is optional, see RenderPassage.
+func (renderer *PassageRenderer) RenderResultPassage(passage *QueryResultPassage, fieldText string) string {
+	return renderer.RenderPassage(stringValue(passage.PassageText), int64Value(passage.StartOffset), passage.Answers, fieldText)
+}
+
+// RenderResponsePassage renders a passage of a query response. The field text is optional, see RenderPassage.
+func (renderer *PassageRenderer) RenderResponsePassage(passage *QueryResponsePassage, fieldText string) string {
+	return renderer.RenderPassage(stringValue(passage.PassageText), int64Value(passage.StartOffset), passage.Answers, fieldText)
+}
+
+// RenderDocumentPassages renders the passages of a query result, using the text of their fields when the result
+// returns it.
+func (renderer *PassageRenderer) RenderDocumentPassages(result *QueryResult) []string {
+	rendered := make([]string, len(result.DocumentPassages))
+	for i := range result.DocumentPassages {
+		passage := &result.DocumentPassages[i]
+		fieldText := ""
+		switch value := result.GetProperty(stringValue(passage.Field)).(type) {
+		case string:
+			fieldText = value
+		case []interface{}:
+			if len(value) == 1 {
+				fieldText, _ = value[0].(string)
+			}
+		}
+		rendered[i] = renderer.RenderResultPassage(passage, fieldText)
+	}
+	return rendered
+}
+
+// RenderHighlights renders the highlights of a query result, which are returned by field when the query sets
+// Highlight.
+func (renderer *PassageRenderer) RenderHighlights(result *QueryResult) map[string][]string {
+	highlights, _ := result.GetProperty("highlight").(map[string]interface{})
+	rendered := make(map[string][]string, len(highlights))
+	for field, fragments := range highlights {
+		list, _ := fragments.([]interface{})
+		for _, fragment := range list {
+			if s, ok := fragment.(string); ok {
+				rendered[field] = append(rendered[field], renderer.Render(ParseHighlightMarkup(s)))
+			}
+		}
+	}
+	return rendered
+}
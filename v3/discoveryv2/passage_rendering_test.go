@@ -0,0 +1,94 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`PassageRenderer`, func() {
+	const field = `Intro text. To reset the <router>, hold the button for 10 seconds. Then wait for the light.`
+
+	It(`Merges spans and parses highlight markup`, func() {
+		Expect(discoveryv2.MergePassageSpans([]discoveryv2.PassageSpan{
+			{Begin: 8, End: 12},
+			{Begin: 0, End: 3},
+			{Begin: 2, End: 5, Answer: true},
+			{Begin: 5, End: 6},
+			{Begin: 7, End: 7},
+		})).To(Equal([]discoveryv2.PassageSpan{{Begin: 0, End: 6, Answer: true}, {Begin: 8, End: 12}}))
+
+		text, spans := discoveryv2.ParseHighlightMarkup(`a <EM>b</EM> <script>c</script> <em>d`)
+		Expect(text).To(Equal(`a b <script>c</script> d`))
+		Expect(spans).To(Equal([]discoveryv2.PassageSpan{{Begin: 2, End: 3}, {Begin: 23, End: 24}}))
+	})
+	It(`Renders passages with answers and context as safe HTML`, func() {
+		renderer := discoveryv2.NewPassageRenderer(discoveryv2.PassageFormatHTMLConst)
+		passage := &discoveryv2.QueryResultPassage{
+			PassageText: core.StringPtr(`To <em>reset</em> the <router>, hold the button`),
+			StartOffset: core.Int64Ptr(12),
+			EndOffset:   core.Int64Ptr(48),
+			Field:       core.StringPtr("text"),
+			Answers: []discoveryv2.ResultPassageAnswer{
+				{AnswerText: core.StringPtr("hold the button"), StartOffset: core.Int64Ptr(35), EndOffset: core.Int64Ptr(50)},
+				{AnswerText: core.StringPtr("the button"), StartOffset: core.Int64Ptr(40), EndOffset: core.Int64Ptr(50)},
+			},
+		}
+		Expect(renderer.RenderResultPassage(passage, "")).
+			To(Equal(`To <mark>reset</mark> the &lt;router&gt;, <mark class="answer">hold the button</mark>`))
+
+		renderer.ContextCharacters = 9
+		Expect(renderer.RenderResultPassage(passage, field)).
+			To(Equal(`…text. To <mark>reset</mark> the &lt;router&gt;, <mark class="answer">hold the button</mark> for 10…`))
+
+		renderer.ContextCharacters = 100
+		renderer.MatchClass = "match"
+		Expect(renderer.RenderResultPassage(passage, field)).To(HavePrefix(`Intro text. To <mark class="match">reset</mark>`))
+		Expect(renderer.RenderResultPassage(passage, field)).To(HaveSuffix(`wait for the light.`))
+	})
+	It(`Renders ANSI snippets without control characters`, func() {
+		renderer := discoveryv2.NewPassageRenderer(discoveryv2.PassageFormatANSIConst)
+		passage := &discoveryv2.QueryResponsePassage{
+			PassageText: core.StringPtr("<em>reset</em>\x1b[31m now"),
+			StartOffset: core.Int64Ptr(100),
+			Answers:     []discoveryv2.ResultPassageAnswer{{StartOffset: core.Int64Ptr(111), EndOffset: core.Int64Ptr(113)}},
+		}
+		Expect(renderer.RenderResponsePassage(passage, "")).
+			To(Equal("\x1b[1mreset\x1b[0m[31m " + "\x1b[1;4;33mno\x1b[0mw"))
+	})
+	It(`Renders document passages and highlights of a result`, func() {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(`{
+			"document_id": "d1",
+			"result_metadata": {"collection_id": "c1"},
+			"text": ["`+field+`"],
+			"highlight": {"text": ["hold the <em>button</em> & wait"]},
+			"document_passages": [{"passage_text": "hold the <em>button</em>", "start_offset": 35, "end_offset": 50, "field": "text"}]
+		}`), &raw)).To(Succeed())
+		var result *discoveryv2.QueryResult
+		Expect(core.UnmarshalModel(raw, "", &result, discoveryv2.UnmarshalQueryResult)).To(Succeed())
+
+		renderer := discoveryv2.NewPassageRenderer(discoveryv2.PassageFormatHTMLConst)
+		Expect(renderer.RenderDocumentPassages(result)).To(Equal([]string{`…hold the <mark>button</mark>…`}))
+		Expect(renderer.RenderHighlights(result)).To(Equal(map[string][]string{"text": {`hold the <mark>button</mark> &amp; wait`}}))
+	})
+})
@@ -0,0 +1,162 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`QueryIterator`, func() {
+	var testServer *httptest.Server
+	var service *discoveryv2.DiscoveryV2
+	var pages []string
+	var failures int
+	var drops int
+	var matching int
+
+	BeforeEach(func() {
+		pages = nil
+		failures = 0
+		drops = 0
+		matching = 5
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			var body struct {
+				Query  string `json:"query"`
+				Count  int    `json:"count"`
+				Offset int    `json:"offset"`
+			}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			Expect(body.Query).To(Equal("watson"))
+			if drops > 0 {
+				drops--
+				conn, _, err := res.(http.Hijacker).Hijack()
+				Expect(err).To(BeNil())
+				conn.Close()
+				return
+			}
+			res.Header().Set("Content-type", "application/json")
+			if failures > 0 {
+				failures--
+				res.Header().Set("Retry-After", "0")
+				res.WriteHeader(503)
+				fmt.Fprint(res, `{"error": "Service unavailable", "code": 503}`)
+				return
+			}
+			pages = append(pages, fmt.Sprintf("%d+%d", body.Offset, body.Count))
+			var results []string
+			for i := body.Offset; i < body.Offset+body.Count && i < matching; i++ {
+				results = append(results, fmt.Sprintf(`{"document_id": "d%d", "result_metadata": {"collection_id": "c1", "confidence": 0.5}}`, i))
+			}
+			fmt.Fprintf(res, `{"matching_results": %d, "results": [%s]}`, matching, strings.Join(results, ","))
+		}))
+		var err error
+		service, err = discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Pages until the results are exhausted and retries transient errors`, func() {
+		failures = 2
+		options := service.NewQueryOptions("p1").SetQuery("watson")
+		iterator := service.NewQueryIterator(options)
+		iterator.PageSize = 2
+		iterator.InitialBackoff = time.Millisecond
+
+		var ids []string
+		for iterator.Next(context.Background()) {
+			ids = append(ids, *iterator.Result().DocumentID)
+		}
+		Expect(iterator.Err()).To(BeNil())
+		Expect(ids).To(Equal([]string{"d0", "d1", "d2", "d3", "d4"}))
+		Expect(pages).To(Equal([]string{"0+2", "2+2", "4+2"}))
+		Expect(*iterator.Response().MatchingResults).To(Equal(int64(5)))
+		Expect(iterator.Next(context.Background())).To(BeFalse())
+		Expect(options.Offset).To(BeNil())
+	})
+	It(`Stops at the maximum number of results and streams NDJSON`, func() {
+		iterator := service.NewQueryIterator(service.NewQueryOptions("p1").SetQuery("watson").SetOffset(1))
+		iterator.PageSize = 2
+		iterator.MaxResults = 3
+
+		var buffer bytes.Buffer
+		written, err := iterator.WriteNDJSON(context.Background(), &buffer)
+		Expect(err).To(BeNil())
+		Expect(written).To(Equal(int64(3)))
+		Expect(pages).To(Equal([]string{"1+2", "3+1"}))
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[2]).To(MatchJSON(`{"document_id": "d3", "result_metadata": {"collection_id": "c1", "confidence": 0.5}}`))
+	})
+	It(`Reports errors after the retries`, func() {
+		failures = 3
+		iterator := service.NewQueryIterator(service.NewQueryOptions("p1").SetQuery("watson"))
+		iterator.MaxRetries = 1
+		iterator.InitialBackoff = time.Millisecond
+		Expect(iterator.Next(context.Background())).To(BeFalse())
+		Expect(iterator.Err()).To(MatchError("Service unavailable"))
+		Expect(iterator.Result()).To(BeNil())
+	})
+	It(`Retries network errors but not invalid options`, func() {
+		drops = 1
+		iterator := service.NewQueryIterator(service.NewQueryOptions("p1").SetQuery("watson"))
+		iterator.InitialBackoff = time.Millisecond
+		Expect(iterator.Next(context.Background())).To(BeTrue())
+		Expect(drops).To(BeZero())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		iterator = service.NewQueryIterator(service.NewQueryOptions("").SetQuery("watson"))
+		iterator.InitialBackoff = time.Hour
+		Expect(iterator.Next(ctx)).To(BeFalse())
+		Expect(iterator.Err()).ToNot(BeNil())
+		Expect(iterator.Err()).ToNot(Equal(context.DeadlineExceeded))
+		Expect(pages).To(HaveLen(1))
+	})
+	It(`Ends at the service's result window`, func() {
+		matching = 20000
+		iterator := service.NewQueryIterator(service.NewQueryOptions("p1").SetQuery("watson").SetOffset(9995))
+		iterator.PageSize = 4
+
+		count := 0
+		for iterator.Next(context.Background()) {
+			count++
+		}
+		Expect(iterator.Err()).To(BeNil())
+		Expect(count).To(Equal(5))
+		Expect(pages).To(Equal([]string{"9995+4", "9999+1"}))
+		Expect(iterator.Truncated()).To(BeTrue())
+	})
+})
@@ -0,0 +1,462 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// The Discovery Query Language builder renders query, filter and aggregation strings with all values escaped. The
+// rendered strings are plain Discovery Query Language, so they can also be used with discoveryv1, for example
+// discoveryv1.QueryOptions.SetFilter(expression.String()).
+
+// QueryDateFormat is the layout used for time.Time values in query expressions, which are converted to UTC first.
+const QueryDateFormat = "2006-01-02T15:04:05"
+
+// Discovery Query Language operators.
+const (
+	QueryOperatorContainsConst           = ":"
+	QueryOperatorNotContainsConst        = ":!"
+	QueryOperatorEqualsConst             = "::"
+	QueryOperatorNotEqualsConst          = "::!"
+	QueryOperatorGreaterThanConst        = ">"
+	QueryOperatorGreaterThanOrEqualConst = ">="
+	QueryOperatorLessThanConst           = "<"
+	QueryOperatorLessThanOrEqualConst    = "<="
+	QueryOperatorAndConst                = ","
+	QueryOperatorOrConst                 = "|"
+)
+
+// negatedQueryOperators maps each comparison operator to its opposite.
+var negatedQueryOperators = map[string]string{
+	QueryOperatorContainsConst:           QueryOperatorNotContainsConst,
+	QueryOperatorNotContainsConst:        QueryOperatorContainsConst,
+	QueryOperatorEqualsConst:             QueryOperatorNotEqualsConst,
+	QueryOperatorNotEqualsConst:          QueryOperatorEqualsConst,
+	QueryOperatorGreaterThanConst:        QueryOperatorLessThanOrEqualConst,
+	QueryOperatorGreaterThanOrEqualConst: QueryOperatorLessThanConst,
+	QueryOperatorLessThanConst:           QueryOperatorGreaterThanOrEqualConst,
+	QueryOperatorLessThanOrEqualConst:    QueryOperatorGreaterThanConst,
+}
+
+var queryValueEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, `,`, `\,`, `:`, `\:`, `!`, `\!`, `|`, `\|`, `(`, `\(`, `)`, `\)`,
+	`[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `~`, `\~`, `*`, `\*`, `^`, `\^`,
+)
+
+var queryPhraseEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// EscapeQueryValue escapes the special characters of the Discovery Query Language in a value.
+func EscapeQueryValue(value string) string {
+	return queryValueEscaper.Replace(value)
+}
+
+// QuoteQueryPhrase returns a value as a quoted phrase.
+func QuoteQueryPhrase(value string) string {
+	return `"` + queryPhraseEscaper.Replace(value) + `"`
+}
+
+// queryValue renders a value for a comparison; values that contain whitespace are quoted.
+func queryValue(value string) string {
+	if value == "" || strings.IndexFunc(value, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' }) >= 0 {
+		return QuoteQueryPhrase(value)
+	}
+	return EscapeQueryValue(value)
+}
+
+// queryRangeValue renders the bound of a range comparison.
+func queryRangeValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return queryValue(v)
+	case time.Time:
+		return v.UTC().Format(QueryDateFormat)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return queryValue(fmt.Sprint(v))
+	}
+}
+
+// QueryExpression : A Discovery Query Language expression, usable as the query or filter of a query.
+type QueryExpression interface {
+	// String renders the expression.
+	String() string
+
+	// Negate returns the expression with inverted operators. For documents that have the compared fields it matches
+	// exactly the documents this one does not; a range comparison and its negation both exclude documents that are
+	// missing the field.
+	Negate() QueryExpression
+}
+
+// QueryComparison : A comparison of a field with a value, such as title:watson or year>=2020.
+type QueryComparison struct {
+	Field    string
+	Operator string
+
+	// The rendered and escaped value, including any fuzziness or proximity suffix.
+	Value string
+
+	// The boost of the comparison when it is used in a query; 0 for none.
+	BoostFactor float64
+}
+
+// Boost sets the boost of the comparison, which increases the relevance of matching documents in a query.
+func (comparison *QueryComparison) Boost(factor float64) *QueryComparison {
+	comparison.BoostFactor = factor
+	return comparison
+}
+
+// String renders the comparison.
+func (comparison *QueryComparison) String() string {
+	s := comparison.Field + comparison.Operator + comparison.Value
+	if comparison.BoostFactor != 0 {
+		s += "^" + strconv.FormatFloat(comparison.BoostFactor, 'f', -1, 64)
+	}
+	return s
+}
+
+// Negate returns the comparison with the opposite operator.
+func (comparison *QueryComparison) Negate() QueryExpression {
+	negated := *comparison
+	negated.Operator = negatedQueryOperators[comparison.Operator]
+	return &negated
+}
+
+// QueryGroup : Expressions combined with AND or OR.
+type QueryGroup struct {
+	// QueryOperatorAndConst or QueryOperatorOrConst.
+	Operator    string
+	Expressions []QueryExpression
+}
+
+// QueryAnd matches documents that match all the expressions. Nil expressions are skipped.
+func QueryAnd(expressions ...QueryExpression) *QueryGroup {
+	return &QueryGroup{Operator: QueryOperatorAndConst, Expressions: expressions}
+}
+
+// QueryOr matches documents that match any of the expressions. Nil expressions are skipped.
+func QueryOr(expressions ...QueryExpression) *QueryGroup {
+	return &QueryGroup{Operator: QueryOperatorOrConst, Expressions: expressions}
+}
+
+// QueryNot matches documents that do not match the expression. The Discovery Query Language has no operator for
+// this, so the expression is negated: operators are inverted and AND and OR are swapped. Documents that are missing a
+// field compared with a range operator match neither the expression nor its negation; combine the negation with
+// QueryField.Missing to include them.
+func QueryNot(expression QueryExpression) QueryExpression {
+	return expression.Negate()
+}
+
+// Add appends expressions to the group.
+func (group *QueryGroup) Add(expressions ...QueryExpression) *QueryGroup {
+	group.Expressions = append(group.Expressions, expressions...)
+	return group
+}
+
+// String renders the group. Nested groups of more than one expression are put in parentheses.
+func (group *QueryGroup) String() string {
+	return strings.Join(group.parts(), group.Operator)
+}
+
+// parts renders the expressions of the group that are not empty.
+func (group *QueryGroup) parts() []string {
+	var parts []string
+	for _, expression := range group.Expressions {
+		if expression == nil {
+			continue
+		}
+		if nested, ok := expression.(*QueryGroup); ok {
+			nestedParts := nested.parts()
+			switch len(nestedParts) {
+			case 0:
+			case 1:
+				parts = append(parts, nestedParts[0])
+			default:
+				parts = append(parts, "("+strings.Join(nestedParts, nested.Operator)+")")
+			}
+			continue
+		}
+		if s := expression.String(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return parts
+}
+
+// Negate returns the group of negated expressions, with AND and OR swapped.
+func (group *QueryGroup) Negate() QueryExpression {
+	negated := &QueryGroup{Operator: QueryOperatorAndConst}
+	if group.Operator == QueryOperatorAndConst {
+		negated.Operator = QueryOperatorOrConst
+	}
+	for _, expression := range group.Expressions {
+		if expression != nil {
+			negated.Expressions = append(negated.Expressions, expression.Negate())
+		}
+	}
+	return negated
+}
+
+// QueryField : Builds comparisons on a field, such as title or enriched_text.entities.text.
+type QueryField struct {
+	Name string
+}
+
+// NewQueryField : Instantiate QueryField
+func NewQueryField(name string) *QueryField {
+	return &QueryField{Name: name}
+}
+
+func (field *QueryField) compare(operator string, value string) *QueryComparison {
+	return &QueryComparison{Field: field.Name, Operator: operator, Value: value}
+}
+
+// Contains matches documents whose field contains the value. Values with whitespace are matched as a phrase.
+func (field *QueryField) Contains(value string) *QueryComparison {
+	return field.compare(QueryOperatorContainsConst, queryValue(value))
+}
+
+// Equals matches documents whose field is exactly the value.
+func (field *QueryField) Equals(value string) *QueryComparison {
+	return field.compare(QueryOperatorEqualsConst, queryValue(value))
+}
+
+// Phrase matches documents whose field contains the words of the phrase in order.
+func (field *QueryField) Phrase(phrase string) *QueryComparison {
+	return field.compare(QueryOperatorContainsConst, QuoteQueryPhrase(phrase))
+}
+
+// Near matches documents whose field contains the words of the phrase within distance words of each other.
+func (field *QueryField) Near(phrase string, distance int) *QueryComparison {
+	return field.compare(QueryOperatorContainsConst, QuoteQueryPhrase(phrase)+"~"+strconv.Itoa(distance))
+}
+
+// Fuzzy matches documents whose field contains a word within the given edit distance of the value.
+func (field *QueryField) Fuzzy(value string, distance int) *QueryComparison {
+	return field.compare(QueryOperatorContainsConst, EscapeQueryValue(value)+"~"+strconv.Itoa(distance))
+}
+
+// Wildcard matches documents whose field contains a word matching the pattern, in which * matches any characters.
+// All other special characters are escaped.
+func (field *QueryField) Wildcard(pattern string) *QueryComparison {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = EscapeQueryValue(part)
+	}
+	return field.compare(QueryOperatorContainsConst, strings.Join(parts, "*"))
+}
+
+// Exists matches documents that have the field.
+func (field *QueryField) Exists() *QueryComparison {
+	return field.compare(QueryOperatorContainsConst, "*")
+}
+
+// Missing matches documents that do not have the field.
+func (field *QueryField) Missing() *QueryComparison {
+	return field.compare(QueryOperatorNotContainsConst, "*")
+}
+
+// GreaterThan matches documents whose field is greater than the value, which can be a number, a string or a
+// time.Time.
+func (field *QueryField) GreaterThan(value interface{}) *QueryComparison {
+	return field.compare(QueryOperatorGreaterThanConst, queryRangeValue(value))
+}
+
+// GreaterThanOrEqual matches documents whose field is greater than or equal to the value.
+func (field *QueryField) GreaterThanOrEqual(value interface{}) *QueryComparison {
+	return field.compare(QueryOperatorGreaterThanOrEqualConst, queryRangeValue(value))
+}
+
+// LessThan matches documents whose field is less than the value.
+func (field *QueryField) LessThan(value interface{}) *QueryComparison {
+	return field.compare(QueryOperatorLessThanConst, queryRangeValue(value))
+}
+
+// LessThanOrEqual matches documents whose field is less than or equal to the value.
+func (field *QueryField) LessThanOrEqual(value interface{}) *QueryComparison {
+	return field.compare(QueryOperatorLessThanOrEqualConst, queryRangeValue(value))
+}
+
+// Between matches documents whose field is in the inclusive range [low, high].
+func (field *QueryField) Between(low interface{}, high interface{}) *QueryGroup {
+	return QueryAnd(field.GreaterThanOrEqual(low), field.LessThanOrEqual(high))
+}
+
+// AnyOf matches documents whose field contains any of the values.
+func (field *QueryField) AnyOf(values ...string) *QueryGroup {
+	group := QueryOr()
+	for _, value := range values {
+		group.Add(field.Contains(value))
+	}
+	return group
+}
+
+// Constants associated with AggregationExpression.Function.
+const (
+	AggregationFunctionTermConst        = "term"
+	AggregationFunctionFilterConst      = "filter"
+	AggregationFunctionNestedConst      = "nested"
+	AggregationFunctionHistogramConst   = "histogram"
+	AggregationFunctionTimesliceConst   = "timeslice"
+	AggregationFunctionTopHitsConst     = "top_hits"
+	AggregationFunctionGroupByConst     = "group_by"
+	AggregationFunctionMinConst         = "min"
+	AggregationFunctionMaxConst         = "max"
+	AggregationFunctionSumConst         = "sum"
+	AggregationFunctionAverageConst     = "average"
+	AggregationFunctionUniqueCountConst = "unique_count"
+)
+
+// AggregationExpression : An aggregation of the Discovery Query Language, such as term(author,count:10), with
+// optional sub-aggregations.
+type AggregationExpression struct {
+	Function  string
+	Arguments []string
+
+	// Aggregations computed for each result of this aggregation.
+	Aggregations []*AggregationExpression
+}
+
+func newAggregation(function string, arguments ...string) *AggregationExpression {
+	return &AggregationExpression{Function: function, Arguments: arguments}
+}
+
+// NewTermAggregation counts the most frequent values of a field.
+func NewTermAggregation(field string) *AggregationExpression {
+	return newAggregation(AggregationFunctionTermConst, field)
+}
+
+// NewFilterAggregation narrows the documents of the sub-aggregations to those matching a filter.
+func NewFilterAggregation(filter QueryExpression) *AggregationExpression {
+	return newAggregation(AggregationFunctionFilterConst, filter.String())
+}
+
+// NewNestedAggregation applies the sub-aggregations to the elements of an array field, such as
+// enriched_text.entities.
+func NewNestedAggregation(path string) *AggregationExpression {
+	return newAggregation(AggregationFunctionNestedConst, path)
+}
+
+// NewHistogramAggregation counts documents in intervals of a numeric field.
+func NewHistogramAggregation(field string, interval int64) *AggregationExpression {
+	return newAggregation(AggregationFunctionHistogramConst, field, strconv.FormatInt(interval, 10))
+}
+
+// NewTimesliceAggregation counts documents in intervals of a date field. The interval is a number and a unit, such as
+// "1day" or "12hours".
+func NewTimesliceAggregation(field string, interval string) *AggregationExpression {
+	return newAggregation(AggregationFunctionTimesliceConst, field, interval)
+}
+
+// NewTopHitsAggregation returns the most relevant documents of each result of the parent aggregation.
+func NewTopHitsAggregation(size int64) *AggregationExpression {
+	return newAggregation(AggregationFunctionTopHitsConst, strconv.FormatInt(size, 10))
+}
+
+// NewGroupByAggregation groups documents by the values of a field.
+func NewGroupByAggregation(field string) *AggregationExpression {
+	return newAggregation(AggregationFunctionGroupByConst, field)
+}
+
+// NewCalculationAggregation computes a value over a numeric field. The function is one of
+// AggregationFunctionMinConst, AggregationFunctionMaxConst, AggregationFunctionSumConst,
+// AggregationFunctionAverageConst and AggregationFunctionUniqueCountConst.
+func NewCalculationAggregation(function string, field string) *AggregationExpression {
+	return newAggregation(function, field)
+}
+
+// Option adds a named argument, such as count:10. Values that contain whitespace are quoted.
+func (aggregation *AggregationExpression) Option(name string, value string) *AggregationExpression {
+	aggregation.Arguments = append(aggregation.Arguments, name+":"+queryValue(value))
+	return aggregation
+}
+
+// Count sets the number of results of a term or group_by aggregation.
+func (aggregation *AggregationExpression) Count(count int64) *AggregationExpression {
+	return aggregation.Option("count", strconv.FormatInt(count, 10))
+}
+
+// Name sets the name of the aggregation, which identifies it in the response.
+func (aggregation *AggregationExpression) Name(name string) *AggregationExpression {
+	return aggregation.Option("name", name)
+}
+
+// Aggregate adds sub-aggregations.
+func (aggregation *AggregationExpression) Aggregate(aggregations ...*AggregationExpression) *AggregationExpression {
+	aggregation.Aggregations = append(aggregation.Aggregations, aggregations...)
+	return aggregation
+}
+
+// String renders the aggregation. A single sub-aggregation is chained with a period; several are listed in brackets.
+func (aggregation *AggregationExpression) String() string {
+	s := aggregation.Function + "(" + strings.Join(aggregation.Arguments, ",") + ")"
+	switch len(aggregation.Aggregations) {
+	case 0:
+	case 1:
+		s += "." + aggregation.Aggregations[0].String()
+	default:
+		s += ".[" + JoinAggregations(aggregation.Aggregations...) + "]"
+	}
+	return s
+}
+
+// JoinAggregations renders several aggregations for the aggregation parameter of a query.
+func JoinAggregations(aggregations ...*AggregationExpression) string {
+	parts := make([]string, len(aggregations))
+	for i, aggregation := range aggregations {
+		parts[i] = aggregation.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// SetQueryExpression : Allow user to set Query from an expression
+func (_options *QueryOptions) SetQueryExpression(query QueryExpression) *QueryOptions {
+	_options.Query = core.StringPtr(query.String())
+	return _options
+}
+
+// SetFilterExpression : Allow user to set Filter from an expression
+func (_options *QueryOptions) SetFilterExpression(filter QueryExpression) *QueryOptions {
+	_options.Filter = core.StringPtr(filter.String())
+	return _options
+}
+
+// SetAggregationExpressions : Allow user to set Aggregation from expressions
+func (_options *QueryOptions) SetAggregationExpressions(aggregations ...*AggregationExpression) *QueryOptions {
+	_options.Aggregation = core.StringPtr(JoinAggregations(aggregations...))
+	return _options
+}
+
+// SetQueryExpression : Allow user to set Query from an expression
+func (_options *QueryNoticesOptions) SetQueryExpression(query QueryExpression) *QueryNoticesOptions {
+	_options.Query = core.StringPtr(query.String())
+	return _options
+}
+
+// SetFilterExpression : Allow user to set Filter from an expression
+func (_options *QueryNoticesOptions) SetFilterExpression(filter QueryExpression) *QueryNoticesOptions {
+	_options.Filter = core.StringPtr(filter.String())
+	return _options
+}
@@ -0,0 +1,95 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`Discovery Query Language builder`, func() {
+	title := discoveryv2.NewQueryField("title")
+	year := discoveryv2.NewQueryField("year")
+
+	It(`Renders comparisons with escaped values`, func() {
+		Expect(title.Contains("watson").String()).To(Equal(`title:watson`))
+		Expect(title.Contains(`a:b,c"d\e`).String()).To(Equal(`title:a\:b\,c\"d\\e`))
+		Expect(title.Contains("ibm watson").String()).To(Equal(`title:"ibm watson"`))
+		Expect(title.Equals("Q1, 2022").String()).To(Equal(`title::"Q1, 2022"`))
+		Expect(title.Phrase(`say "hi"`).String()).To(Equal(`title:"say \"hi\""`))
+		Expect(title.Near("quick fox", 3).String()).To(Equal(`title:"quick fox"~3`))
+		Expect(title.Fuzzy("watsn", 1).String()).To(Equal(`title:watsn~1`))
+		Expect(title.Wildcard("wat*(1)").String()).To(Equal(`title:wat*\(1\)`))
+		Expect(title.Exists().String()).To(Equal(`title:*`))
+		Expect(title.Contains("watson").Boost(2.5).String()).To(Equal(`title:watson^2.5`))
+		Expect(year.Between(2020, 2022.5).String()).To(Equal(`year>=2020,year<=2022.5`))
+		Expect(discoveryv2.NewQueryField("date").GreaterThan(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)).String()).
+			To(Equal(`date>2022-03-01T12:00:00`))
+		Expect(discoveryv2.NewQueryField("date").LessThan(time.Date(2022, 3, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60))).String()).
+			To(Equal(`date<2022-03-01T12:00:00`))
+	})
+	It(`Nests and negates groups`, func() {
+		expression := discoveryv2.QueryAnd(
+			title.AnyOf("watson", "discovery"),
+			year.GreaterThanOrEqual(2020),
+			discoveryv2.QueryOr(),
+			discoveryv2.QueryAnd(discoveryv2.QueryOr(title.Contains("single"))),
+		)
+		Expect(expression.String()).To(Equal(`(title:watson|title:discovery),year>=2020,title:single`))
+		Expect(discoveryv2.QueryNot(expression).String()).
+			To(Equal(`(title:!watson,title:!discovery)|year<2020|title:!single`))
+		Expect(discoveryv2.QueryNot(title.Missing()).String()).To(Equal(`title:*`))
+	})
+	It(`Renders aggregations`, func() {
+		aggregation := discoveryv2.NewNestedAggregation("enriched_text.entities").Aggregate(
+			discoveryv2.NewFilterAggregation(discoveryv2.NewQueryField("enriched_text.entities.type").Equals("Company")).Aggregate(
+				discoveryv2.NewTermAggregation("enriched_text.entities.text").Count(10).Name("companies").Aggregate(
+					discoveryv2.NewTopHitsAggregation(1),
+					discoveryv2.NewCalculationAggregation(discoveryv2.AggregationFunctionAverageConst, "price"),
+				),
+			),
+		)
+		Expect(aggregation.String()).To(Equal(`nested(enriched_text.entities).filter(enriched_text.entities.type::Company).` +
+			`term(enriched_text.entities.text,count:10,name:companies).[top_hits(1),average(price)]`))
+		Expect(discoveryv2.JoinAggregations(
+			discoveryv2.NewHistogramAggregation("price", 100),
+			discoveryv2.NewTimesliceAggregation("date", "1day"),
+			discoveryv2.NewGroupByAggregation("author"),
+		)).To(Equal(`histogram(price,100),timeslice(date,1day),group_by(author)`))
+		Expect(discoveryv2.NewTermAggregation("author").Name("top authors").String()).To(Equal(`term(author,name:"top authors")`))
+	})
+	It(`Sets query options`, func() {
+		service := &discoveryv2.DiscoveryV2{}
+		options := service.NewQueryOptions("p1").
+			SetQueryExpression(title.Contains("watson").Boost(2)).
+			SetFilterExpression(year.LessThan(2020)).
+			SetAggregationExpressions(discoveryv2.NewTermAggregation("author"), discoveryv2.NewCalculationAggregation(discoveryv2.AggregationFunctionMaxConst, "year"))
+		Expect(*options.Query).To(Equal(`title:watson^2`))
+		Expect(*options.Filter).To(Equal(`year<2020`))
+		Expect(*options.Aggregation).To(Equal(`term(author),max(year)`))
+
+		notices := service.NewQueryNoticesOptions("p1").SetFilterExpression(title.Exists())
+		Expect(*notices.Filter).To(Equal(`title:*`))
+
+		v1Options := (&discoveryv1.DiscoveryV1{}).NewQueryOptions("e1", "c1").SetFilter(year.Between(2000, 2010).String())
+		Expect(*v1Options.Filter).To(Equal(`year>=2000,year<=2010`))
+	})
+})
@@ -0,0 +1,373 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultEvaluationK is the default rank cutoff of a RelevanceEvaluator.
+const DefaultEvaluationK = 10
+
+// RelevanceSearcher : Runs a judged query and returns the IDs of the documents found, best first.
+type RelevanceSearcher interface {
+	Search(ctx context.Context, naturalLanguageQuery string, filter string, count int64) ([]string, error)
+}
+
+// QuerySearcher : A RelevanceSearcher that runs natural language queries against a project.
+type QuerySearcher struct {
+	Discovery     *DiscoveryV2
+	ProjectID     string
+	CollectionIds []string
+
+	// Called with the options of every query, for example to evaluate a different configuration. Optional.
+	Configure func(*QueryOptions)
+}
+
+// Search runs a natural language query and returns the IDs of the results.
+func (searcher *QuerySearcher) Search(ctx context.Context, naturalLanguageQuery string, filter string, count int64) ([]string, error) {
+	options := searcher.Discovery.NewQueryOptions(searcher.ProjectID).
+		SetNaturalLanguageQuery(naturalLanguageQuery).
+		SetReturn([]string{"document_id"}).
+		SetCount(count)
+	if filter != "" {
+		options.SetFilter(filter)
+	}
+	if len(searcher.CollectionIds) > 0 {
+		options.SetCollectionIds(searcher.CollectionIds)
+	}
+	if searcher.Configure != nil {
+		searcher.Configure(options)
+	}
+	result, _, err := searcher.Discovery.QueryWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(result.Results))
+	for _, queryResult := range result.Results {
+		ids = append(ids, stringValue(queryResult.DocumentID))
+	}
+	return ids, nil
+}
+
+// QueryEvaluation : The metrics of one judged query.
+type QueryEvaluation struct {
+	NaturalLanguageQuery string `json:"natural_language_query"`
+	Filter               string `json:"filter,omitempty"`
+
+	// The documents returned, best first.
+	Ranked []string `json:"ranked"`
+
+	// The number of judged documents that are relevant.
+	Relevant int `json:"relevant"`
+
+	NDCG      float64 `json:"ndcg"`
+	MRR       float64 `json:"mrr"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+
+	// The error of the query, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Evaluated returns whether the query counts towards the aggregate metrics: it succeeded and has relevant documents.
+func (evaluation *QueryEvaluation) Evaluated() bool {
+	return evaluation.Error == "" && evaluation.Relevant > 0
+}
+
+// EvaluationReport : The metrics of a set of judged queries. The aggregate metrics are the means over the evaluated
+// queries.
+type EvaluationReport struct {
+	K       int               `json:"k"`
+	Queries []QueryEvaluation `json:"queries"`
+
+	NDCG      float64 `json:"ndcg"`
+	MRR       float64 `json:"mrr"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+
+	// The number of queries that count towards the aggregate metrics.
+	Evaluated int `json:"evaluated"`
+}
+
+// RelevanceEvaluator : Computes NDCG@K, MRR, precision@K and recall@K of queries against relevance judgments.
+type RelevanceEvaluator struct {
+	Searcher RelevanceSearcher
+
+	// The rank cutoff. Defaults to DefaultEvaluationK.
+	K int
+
+	// Documents with a relevance above the threshold are relevant for MRR, precision and recall. NDCG uses the
+	// relevance as the gain.
+	RelevanceThreshold int64
+}
+
+// NewRelevanceEvaluator : Instantiate RelevanceEvaluator
+func NewRelevanceEvaluator(searcher RelevanceSearcher) *RelevanceEvaluator {
+	return &RelevanceEvaluator{Searcher: searcher, K: DefaultEvaluationK}
+}
+
+// NewRelevanceEvaluator returns an evaluator that runs natural language queries against a project.
+func (discovery *DiscoveryV2) NewRelevanceEvaluator(projectID string, collectionIds ...string) *RelevanceEvaluator {
+	return NewRelevanceEvaluator(&QuerySearcher{Discovery: discovery, ProjectID: projectID, CollectionIds: collectionIds})
+}
+
+// Evaluate runs every judged query, in the order the queries first appear in the judgments. Judgments are matched to
+// results by document ID, so judgments with only a document key must be resolved first. A failed query is recorded
+// in its evaluation; the error is only returned when the context ends.
+func (evaluator *RelevanceEvaluator) Evaluate(ctx context.Context, judgments []Judgment) (*EvaluationReport, error) {
+	k := evaluator.K
+	if k <= 0 {
+		k = DefaultEvaluationK
+	}
+	type judgedQuery struct {
+		naturalLanguageQuery string
+		filter               string
+		relevance            map[string]int64
+	}
+	var queries []*judgedQuery
+	byKey := make(map[string]*judgedQuery)
+	for _, judgment := range judgments {
+		key := trainingQueryKey(judgment.NaturalLanguageQuery, judgment.Filter)
+		query, ok := byKey[key]
+		if !ok {
+			query = &judgedQuery{naturalLanguageQuery: judgment.NaturalLanguageQuery, filter: judgment.Filter, relevance: make(map[string]int64)}
+			queries = append(queries, query)
+			byKey[key] = query
+		}
+		if judgment.DocumentID != "" {
+			query.relevance[judgment.DocumentID] = judgment.Relevance
+		}
+	}
+
+	report := &EvaluationReport{K: k}
+	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		evaluation := QueryEvaluation{NaturalLanguageQuery: query.naturalLanguageQuery, Filter: query.filter}
+		ranked, err := evaluator.Searcher.Search(ctx, query.naturalLanguageQuery, query.filter, int64(k))
+		if err != nil {
+			evaluation.Error = err.Error()
+		} else {
+			if len(ranked) > k {
+				ranked = ranked[:k]
+			}
+			evaluation.Ranked = ranked
+			evaluator.score(&evaluation, query.relevance, k)
+		}
+		if evaluation.Evaluated() {
+			report.Evaluated++
+			report.NDCG += evaluation.NDCG
+			report.MRR += evaluation.MRR
+			report.Precision += evaluation.Precision
+			report.Recall += evaluation.Recall
+		}
+		report.Queries = append(report.Queries, evaluation)
+	}
+	if report.Evaluated > 0 {
+		n := float64(report.Evaluated)
+		report.NDCG /= n
+		report.MRR /= n
+		report.Precision /= n
+		report.Recall /= n
+	}
+	return report, nil
+}
+
+// score computes the metrics of ranked results against the relevance of the judged documents.
+func (evaluator *RelevanceEvaluator) score(evaluation *QueryEvaluation, relevance map[string]int64, k int) {
+	var gains []float64
+	for _, r := range relevance {
+		if r > evaluator.RelevanceThreshold {
+			evaluation.Relevant++
+		}
+		if r > 0 {
+			gains = append(gains, math.Pow(2, float64(r))-1)
+		}
+	}
+	if evaluation.Relevant == 0 {
+		return
+	}
+
+	var dcg float64
+	found := 0
+	for i, id := range evaluation.Ranked {
+		r := relevance[id]
+		if r > 0 {
+			dcg += (math.Pow(2, float64(r)) - 1) / math.Log2(float64(i+2))
+		}
+		if r > evaluator.RelevanceThreshold {
+			found++
+			if evaluation.MRR == 0 {
+				evaluation.MRR = 1 / float64(i+1)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(gains)))
+	var idcg float64
+	for i := 0; i < len(gains) && i < k; i++ {
+		idcg += gains[i] / math.Log2(float64(i+2))
+	}
+	if idcg > 0 {
+		evaluation.NDCG = dcg / idcg
+	}
+	evaluation.Precision = float64(found) / float64(k)
+	evaluation.Recall = float64(found) / float64(evaluation.Relevant)
+}
+
+// WriteTable writes the metrics of every query followed by the aggregate metrics.
+func (report *EvaluationReport) WriteTable(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "query\tndcg@%d\tmrr\tp@%d\trecall@%d\n", report.K, report.K, report.K)
+	for i := range report.Queries {
+		evaluation := &report.Queries[i]
+		row := evaluationLabel(evaluation.NaturalLanguageQuery, evaluation.Filter) + "\t" + evaluationMetrics(evaluation)
+		if note := evaluationNote(evaluation); note != "" {
+			row += "\t" + note
+		}
+		fmt.Fprintln(writer, row)
+	}
+	fmt.Fprintf(writer, "mean (%d queries)\t%.3f\t%.3f\t%.3f\t%.3f\n", report.Evaluated, report.NDCG, report.MRR, report.Precision, report.Recall)
+	return writer.Flush()
+}
+
+func evaluationLabel(naturalLanguageQuery string, filter string) string {
+	if filter == "" {
+		return naturalLanguageQuery
+	}
+	return naturalLanguageQuery + " [" + filter + "]"
+}
+
+// evaluationMetrics returns the four metric cells of a query, or dashes when it was not evaluated.
+func evaluationMetrics(evaluation *QueryEvaluation) string {
+	if evaluation == nil || !evaluation.Evaluated() {
+		return "-\t-\t-\t-"
+	}
+	return fmt.Sprintf("%.3f\t%.3f\t%.3f\t%.3f", evaluation.NDCG, evaluation.MRR, evaluation.Precision, evaluation.Recall)
+}
+
+// evaluationNote explains why a query was not evaluated.
+func evaluationNote(evaluation *QueryEvaluation) string {
+	switch {
+	case evaluation == nil:
+		return "missing"
+	case evaluation.Error != "":
+		return "error: " + evaluation.Error
+	case evaluation.Relevant == 0:
+		return "no relevant documents"
+	}
+	return ""
+}
+
+// EvaluationDiff : The metrics of one query in two evaluations. Either side is nil when the query is missing from it.
+type EvaluationDiff struct {
+	NaturalLanguageQuery string
+	Filter               string
+	Baseline             *QueryEvaluation
+	Candidate            *QueryEvaluation
+}
+
+// NDCGDelta returns the change of NDCG from the baseline to the candidate, or 0 unless both were evaluated.
+func (diff *EvaluationDiff) NDCGDelta() float64 {
+	if diff.Baseline == nil || diff.Candidate == nil || !diff.Baseline.Evaluated() || !diff.Candidate.Evaluated() {
+		return 0
+	}
+	return diff.Candidate.NDCG - diff.Baseline.NDCG
+}
+
+// EvaluationComparison : Two evaluations of the same judgments, for example before and after tuning a project.
+type EvaluationComparison struct {
+	Baseline  *EvaluationReport
+	Candidate *EvaluationReport
+
+	// The queries of both evaluations, in the order of the baseline followed by those only in the candidate.
+	Diffs []*EvaluationDiff
+
+	// The number of queries whose NDCG improved and regressed.
+	Improved  int
+	Regressed int
+}
+
+// CompareEvaluations matches the queries of two evaluations by natural language query and filter.
+func CompareEvaluations(baseline *EvaluationReport, candidate *EvaluationReport) *EvaluationComparison {
+	comparison := &EvaluationComparison{Baseline: baseline, Candidate: candidate}
+	byKey := make(map[string]*EvaluationDiff)
+	add := func(evaluations []QueryEvaluation, set func(*EvaluationDiff, *QueryEvaluation)) {
+		for i := range evaluations {
+			evaluation := &evaluations[i]
+			key := trainingQueryKey(evaluation.NaturalLanguageQuery, evaluation.Filter)
+			diff, ok := byKey[key]
+			if !ok {
+				diff = &EvaluationDiff{NaturalLanguageQuery: evaluation.NaturalLanguageQuery, Filter: evaluation.Filter}
+				comparison.Diffs = append(comparison.Diffs, diff)
+				byKey[key] = diff
+			}
+			set(diff, evaluation)
+		}
+	}
+	add(baseline.Queries, func(diff *EvaluationDiff, evaluation *QueryEvaluation) { diff.Baseline = evaluation })
+	add(candidate.Queries, func(diff *EvaluationDiff, evaluation *QueryEvaluation) { diff.Candidate = evaluation })
+	for _, diff := range comparison.Diffs {
+		switch delta := diff.NDCGDelta(); {
+		case delta > 0:
+			comparison.Improved++
+		case delta < 0:
+			comparison.Regressed++
+		}
+	}
+	return comparison
+}
+
+// WriteTable writes the metrics of both evaluations side by side with the change of NDCG, followed by the aggregate
+// metrics.
+func (comparison *EvaluationComparison) WriteTable(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	k := comparison.Baseline.K
+	fmt.Fprintf(writer, "query\tndcg@%d\tmrr\tp@%d\trecall@%d\t|\tndcg@%d\tmrr\tp@%d\trecall@%d\t|\tΔndcg\n", k, k, k, k, k, k)
+	for _, diff := range comparison.Diffs {
+		delta := "-"
+		if diff.Baseline != nil && diff.Candidate != nil && diff.Baseline.Evaluated() && diff.Candidate.Evaluated() {
+			delta = fmt.Sprintf("%+.3f", diff.NDCGDelta())
+		}
+		row := fmt.Sprintf("%s\t%s\t|\t%s\t|\t%s", evaluationLabel(diff.NaturalLanguageQuery, diff.Filter),
+			evaluationMetrics(diff.Baseline), evaluationMetrics(diff.Candidate), delta)
+		var notes []string
+		if note := evaluationNote(diff.Baseline); note != "" {
+			notes = append(notes, "baseline "+note)
+		}
+		if note := evaluationNote(diff.Candidate); note != "" {
+			notes = append(notes, "candidate "+note)
+		}
+		if len(notes) > 0 {
+			row += "\t" + strings.Join(notes, ", ")
+		}
+		fmt.Fprintln(writer, row)
+	}
+	baseline, candidate := comparison.Baseline, comparison.Candidate
+	fmt.Fprintf(writer, "mean\t%.3f\t%.3f\t%.3f\t%.3f\t|\t%.3f\t%.3f\t%.3f\t%.3f\t|\t%+.3f\n",
+		baseline.NDCG, baseline.MRR, baseline.Precision, baseline.Recall,
+		candidate.NDCG, candidate.MRR, candidate.Precision, candidate.Recall, candidate.NDCG-baseline.NDCG)
+	fmt.Fprintf(writer, "%d improved, %d regressed\n", comparison.Improved, comparison.Regressed)
+	return writer.Flush()
+}
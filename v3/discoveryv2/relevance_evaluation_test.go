@@ -0,0 +1,141 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+type fakeSearcher map[string][]string
+
+func (searcher fakeSearcher) Search(ctx context.Context, naturalLanguageQuery string, filter string, count int64) ([]string, error) {
+	ranked, ok := searcher[naturalLanguageQuery]
+	if !ok {
+		return nil, errors.New("unavailable")
+	}
+	return ranked, nil
+}
+
+var _ = Describe(`RelevanceEvaluator`, func() {
+	judgments := []discoveryv2.Judgment{
+		{NaturalLanguageQuery: "reset", DocumentID: "a", Relevance: 2},
+		{NaturalLanguageQuery: "reset", DocumentID: "b", Relevance: 1},
+		{NaturalLanguageQuery: "reset", DocumentID: "c", Relevance: 0},
+		{NaturalLanguageQuery: "pricing", Filter: "year>2020", DocumentID: "p", Relevance: 1},
+		{NaturalLanguageQuery: "nothing", DocumentID: "n", Relevance: 0},
+		{NaturalLanguageQuery: "broken", DocumentID: "x", Relevance: 1},
+	}
+
+	It(`Computes per-query and aggregate metrics`, func() {
+		evaluator := discoveryv2.NewRelevanceEvaluator(fakeSearcher{
+			"reset":   {"c", "a", "z"},
+			"pricing": {"p"},
+			"nothing": {"n"},
+		})
+		evaluator.K = 2
+		report, err := evaluator.Evaluate(context.Background(), judgments)
+		Expect(err).To(BeNil())
+		Expect(report.Queries).To(HaveLen(4))
+		Expect(report.Evaluated).To(Equal(2))
+
+		reset := report.Queries[0]
+		Expect(reset.Ranked).To(Equal([]string{"c", "a"}))
+		Expect(reset.Relevant).To(Equal(2))
+		Expect(reset.MRR).To(Equal(0.5))
+		Expect(reset.Precision).To(Equal(0.5))
+		Expect(reset.Recall).To(Equal(0.5))
+		// DCG = 3/log2(3); IDCG = 3 + 1/log2(3).
+		Expect(reset.NDCG).To(BeNumerically("~", 0.5213, 0.0001))
+
+		Expect(report.Queries[1].NDCG).To(Equal(1.0))
+		Expect(report.Queries[2].Evaluated()).To(BeFalse())
+		Expect(report.Queries[3].Error).To(Equal("unavailable"))
+		Expect(report.MRR).To(Equal(0.75))
+		Expect(report.Recall).To(Equal(0.75))
+
+		var table bytes.Buffer
+		Expect(report.WriteTable(&table)).To(Succeed())
+		Expect(table.String()).To(Equal(`query                ndcg@2  mrr    p@2    recall@2
+reset                0.521   0.500  0.500  0.500
+pricing [year>2020]  1.000   1.000  0.500  1.000
+nothing              -       -      -      -  no relevant documents
+broken               -       -      -      -  error: unavailable
+mean (2 queries)     0.761   0.750  0.500  0.750
+`))
+	})
+	It(`Compares two evaluations side by side`, func() {
+		baseline, err := discoveryv2.NewRelevanceEvaluator(fakeSearcher{"reset": {"c", "b", "a"}, "pricing": {"p"}}).Evaluate(context.Background(), judgments[:4])
+		Expect(err).To(BeNil())
+		candidate, err := discoveryv2.NewRelevanceEvaluator(fakeSearcher{"reset": {"a", "b"}}).Evaluate(context.Background(), judgments)
+		Expect(err).To(BeNil())
+
+		comparison := discoveryv2.CompareEvaluations(baseline, candidate)
+		Expect(comparison.Diffs).To(HaveLen(4))
+		Expect(comparison.Improved).To(Equal(1))
+		Expect(comparison.Regressed).To(Equal(0))
+		Expect(comparison.Diffs[0].NDCGDelta()).To(BeNumerically(">", 0))
+		Expect(comparison.Diffs[1].Candidate.Error).To(Equal("unavailable"))
+		Expect(comparison.Diffs[2].Baseline).To(BeNil())
+
+		var table bytes.Buffer
+		Expect(comparison.WriteTable(&table)).To(Succeed())
+		Expect(table.String()).To(ContainSubstring("1 improved, 0 regressed\n"))
+		Expect(table.String()).To(MatchRegexp(`\nbroken +- +- +- +- +\| +- +- +- +- +\| +- +baseline missing, candidate error: unavailable\n`))
+	})
+	It(`Runs judged queries through Query`, func() {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			var body map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			Expect(body["natural_language_query"]).To(Equal("reset"))
+			Expect(body["collection_ids"]).To(Equal([]interface{}{"c1"}))
+			Expect(body["count"]).To(Equal(10.0))
+			Expect(body["table_results"]).To(Equal(map[string]interface{}{"enabled": false}))
+			res.Header().Set("Content-type", "application/json")
+			fmt.Fprint(res, `{"matching_results": 2, "results": [
+				{"document_id": "a", "result_metadata": {"collection_id": "c1"}},
+				{"document_id": "b", "result_metadata": {"collection_id": "c1"}}]}`)
+		}))
+		defer testServer.Close()
+		service, err := discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+
+		evaluator := service.NewRelevanceEvaluator("p1", "c1")
+		evaluator.Searcher.(*discoveryv2.QuerySearcher).Configure = func(options *discoveryv2.QueryOptions) {
+			options.SetTableResults(&discoveryv2.QueryLargeTableResults{Enabled: core.BoolPtr(false)})
+		}
+		report, err := evaluator.Evaluate(context.Background(), judgments[:3])
+		Expect(err).To(BeNil())
+		Expect(report.NDCG).To(Equal(1.0))
+		Expect(report.Precision).To(Equal(0.2))
+	})
+})
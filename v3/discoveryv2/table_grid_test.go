@@ -0,0 +1,107 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`TableGrid`, func() {
+	const tableResult = `{
+		"table_id": "t1",
+		"table": {
+			"title": {"text": "Results & costs"},
+			"table_headers": [{"cell_id": "th", "text": "", "row_index_begin": 0, "row_index_end": 0, "column_index_begin": 0, "column_index_end": 0}],
+			"column_headers": [
+				{"cell_id": "ch1", "text": "Q1", "row_index_begin": 0, "row_index_end": 0, "column_index_begin": 1, "column_index_end": 1},
+				{"cell_id": "ch2", "text": "Q2", "row_index_begin": 0, "row_index_end": 0, "column_index_begin": 2, "column_index_end": 2}
+			],
+			"row_headers": [
+				{"cell_id": "rh1", "text": "Revenue", "row_index_begin": 1, "row_index_end": 1, "column_index_begin": 0, "column_index_end": 0},
+				{"cell_id": "rh2", "text": "Costs <net>", "row_index_begin": 2, "row_index_end": 2, "column_index_begin": 0, "column_index_end": 0}
+			],
+			"body_cells": [
+				{"cell_id": "b1", "text": "10", "row_index_begin": 1, "row_index_end": 1, "column_index_begin": 1, "column_index_end": 1,
+					"row_header_texts": [{"text": "Revenue"}], "column_header_texts": [{"text": "Q1"}]},
+				{"cell_id": "b2", "text": "20", "row_index_begin": 1, "row_index_end": 1, "column_index_begin": 2, "column_index_end": 2,
+					"row_header_ids": [{"id": "rh1"}], "column_header_ids": [{"id": "ch2"}]},
+				{"cell_id": "b3", "text": "5", "row_index_begin": 2, "row_index_end": 2, "column_index_begin": 1, "column_index_end": 2}
+			],
+			"key_value_pairs": [{"key": {"text": "Currency"}, "value": [{"text": "USD"}]}]
+		}
+	}`
+
+	var grid *discoveryv2.TableGrid
+
+	BeforeEach(func() {
+		var raw map[string]json.RawMessage
+		Expect(json.Unmarshal([]byte(tableResult), &raw)).To(Succeed())
+		var result *discoveryv2.QueryTableResult
+		Expect(core.UnmarshalModel(raw, "", &result, discoveryv2.UnmarshalQueryTableResult)).To(Succeed())
+		grid = result.Grid()
+	})
+
+	It(`Expands spanned cells and attaches headers`, func() {
+		Expect(grid.Title).To(Equal("Results & costs"))
+		Expect(grid.Strings()).To(Equal([][]string{
+			{"", "Q1", "Q2"},
+			{"Revenue", "10", "20"},
+			{"Costs <net>", "5", "5"},
+		}))
+		Expect(grid.Rows[1][1].RowHeaders).To(Equal([]string{"Revenue"}))
+		Expect(grid.Rows[1][2].ColumnHeaders).To(Equal([]string{"Q2"}))
+		spanned := grid.Rows[2][2]
+		Expect(spanned).To(BeIdenticalTo(grid.Rows[2][1]))
+		Expect(spanned.ColumnSpan()).To(Equal(2))
+		Expect(spanned.RowHeaders).To(Equal([]string{"Costs <net>"}))
+		Expect(spanned.ColumnHeaders).To(Equal([]string{"Q1", "Q2"}))
+		Expect(grid.Rows[0][0].Kind).To(Equal(discoveryv2.TableCellKindTableHeaderConst))
+		Expect(grid.KeyValuePairs).To(Equal([]discoveryv2.TableGridKeyValue{{Key: "Currency", Values: []string{"USD"}}}))
+		Expect((&discoveryv2.QueryTableResult{}).Grid()).To(BeNil())
+	})
+	It(`Exports CSV and HTML`, func() {
+		var buffer bytes.Buffer
+		Expect(grid.WriteCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal(",Q1,Q2\nRevenue,10,20\nCosts <net>,5,5\n"))
+		Expect(grid.HTML()).To(Equal(`<table>
+<caption>Results &amp; costs</caption>
+<tr><th></th><th scope="col">Q1</th><th scope="col">Q2</th></tr>
+<tr><th scope="row">Revenue</th><td>10</td><td>20</td></tr>
+<tr><th scope="row">Costs &lt;net&gt;</th><td colspan="2">5</td></tr>
+</table>
+`))
+	})
+	It(`Renders overlapping cells with the positions they occupy`, func() {
+		first := &discoveryv2.TableGridCell{Kind: discoveryv2.TableCellKindBodyConst, Text: "A"}
+		second := &discoveryv2.TableGridCell{Kind: discoveryv2.TableCellKindBodyConst, Text: "B"}
+		overlapping := &discoveryv2.TableGrid{Rows: [][]*discoveryv2.TableGridCell{
+			{first, first, nil},
+			{first, second, second},
+		}}
+		Expect(overlapping.HTML()).To(Equal(`<table>
+<tr><td colspan="2">A</td><td></td></tr>
+<tr><td></td><td colspan="2">B</td></tr>
+</table>
+`))
+	})
+})
@@ -0,0 +1,494 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// The requirements that training data must meet before the service trains a relevancy model for a project.
+const (
+	MinTrainingQueries   = 50
+	MaxTrainingRelevance = 10
+)
+
+// The columns of a judgment CSV file.
+const (
+	JudgmentColumnQuery        = "natural_language_query"
+	JudgmentColumnFilter       = "filter"
+	JudgmentColumnDocumentID   = "document_id"
+	JudgmentColumnDocumentKey  = "document_key"
+	JudgmentColumnCollectionID = "collection_id"
+	JudgmentColumnRelevance    = "relevance"
+)
+
+// Judgment : The relevance of one document to one query, as maintained in a judgment file.
+type Judgment struct {
+	NaturalLanguageQuery string `json:"natural_language_query"`
+	Filter               string `json:"filter,omitempty"`
+
+	// The document is identified either by its ID or by the value of TrainingSync.KeyField.
+	DocumentID  string `json:"document_id,omitempty"`
+	DocumentKey string `json:"document_key,omitempty"`
+
+	// The collection of the document. Optional when it can be resolved.
+	CollectionID string `json:"collection_id,omitempty"`
+
+	Relevance int64 `json:"relevance"`
+}
+
+// ReadJudgmentsCSV reads judgments from CSV with a header row. The columns are named by the JudgmentColumn constants;
+// query is accepted for natural_language_query, and the columns may be in any order.
+func ReadJudgmentsCSV(r io.Reader) ([]Judgment, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "query" {
+			name = JudgmentColumnQuery
+		}
+		columns[name] = i
+	}
+	if _, ok := columns[JudgmentColumnQuery]; !ok {
+		return nil, fmt.Errorf("missing column %s", JudgmentColumnQuery)
+	}
+	if _, ok := columns[JudgmentColumnRelevance]; !ok {
+		return nil, fmt.Errorf("missing column %s", JudgmentColumnRelevance)
+	}
+	_, hasID := columns[JudgmentColumnDocumentID]
+	_, hasKey := columns[JudgmentColumnDocumentKey]
+	if !hasID && !hasKey {
+		return nil, fmt.Errorf("missing column %s or %s", JudgmentColumnDocumentID, JudgmentColumnDocumentKey)
+	}
+
+	var judgments []Judgment
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return judgments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		relevance, err := strconv.ParseInt(value(JudgmentColumnRelevance), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid relevance %q", line, value(JudgmentColumnRelevance))
+		}
+		judgments = append(judgments, Judgment{
+			NaturalLanguageQuery: value(JudgmentColumnQuery),
+			Filter:               value(JudgmentColumnFilter),
+			DocumentID:           value(JudgmentColumnDocumentID),
+			DocumentKey:          value(JudgmentColumnDocumentKey),
+			CollectionID:         value(JudgmentColumnCollectionID),
+			Relevance:            relevance,
+		})
+	}
+}
+
+// ReadJudgmentsJSON reads judgments from a JSON array.
+func ReadJudgmentsJSON(r io.Reader) ([]Judgment, error) {
+	var judgments []Judgment
+	if err := json.NewDecoder(r).Decode(&judgments); err != nil {
+		return nil, err
+	}
+	return judgments, nil
+}
+
+// WriteJudgmentsCSV writes judgments as CSV with a header row.
+func WriteJudgmentsCSV(w io.Writer, judgments []Judgment) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{JudgmentColumnQuery, JudgmentColumnFilter, JudgmentColumnDocumentID,
+		JudgmentColumnDocumentKey, JudgmentColumnCollectionID, JudgmentColumnRelevance})
+	if err != nil {
+		return err
+	}
+	for _, judgment := range judgments {
+		err := writer.Write([]string{judgment.NaturalLanguageQuery, judgment.Filter, judgment.DocumentID,
+			judgment.DocumentKey, judgment.CollectionID, strconv.FormatInt(judgment.Relevance, 10)})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJudgmentsJSON writes judgments as an indented JSON array.
+func WriteJudgmentsJSON(w io.Writer, judgments []Judgment) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(judgments)
+}
+
+// TrainingIssue : A problem with the judgments of a query.
+type TrainingIssue struct {
+	// The query, or empty for problems with the training data as a whole.
+	NaturalLanguageQuery string `json:"natural_language_query,omitempty"`
+	Filter               string `json:"filter,omitempty"`
+
+	Message string `json:"message"`
+}
+
+func (issue TrainingIssue) String() string {
+	if issue.NaturalLanguageQuery == "" {
+		return issue.Message
+	}
+	query := strconv.Quote(issue.NaturalLanguageQuery)
+	if issue.Filter != "" {
+		query += " [" + issue.Filter + "]"
+	}
+	return query + ": " + issue.Message
+}
+
+// TrainingSyncReport : The result of a training data sync.
+type TrainingSyncReport struct {
+	// The natural language queries of the training queries that were, or in a dry run would be, changed.
+	Created   []string `json:"created,omitempty"`
+	Updated   []string `json:"updated,omitempty"`
+	Deleted   []string `json:"deleted,omitempty"`
+	Unchanged int      `json:"unchanged"`
+
+	// Judgments that could not be resolved and queries that fall short of the service's requirements.
+	Issues []TrainingIssue `json:"issues,omitempty"`
+}
+
+// WriteSummary writes the counts and one line per issue.
+func (report *TrainingSyncReport) WriteSummary(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%d created, %d updated, %d deleted, %d unchanged\n",
+		len(report.Created), len(report.Updated), len(report.Deleted), report.Unchanged)
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		if _, err := fmt.Fprintln(w, issue.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrainingSync : Keeps the training queries of a project in line with judgments maintained outside the service.
+// Training queries are matched to judgments by natural language query and filter.
+type TrainingSync struct {
+	Discovery *DiscoveryV2
+	ProjectID string
+
+	// The collections searched when a document must be resolved with a query.
+	CollectionIds []string
+
+	// The field that holds Judgment.DocumentKey, for example metadata.external_id.
+	KeyField string
+
+	// When true, training queries without judgments are deleted.
+	DeleteStale bool
+
+	// When true, the changes are reported but not made.
+	DryRun bool
+
+	// Resolved documents, by reference.
+	resolved map[string]TrainingExample
+}
+
+// NewTrainingSync : Instantiate TrainingSync
+func (discovery *DiscoveryV2) NewTrainingSync(projectID string) *TrainingSync {
+	return &TrainingSync{Discovery: discovery, ProjectID: projectID}
+}
+
+// trainingQueryKey identifies a training query by its natural language query and filter.
+func trainingQueryKey(naturalLanguageQuery string, filter string) string {
+	return naturalLanguageQuery + "\x00" + filter
+}
+
+// Export returns the training queries of the project as judgments.
+func (trainingSync *TrainingSync) Export(ctx context.Context) ([]Judgment, error) {
+	queries, err := trainingSync.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var judgments []Judgment
+	for _, query := range queries {
+		for _, example := range query.Examples {
+			judgments = append(judgments, Judgment{
+				NaturalLanguageQuery: stringValue(query.NaturalLanguageQuery),
+				Filter:               stringValue(query.Filter),
+				DocumentID:           stringValue(example.DocumentID),
+				CollectionID:         stringValue(example.CollectionID),
+				Relevance:            int64Value(example.Relevance),
+			})
+		}
+	}
+	return judgments, nil
+}
+
+func (trainingSync *TrainingSync) list(ctx context.Context) ([]TrainingQuery, error) {
+	result, _, err := trainingSync.Discovery.ListTrainingQueriesWithContext(ctx, trainingSync.Discovery.NewListTrainingQueriesOptions(trainingSync.ProjectID))
+	if err != nil {
+		return nil, err
+	}
+	return result.Queries, nil
+}
+
+// Sync creates the training queries of judgments that have none, updates those whose examples differ and, with
+// DeleteStale, deletes those without judgments. Queries with issues are reported and left unchanged, except that
+// examples whose documents cannot be resolved, because no document or more than one matches, are left out. When a
+// query that resolves a document fails, the error is returned before any change is made.
+func (trainingSync *TrainingSync) Sync(ctx context.Context, judgments []Judgment) (*TrainingSyncReport, error) {
+	report := &TrainingSyncReport{}
+	type desiredQuery struct {
+		naturalLanguageQuery string
+		filter               string
+		examples             []TrainingExample
+		byDocument           map[string]int
+		valid                bool
+	}
+	var desired []*desiredQuery
+	byKey := make(map[string]*desiredQuery)
+	for _, judgment := range judgments {
+		key := trainingQueryKey(judgment.NaturalLanguageQuery, judgment.Filter)
+		query, ok := byKey[key]
+		if !ok {
+			query = &desiredQuery{naturalLanguageQuery: judgment.NaturalLanguageQuery, filter: judgment.Filter, byDocument: make(map[string]int), valid: true}
+			desired = append(desired, query)
+			byKey[key] = query
+		}
+		issue := func(format string, args ...interface{}) {
+			report.Issues = append(report.Issues, TrainingIssue{
+				NaturalLanguageQuery: judgment.NaturalLanguageQuery,
+				Filter:               judgment.Filter,
+				Message:              fmt.Sprintf(format, args...),
+			})
+		}
+		if judgment.NaturalLanguageQuery == "" {
+			issue("judgment without a natural language query")
+			query.valid = false
+			continue
+		}
+		if judgment.Relevance < 0 || judgment.Relevance > MaxTrainingRelevance {
+			issue("relevance %d is not between 0 and %d", judgment.Relevance, MaxTrainingRelevance)
+			query.valid = false
+			continue
+		}
+		example, unresolved, err := trainingSync.resolve(ctx, judgment)
+		if err != nil {
+			return report, err
+		}
+		if unresolved != "" {
+			issue("%s", unresolved)
+			continue
+		}
+		example.Relevance = core.Int64Ptr(judgment.Relevance)
+		documentKey := *example.CollectionID + "/" + *example.DocumentID
+		if i, ok := query.byDocument[documentKey]; ok {
+			query.examples[i] = example
+			continue
+		}
+		query.byDocument[documentKey] = len(query.examples)
+		query.examples = append(query.examples, example)
+	}
+
+	for _, query := range desired {
+		if !query.valid || query.naturalLanguageQuery == "" {
+			continue
+		}
+		issue := func(message string) {
+			report.Issues = append(report.Issues, TrainingIssue{NaturalLanguageQuery: query.naturalLanguageQuery, Filter: query.filter, Message: message})
+			query.valid = false
+		}
+		relevant := 0
+		for _, example := range query.examples {
+			if *example.Relevance > 0 {
+				relevant++
+			}
+		}
+		if len(query.examples) == 0 {
+			issue("no examples")
+		} else if relevant == 0 {
+			issue("no example with a relevance above 0")
+		}
+	}
+
+	existing, err := trainingSync.list(ctx)
+	if err != nil {
+		return report, err
+	}
+	current := make(map[string]TrainingQuery)
+	for _, query := range existing {
+		current[trainingQueryKey(stringValue(query.NaturalLanguageQuery), stringValue(query.Filter))] = query
+	}
+
+	valid := 0
+	for _, query := range desired {
+		if !query.valid {
+			continue
+		}
+		valid++
+		key := trainingQueryKey(query.naturalLanguageQuery, query.filter)
+		if found, ok := current[key]; ok {
+			if sameTrainingExamples(found.Examples, query.examples) {
+				report.Unchanged++
+				continue
+			}
+			report.Updated = append(report.Updated, query.naturalLanguageQuery)
+			if trainingSync.DryRun {
+				continue
+			}
+			options := trainingSync.Discovery.NewUpdateTrainingQueryOptions(trainingSync.ProjectID, stringValue(found.QueryID), query.naturalLanguageQuery, query.examples)
+			if query.filter != "" {
+				options.SetFilter(query.filter)
+			}
+			if _, _, err := trainingSync.Discovery.UpdateTrainingQueryWithContext(ctx, options); err != nil {
+				return report, err
+			}
+			continue
+		}
+		report.Created = append(report.Created, query.naturalLanguageQuery)
+		if trainingSync.DryRun {
+			continue
+		}
+		options := trainingSync.Discovery.NewCreateTrainingQueryOptions(trainingSync.ProjectID, query.naturalLanguageQuery, query.examples)
+		if query.filter != "" {
+			options.SetFilter(query.filter)
+		}
+		if _, _, err := trainingSync.Discovery.CreateTrainingQueryWithContext(ctx, options); err != nil {
+			return report, err
+		}
+	}
+
+	if trainingSync.DeleteStale {
+		for _, query := range existing {
+			if _, ok := byKey[trainingQueryKey(stringValue(query.NaturalLanguageQuery), stringValue(query.Filter))]; ok {
+				continue
+			}
+			report.Deleted = append(report.Deleted, stringValue(query.NaturalLanguageQuery))
+			if trainingSync.DryRun {
+				continue
+			}
+			options := trainingSync.Discovery.NewDeleteTrainingQueryOptions(trainingSync.ProjectID, stringValue(query.QueryID))
+			if _, err := trainingSync.Discovery.DeleteTrainingQueryWithContext(ctx, options); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if valid < MinTrainingQueries {
+		report.Issues = append(report.Issues, TrainingIssue{
+			Message: fmt.Sprintf("%d valid training queries, at least %d are needed to train", valid, MinTrainingQueries),
+		})
+	}
+	return report, nil
+}
+
+// resolve returns the example of a judgment without its relevance. When the document of the judgment cannot be
+// identified, it returns the reason instead; err is only set when the query that looks for the document fails.
+func (trainingSync *TrainingSync) resolve(ctx context.Context, judgment Judgment) (example TrainingExample, unresolved string, err error) {
+	if judgment.DocumentID != "" && judgment.CollectionID != "" {
+		return TrainingExample{DocumentID: core.StringPtr(judgment.DocumentID), CollectionID: core.StringPtr(judgment.CollectionID)}, "", nil
+	}
+	if judgment.DocumentID != "" && len(trainingSync.CollectionIds) == 1 {
+		return TrainingExample{DocumentID: core.StringPtr(judgment.DocumentID), CollectionID: core.StringPtr(trainingSync.CollectionIds[0])}, "", nil
+	}
+
+	var field *QueryField
+	var value string
+	switch {
+	case judgment.DocumentID != "":
+		field, value = NewQueryField("document_id"), judgment.DocumentID
+	case judgment.DocumentKey != "" && trainingSync.KeyField != "":
+		field, value = NewQueryField(trainingSync.KeyField), judgment.DocumentKey
+	case judgment.DocumentKey != "":
+		return TrainingExample{}, fmt.Sprintf("document key %q needs a key field", judgment.DocumentKey), nil
+	default:
+		return TrainingExample{}, "judgment without a document", nil
+	}
+
+	filter := QueryExpression(field.Equals(value))
+	collectionIds := trainingSync.CollectionIds
+	if judgment.CollectionID != "" {
+		collectionIds = []string{judgment.CollectionID}
+	}
+	reference := strings.Join(collectionIds, ",") + "\x00" + filter.String()
+	if example, ok := trainingSync.resolved[reference]; ok {
+		return example, "", nil
+	}
+
+	options := trainingSync.Discovery.NewQueryOptions(trainingSync.ProjectID).
+		SetFilterExpression(filter).
+		SetReturn([]string{"document_id"}).
+		SetCount(2)
+	if len(collectionIds) > 0 {
+		options.SetCollectionIds(collectionIds)
+	}
+	result, _, err := trainingSync.Discovery.QueryWithContext(ctx, options)
+	if err != nil {
+		return TrainingExample{}, "", err
+	}
+	if len(result.Results) != 1 {
+		return TrainingExample{}, fmt.Sprintf("%d documents match %s", len(result.Results), filter.String()), nil
+	}
+	document := result.Results[0]
+	if document.DocumentID == nil || document.ResultMetadata == nil || document.ResultMetadata.CollectionID == nil {
+		return TrainingExample{}, fmt.Sprintf("the document matching %s has no document or collection ID", filter.String()), nil
+	}
+	example = TrainingExample{DocumentID: document.DocumentID, CollectionID: document.ResultMetadata.CollectionID}
+	if trainingSync.resolved == nil {
+		trainingSync.resolved = make(map[string]TrainingExample)
+	}
+	trainingSync.resolved[reference] = example
+	return example, "", nil
+}
+
+// sameTrainingExamples returns whether two lists have the same documents with the same relevance, in any order.
+func sameTrainingExamples(a []TrainingExample, b []TrainingExample) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	format := func(examples []TrainingExample) []string {
+		s := make([]string, len(examples))
+		for i, example := range examples {
+			s[i] = stringValue(example.CollectionID) + "/" + stringValue(example.DocumentID) + "=" + strconv.FormatInt(int64Value(example.Relevance), 10)
+		}
+		sort.Strings(s)
+		return s
+	}
+	x, y := format(a), format(b)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
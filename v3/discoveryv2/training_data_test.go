@@ -0,0 +1,192 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discoveryv2_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/discoveryv2"
+)
+
+var _ = Describe(`Training data`, func() {
+	const judgmentsCSV = `query,filter,document_id,document_key,collection_id,relevance
+how to reset,,d1,,c1,10
+how to reset,,,KB-2,,0
+how to reset,,,KB-2,,0
+pricing,year>2020,d3,,c1,10
+pricing,year>2020,d4,,c1,0
+no relevant,,d5,,c1,0
+bad relevance,,d6,,c1,11
+missing,,,KB-404,,10
+`
+	var testServer *httptest.Server
+	var service *discoveryv2.DiscoveryV2
+	var requests []string
+	var queries []string
+
+	BeforeEach(func() {
+		requests = nil
+		queries = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case req.URL.Path == "/v2/projects/p1/query":
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				filter := body["filter"].(string)
+				queries = append(queries, filter)
+				switch filter {
+				case "metadata.kb::KB-2":
+					fmt.Fprint(res, `{"matching_results": 1, "results": [{"document_id": "d2", "result_metadata": {"collection_id": "c2"}}]}`)
+					return
+				case "metadata.kb::KB-3":
+					fmt.Fprint(res, `{"matching_results": 1, "results": [{"document_id": "d3"}]}`)
+					return
+				case "metadata.kb::KB-500":
+					res.WriteHeader(500)
+					fmt.Fprint(res, `{"error": "Internal error", "code": 500}`)
+					return
+				}
+				fmt.Fprint(res, `{"matching_results": 0, "results": []}`)
+			case req.Method == "GET":
+				fmt.Fprint(res, `{"queries": [
+					{"query_id": "q1", "natural_language_query": "how to reset", "examples": [{"document_id": "d1", "collection_id": "c1", "relevance": 10}]},
+					{"query_id": "q2", "natural_language_query": "pricing", "filter": "year>2020", "examples": [
+						{"document_id": "d4", "collection_id": "c1", "relevance": 0}, {"document_id": "d3", "collection_id": "c1", "relevance": 10}]},
+					{"query_id": "q3", "natural_language_query": "stale", "examples": []}]}`)
+			default:
+				var body map[string]interface{}
+				if req.Body != nil && req.Method != "DELETE" {
+					Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				}
+				data, _ := json.Marshal(body)
+				requests = append(requests, req.Method+" "+strings.TrimPrefix(req.URL.Path, "/v2/projects/p1/training_data/queries")+" "+string(data))
+				if req.Method == "DELETE" {
+					res.WriteHeader(204)
+					return
+				}
+				fmt.Fprint(res, `{"natural_language_query": "x", "examples": []}`)
+			}
+		}))
+		var err error
+		service, err = discoveryv2.NewDiscoveryV2(&discoveryv2.DiscoveryV2Options{
+			URL:           testServer.URL,
+			Version:       core.StringPtr("2020-08-30"),
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Reads and writes judgment files`, func() {
+		judgments, err := discoveryv2.ReadJudgmentsCSV(strings.NewReader(judgmentsCSV))
+		Expect(err).To(BeNil())
+		Expect(judgments).To(HaveLen(8))
+		Expect(judgments[1]).To(Equal(discoveryv2.Judgment{NaturalLanguageQuery: "how to reset", DocumentKey: "KB-2"}))
+		Expect(judgments[3].Filter).To(Equal("year>2020"))
+
+		var buffer bytes.Buffer
+		Expect(discoveryv2.WriteJudgmentsJSON(&buffer, judgments[:1])).To(Succeed())
+		Expect(buffer.String()).To(MatchJSON(`[{"natural_language_query": "how to reset", "document_id": "d1", "collection_id": "c1", "relevance": 10}]`))
+		read, err := discoveryv2.ReadJudgmentsJSON(&buffer)
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(judgments[:1]))
+
+		buffer.Reset()
+		Expect(discoveryv2.WriteJudgmentsCSV(&buffer, judgments[3:4])).To(Succeed())
+		Expect(buffer.String()).To(Equal("natural_language_query,filter,document_id,document_key,collection_id,relevance\npricing,year>2020,d3,,c1,10\n"))
+
+		_, err = discoveryv2.ReadJudgmentsCSV(strings.NewReader("query,relevance\n"))
+		Expect(err).To(MatchError("missing column document_id or document_key"))
+		_, err = discoveryv2.ReadJudgmentsCSV(strings.NewReader("query,document_id,relevance\nq,d,high\n"))
+		Expect(err).To(MatchError(`line 2: invalid relevance "high"`))
+	})
+	It(`Syncs training queries and reports issues`, func() {
+		judgments, err := discoveryv2.ReadJudgmentsCSV(strings.NewReader(judgmentsCSV))
+		Expect(err).To(BeNil())
+		trainingSync := service.NewTrainingSync("p1")
+		trainingSync.KeyField = "metadata.kb"
+		trainingSync.DeleteStale = true
+
+		report, err := trainingSync.Sync(context.Background(), judgments)
+		Expect(err).To(BeNil())
+		Expect(report.Created).To(BeEmpty())
+		Expect(report.Updated).To(Equal([]string{"how to reset"}))
+		Expect(report.Deleted).To(Equal([]string{"stale"}))
+		Expect(report.Unchanged).To(Equal(1))
+		Expect(queries).To(Equal([]string{"metadata.kb::KB-2", "metadata.kb::KB-404"}))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0]).To(HavePrefix("POST /q1 "))
+		Expect(requests[0]).To(ContainSubstring(`{"collection_id":"c2","document_id":"d2","relevance":0}`))
+		Expect(requests[1]).To(Equal("DELETE /q3 null"))
+
+		var summary bytes.Buffer
+		Expect(report.WriteSummary(&summary)).To(Succeed())
+		Expect(summary.String()).To(Equal(`0 created, 1 updated, 1 deleted, 1 unchanged
+"bad relevance": relevance 11 is not between 0 and 10
+"missing": 0 documents match metadata.kb::KB-404
+"no relevant": no example with a relevance above 0
+"missing": no examples
+2 valid training queries, at least 50 are needed to train
+`))
+	})
+	It(`Creates queries in dry runs only in the report, and exports`, func() {
+		trainingSync := service.NewTrainingSync("p1")
+		trainingSync.DryRun = true
+		trainingSync.CollectionIds = []string{"c9"}
+		report, err := trainingSync.Sync(context.Background(), []discoveryv2.Judgment{
+			{NaturalLanguageQuery: "new", DocumentID: "d9", Relevance: 5},
+		})
+		Expect(err).To(BeNil())
+		Expect(report.Created).To(Equal([]string{"new"}))
+		Expect(report.Deleted).To(BeEmpty())
+		Expect(requests).To(BeEmpty())
+
+		judgments, err := trainingSync.Export(context.Background())
+		Expect(err).To(BeNil())
+		Expect(judgments).To(HaveLen(3))
+		Expect(judgments[1]).To(Equal(discoveryv2.Judgment{NaturalLanguageQuery: "pricing", Filter: "year>2020", DocumentID: "d4", CollectionID: "c1"}))
+	})
+	It(`Stops before making changes when a document cannot be looked up`, func() {
+		trainingSync := service.NewTrainingSync("p1")
+		trainingSync.KeyField = "metadata.kb"
+		report, err := trainingSync.Sync(context.Background(), []discoveryv2.Judgment{
+			{NaturalLanguageQuery: "how to reset", DocumentKey: "KB-3", Relevance: 10},
+		})
+		Expect(err).To(BeNil())
+		Expect(report.Issues[0].Message).To(Equal("the document matching metadata.kb::KB-3 has no document or collection ID"))
+
+		_, err = trainingSync.Sync(context.Background(), []discoveryv2.Judgment{
+			{NaturalLanguageQuery: "how to reset", DocumentID: "d1", CollectionID: "c1", Relevance: 5},
+			{NaturalLanguageQuery: "how to reset", DocumentKey: "KB-500", Relevance: 0},
+		})
+		Expect(err).To(MatchError("Internal error"))
+		Expect(requests).To(BeEmpty())
+	})
+})
@@ -11,4 +11,5 @@ require (
 	github.com/onsi/gomega v1.18.1
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
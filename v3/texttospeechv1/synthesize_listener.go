@@ -18,6 +18,8 @@ package texttospeechv1
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -34,6 +36,7 @@ const (
 type SynthesizeListener struct {
 	IsClosed chan bool
 	Callback SynthesizeCallbackWrapper
+	ctx      context.Context
 }
 
 func decode(b []byte, target interface{}) {
@@ -79,12 +82,13 @@ func (listener SynthesizeListener) OnData(conn *websocket.Conn) {
 		// The service will close the connection. We need to decipher
 		// if the error is a normal close signal
 		if err != nil {
-			listener.IsClosed <- true
-
-			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			if listener.ctx != nil && listener.ctx.Err() != nil {
+				listener.OnError(listener.ctx.Err())
+			} else if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
 				listener.OnError(err)
 				conn.Close()
 			}
+			listener.IsClosed <- true
 			break
 		}
 
@@ -127,11 +131,33 @@ func (listener SynthesizeListener) OnData(conn *websocket.Conn) {
 }
 
 func (textToSpeechV1 *TextToSpeechV1) NewSynthesizeListener(callback SynthesizeCallbackWrapper, req *http.Request) {
-	synthesizeListener := SynthesizeListener{Callback: callback, IsClosed: make(chan bool, 1)}
-	conn, _, err := websocket.DefaultDialer.Dial(req.URL.String(), req.Header)
+	textToSpeechV1.newSynthesizeListener(context.Background(), callback, req)
+}
+
+// newSynthesizeListener runs a synthesis and returns once the connection is closed. The connection is closed early
+// when ctx is done.
+func (textToSpeechV1 *TextToSpeechV1) newSynthesizeListener(ctx context.Context, callback SynthesizeCallbackWrapper, req *http.Request) {
+	synthesizeListener := SynthesizeListener{Callback: callback, IsClosed: make(chan bool, 1), ctx: ctx}
+	dialer := *websocket.DefaultDialer
+	if textToSpeechV1.Service.IsSSLDisabled() {
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+	}
+	conn, _, err := dialer.DialContext(ctx, req.URL.String(), req.Header)
 	if err != nil {
 		synthesizeListener.OnError(err)
+		callback.OnClose()
+		return
 	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
 
 	go synthesizeListener.OnData(conn)
 	go synthesizeListener.SendText(conn, req)
@@ -17,8 +17,8 @@
 package texttospeechv1
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/IBM/go-sdk-core/v5/core"
 	common "github.com/watson-developer-cloud/go-sdk/v3/common"
@@ -89,6 +89,11 @@ func (options *SynthesizeUsingWebsocketOptions) SetTimings(timings []string) *Sy
 
 // SynthesizeUsingWebsocket: Synthesize text over websocket connection
 func (textToSpeech *TextToSpeechV1) SynthesizeUsingWebsocket(synthesizeOptions *SynthesizeUsingWebsocketOptions) error {
+	return textToSpeech.SynthesizeUsingWebsocketWithContext(context.Background(), synthesizeOptions)
+}
+
+// SynthesizeUsingWebsocketWithContext: Synthesize text over websocket connection, closing the connection when ctx is done
+func (textToSpeech *TextToSpeechV1) SynthesizeUsingWebsocketWithContext(ctx context.Context, synthesizeOptions *SynthesizeUsingWebsocketOptions) error {
 	if err := core.ValidateNotNil(synthesizeOptions, "synthesizeOptions cannot be nil"); err != nil {
 		return err
 	}
@@ -105,8 +110,7 @@ func (textToSpeech *TextToSpeechV1) SynthesizeUsingWebsocket(synthesizeOptions *
 	pathParameters := []string{}
 
 	builder := core.NewRequestBuilder(core.POST)
-	dialURL := strings.Replace(textToSpeech.Service.Options.URL, "https", "wss", 1)
-	_, err := builder.ConstructHTTPURL(dialURL, pathSegments, pathParameters)
+	_, err := builder.ConstructHTTPURL(textToSpeech.Service.Options.URL, pathSegments, pathParameters)
 	if err != nil {
 		return err
 	}
@@ -148,6 +152,12 @@ func (textToSpeech *TextToSpeechV1) SynthesizeUsingWebsocket(synthesizeOptions *
 	if err != nil {
 		return err
 	}
+	switch request.URL.Scheme {
+	case "https":
+		request.URL.Scheme = "wss"
+	case "http":
+		request.URL.Scheme = "ws"
+	}
 
 	// Add the authentication header
 	err = textToSpeech.Service.Options.Authenticator.Authenticate(request)
@@ -155,6 +165,6 @@ func (textToSpeech *TextToSpeechV1) SynthesizeUsingWebsocket(synthesizeOptions *
 		return err
 	}
 
-	textToSpeech.NewSynthesizeListener(synthesizeOptions.Callback, request)
+	textToSpeech.newSynthesizeListener(ctx, synthesizeOptions.Callback, request)
 	return nil
 }
@@ -0,0 +1,354 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package voicebridge connects Speech to Text, Watson Assistant and Text to Speech into a voice bot. A Bridge runs
+// the turn-taking loop of a call: final transcripts of the caller's speech are sent to the assistant, the responses are
+// rendered as SSML and the synthesized audio is played back, pausing where the assistant asks for a pause and stopping
+// when the caller barges in.
+//
+// Every stage is an interface, so that the bridge can be used with other services and tested without a network.
+// SpeechToTextRecognizer, AssistantConversation and TextToSpeechSynthesizer implement the stages with the Watson
+// services.
+package voicebridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+)
+
+// Transcript : A transcription of the caller's speech.
+type Transcript struct {
+	Text       string
+	Confidence float64
+
+	// Whether the transcript is final. Interim transcripts are only used to detect barge-in.
+	Final bool
+}
+
+// Recognizer : Transcribes the caller's audio.
+type Recognizer interface {
+	// Recognize reads audio until it ends or ctx is done and calls onTranscript for every interim and final transcript.
+	Recognize(ctx context.Context, audio io.Reader, onTranscript func(Transcript)) error
+}
+
+// Conversation : Sends the caller's utterances to an assistant.
+type Conversation interface {
+	// Message sends the text of an utterance and returns the output of the assistant. An empty text starts the
+	// conversation.
+	Message(ctx context.Context, text string) (*assistantv2.MessageOutput, error)
+}
+
+// Synthesizer : Converts SSML into audio.
+type Synthesizer interface {
+	// Synthesize writes the audio for an SSML document to audio. It returns early when ctx is done.
+	Synthesize(ctx context.Context, ssml string, audio io.Writer) error
+}
+
+// AudioSink : Plays audio to the caller. Its methods may be called from different goroutines.
+type AudioSink interface {
+	io.Writer
+
+	// Interrupt discards audio that has been written but not played yet. It is called when the caller barges in.
+	Interrupt() error
+}
+
+// Segment : A part of the assistant's response: either SSML to be spoken or a pause.
+type Segment struct {
+	SSML  string
+	Pause time.Duration
+}
+
+// Turn : One exchange of a call.
+type Turn struct {
+	// The final transcript of the caller's utterance; empty for the welcome turn.
+	UserInput string
+
+	Output   *assistantv2.MessageOutput
+	Segments []Segment
+}
+
+// RenderSegments renders responses as SSML segments, split at pause responses so that pauses can be honoured exactly
+// instead of relying on SSML breaks.
+func RenderSegments(responses []assistantv2.RuntimeResponseGenericIntf, renderer assistantv2.ResponseRenderer) ([]Segment, error) {
+	var segments []Segment
+	var pending []assistantv2.RuntimeResponseGenericIntf
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		ssml, err := renderer.Render(pending)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, Segment{SSML: ssml})
+		pending = nil
+		return nil
+	}
+
+	for _, response := range responses {
+		pause, ok := response.(*assistantv2.RuntimeResponseGenericRuntimeResponseTypePause)
+		if !ok {
+			pending = append(pending, response)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if pause.Time != nil && *pause.Time > 0 {
+			segments = append(segments, Segment{Pause: time.Duration(*pause.Time) * time.Millisecond})
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// Bridge : Runs the turn-taking loop of a call.
+type Bridge struct {
+	Recognizer   Recognizer
+	Conversation Conversation
+	Synthesizer  Synthesizer
+
+	// Renders the responses of the assistant. Defaults to an SSMLRenderer without a channel, because the responses are
+	// filtered by Channel before they are rendered. A renderer with a channel of its own filters them again.
+	Renderer assistantv2.ResponseRenderer
+
+	// Only responses for this channel are played. Defaults to "voice_telephony"; set to "" to play all responses.
+	Channel string
+
+	// Whether the caller can interrupt the assistant by speaking. When false, speech during playback is ignored.
+	BargeIn bool
+
+	// Whether to send an empty message when the call starts, so that the assistant greets the caller.
+	Welcome bool
+
+	// Called with every turn before it is played.
+	OnTurn func(*Turn)
+
+	// Decides whether the call ends after a response is played, for example on a hang-up action.
+	Hangup func(*assistantv2.MessageOutput) bool
+
+	// Waits for a pause. Defaults to a timer; replaced in tests.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBridge : Instantiate Bridge with barge-in and a welcome turn enabled
+func NewBridge(recognizer Recognizer, conversation Conversation, synthesizer Synthesizer) *Bridge {
+	return &Bridge{
+		Recognizer:   recognizer,
+		Conversation: conversation,
+		Synthesizer:  synthesizer,
+		Renderer:     &assistantv2.SSMLRenderer{},
+		Channel:      assistantv2.ResponseGenericChannelChannelVoiceTelephonyConst,
+		BargeIn:      true,
+		Welcome:      true,
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type playback struct {
+	cancel context.CancelFunc
+	done   chan error
+	hangup bool
+}
+
+// Run runs a call until the audio source ends, the assistant hangs up or ctx is done. The source is the caller's
+// audio and the sink plays audio to the caller. When the source ends, the response being played is finished first.
+func (bridge *Bridge) Run(ctx context.Context, source io.Reader, sink AudioSink) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	transcripts := make(chan Transcript, 16)
+	var recognizeErr error
+	go func() {
+		defer close(transcripts)
+		recognizeErr = bridge.Recognizer.Recognize(ctx, source, func(transcript Transcript) {
+			select {
+			case transcripts <- transcript:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var current *playback
+	// stop ends the current playback, returning the error it failed with, if any, and the error of interrupting the
+	// sink.
+	stop := func() error {
+		if current == nil {
+			return nil
+		}
+		select {
+		case playErr := <-current.done:
+			// The response has already been played.
+			current.cancel()
+			current = nil
+			return playErr
+		default:
+		}
+		current.cancel()
+		playErr := <-current.done
+		current = nil
+		interruptErr := sink.Interrupt()
+		if playErr != nil && interruptErr != nil {
+			return fmt.Errorf("%s; interrupting playback: %s", playErr.Error(), interruptErr.Error())
+		} else if playErr != nil {
+			return playErr
+		}
+		return interruptErr
+	}
+	defer func() {
+		if stopErr := stop(); err == nil {
+			err = stopErr
+		}
+	}()
+
+	if bridge.Welcome {
+		current, err = bridge.respond(ctx, "", sink)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		var played chan error
+		if current != nil {
+			played = current.done
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-played:
+			hangup := current.hangup
+			current.cancel()
+			current = nil
+			if err != nil || hangup {
+				return err
+			}
+
+		case transcript, ok := <-transcripts:
+			if !ok {
+				if current != nil {
+					select {
+					case err := <-current.done:
+						current.cancel()
+						current = nil
+						if err != nil {
+							return err
+						}
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				return recognizeErr
+			}
+			if strings.TrimSpace(transcript.Text) == "" {
+				continue
+			}
+			if current != nil {
+				if !bridge.BargeIn {
+					continue
+				}
+				if err := stop(); err != nil {
+					return err
+				}
+			}
+			if !transcript.Final {
+				continue
+			}
+			current, err = bridge.respond(ctx, strings.TrimSpace(transcript.Text), sink)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// respond sends an utterance to the assistant and starts playing the response.
+func (bridge *Bridge) respond(ctx context.Context, text string, sink AudioSink) (*playback, error) {
+	output, err := bridge.Conversation.Message(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	turn := &Turn{UserInput: text, Output: output}
+	if output != nil {
+		responses := output.Generic
+		if bridge.Channel != "" {
+			responses = assistantv2.FilterRuntimeResponseGenericByChannel(responses, bridge.Channel)
+		}
+		renderer := bridge.Renderer
+		if renderer == nil {
+			renderer = &assistantv2.SSMLRenderer{}
+		}
+		turn.Segments, err = RenderSegments(responses, renderer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if bridge.OnTurn != nil {
+		bridge.OnTurn(turn)
+	}
+
+	playCtx, cancel := context.WithCancel(ctx)
+	current := &playback{
+		cancel: cancel,
+		done:   make(chan error, 1),
+		hangup: bridge.Hangup != nil && bridge.Hangup(output),
+	}
+	go func() {
+		current.done <- bridge.play(playCtx, turn.Segments, sink)
+	}()
+	return current, nil
+}
+
+// play plays segments until they are done or ctx is done. An interrupted playback is not an error.
+func (bridge *Bridge) play(ctx context.Context, segments []Segment, sink AudioSink) error {
+	wait := bridge.Sleep
+	if wait == nil {
+		wait = sleep
+	}
+	for _, segment := range segments {
+		var err error
+		if segment.Pause > 0 {
+			err = wait(ctx, segment.Pause)
+		} else {
+			err = bridge.Synthesizer.Synthesize(ctx, segment.SSML, sink)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package voicebridge_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+	"github.com/watson-developer-cloud/go-sdk/v3/texttospeechv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/voicebridge"
+)
+
+func unmarshalOutput(data string) *assistantv2.MessageOutput {
+	var raw map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(data), &raw)).To(Succeed())
+	var output *assistantv2.MessageOutput
+	Expect(core.UnmarshalModel(raw, "", &output, assistantv2.UnmarshalMessageOutput)).To(Succeed())
+	return output
+}
+
+// fakeRecognizer emits the transcripts sent on its channel until the channel is closed.
+type fakeRecognizer struct {
+	transcripts chan voicebridge.Transcript
+}
+
+func (recognizer *fakeRecognizer) Recognize(ctx context.Context, audio io.Reader, onTranscript func(voicebridge.Transcript)) error {
+	for {
+		select {
+		case transcript, ok := <-recognizer.transcripts:
+			if !ok {
+				return nil
+			}
+			onTranscript(transcript)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// fakeConversation replies with a canned output per input.
+type fakeConversation struct {
+	mutex   sync.Mutex
+	inputs  []string
+	outputs map[string]string
+}
+
+func (conversation *fakeConversation) Message(ctx context.Context, text string) (*assistantv2.MessageOutput, error) {
+	conversation.mutex.Lock()
+	defer conversation.mutex.Unlock()
+	conversation.inputs = append(conversation.inputs, text)
+	output, ok := conversation.outputs[text]
+	if !ok {
+		return nil, errors.New("unexpected input " + text)
+	}
+	return unmarshalOutput(output), nil
+}
+
+func (conversation *fakeConversation) Inputs() []string {
+	conversation.mutex.Lock()
+	defer conversation.mutex.Unlock()
+	return append([]string{}, conversation.inputs...)
+}
+
+// fakeSynthesizer writes the SSML as audio; documents containing "long" block until playback is interrupted.
+type fakeSynthesizer struct{}
+
+func (fakeSynthesizer) Synthesize(ctx context.Context, ssml string, audio io.Writer) error {
+	if _, err := audio.Write([]byte(ssml)); err != nil {
+		return err
+	}
+	if strings.Contains(ssml, "long") {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+type fakeSink struct {
+	mutex         sync.Mutex
+	played        []string
+	interruptions int
+	interruptErr  error
+}
+
+func (sink *fakeSink) Write(p []byte) (int, error) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.played = append(sink.played, string(p))
+	return len(p), nil
+}
+
+func (sink *fakeSink) Interrupt() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.interruptions++
+	return sink.interruptErr
+}
+
+func (sink *fakeSink) Played() []string {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return append([]string{}, sink.played...)
+}
+
+var _ = Describe(`Bridge`, func() {
+	var recognizer *fakeRecognizer
+	var conversation *fakeConversation
+	var sink *fakeSink
+	var bridge *voicebridge.Bridge
+	var pauses []time.Duration
+	var result chan error
+
+	run := func() {
+		result = make(chan error, 1)
+		go func() {
+			result <- bridge.Run(context.Background(), strings.NewReader(""), sink)
+		}()
+	}
+	say := func(text string, final bool) {
+		recognizer.transcripts <- voicebridge.Transcript{Text: text, Final: final}
+	}
+
+	BeforeEach(func() {
+		recognizer = &fakeRecognizer{transcripts: make(chan voicebridge.Transcript)}
+		conversation = &fakeConversation{outputs: map[string]string{
+			"": `{"generic": [{"response_type": "text", "text": "Welcome"}]}`,
+			"hours": `{"generic": [
+				{"response_type": "text", "text": "One moment"},
+				{"response_type": "pause", "time": 1500, "typing": true},
+				{"response_type": "text", "text": "We open at 9"},
+				{"response_type": "text", "text": "See our website", "channels": [{"channel": "chat"}]}
+			]}`,
+			"story": `{"generic": [{"response_type": "text", "text": "A long story"}]}`,
+			"stop":  `{"generic": [{"response_type": "text", "text": "OK"}]}`,
+			"bye":   `{"generic": [{"response_type": "text", "text": "Goodbye"}], "user_defined": {"hangup": true}}`,
+		}}
+		sink = &fakeSink{}
+		pauses = nil
+		bridge = voicebridge.NewBridge(recognizer, conversation, fakeSynthesizer{})
+		bridge.Sleep = func(ctx context.Context, d time.Duration) error {
+			pauses = append(pauses, d)
+			return nil
+		}
+	})
+
+	It(`Plays the welcome and the responses for the voice channel, honouring pauses`, func() {
+		var turns []*voicebridge.Turn
+		bridge.OnTurn = func(turn *voicebridge.Turn) {
+			turns = append(turns, turn)
+		}
+		run()
+		Eventually(sink.Played).Should(Equal([]string{"<speak>Welcome</speak>"}))
+		say("hours", false)
+		say(" hours ", true)
+		close(recognizer.transcripts)
+		Eventually(result).Should(Receive(BeNil()))
+
+		Expect(conversation.Inputs()).To(Equal([]string{"", "hours"}))
+		Expect(sink.Played()).To(Equal([]string{"<speak>Welcome</speak>", "<speak>One moment</speak>", "<speak>We open at 9</speak>"}))
+		Expect(pauses).To(Equal([]time.Duration{1500 * time.Millisecond}))
+		Expect(turns).To(HaveLen(2))
+		Expect(turns[1].UserInput).To(Equal("hours"))
+		Expect(turns[1].Segments).To(HaveLen(3))
+	})
+	It(`Stops playback when the caller barges in`, func() {
+		bridge.Welcome = false
+		run()
+		say("story", true)
+		Eventually(sink.Played).Should(Equal([]string{"<speak>A long story</speak>"}))
+		say("st", false)
+		say("stop", true)
+		close(recognizer.transcripts)
+		Eventually(result).Should(Receive(BeNil()))
+
+		Expect(conversation.Inputs()).To(Equal([]string{"story", "stop"}))
+		Expect(sink.Played()).To(Equal([]string{"<speak>A long story</speak>", "<speak>OK</speak>"}))
+		Expect(sink.interruptions).To(Equal(1))
+	})
+	It(`Returns the error of interrupting playback`, func() {
+		bridge.Welcome = false
+		sink.interruptErr = errors.New("line busy")
+		run()
+		say("story", true)
+		Eventually(sink.Played).Should(Equal([]string{"<speak>A long story</speak>"}))
+		say("stop", false)
+		Eventually(result).Should(Receive(MatchError("line busy")))
+	})
+	It(`Ends the call on hang-up`, func() {
+		bridge.Welcome = false
+		bridge.Hangup = func(output *assistantv2.MessageOutput) bool {
+			return output.UserDefined["hangup"] == true
+		}
+		run()
+		say("bye", true)
+		Eventually(result).Should(Receive(BeNil()))
+		Expect(sink.Played()).To(Equal([]string{"<speak>Goodbye</speak>"}))
+	})
+	It(`Returns errors from the assistant`, func() {
+		run()
+		Eventually(sink.Played).Should(HaveLen(1))
+		say("unknown", true)
+		Eventually(result).Should(Receive(MatchError("unexpected input unknown")))
+	})
+})
+
+var _ = Describe(`TextToSpeechSynthesizer`, func() {
+	It(`Streams audio and closes the websocket when interrupted`, func() {
+		closed := make(chan struct{})
+		upgrader := websocket.Upgrader{}
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/v1/synthesize"))
+			Expect(req.URL.Query().Get("voice")).To(Equal("en-US_AllisonV3Voice"))
+			Expect(req.URL.Query().Get("customization_id")).To(Equal("c1"))
+			conn, err := upgrader.Upgrade(res, req, nil)
+			Expect(err).To(BeNil())
+			defer conn.Close()
+			var message map[string]interface{}
+			Expect(conn.ReadJSON(&message)).To(Succeed())
+			Expect(message).To(Equal(map[string]interface{}{"text": "<speak>Hello</speak>", "accept": "audio/wav"}))
+			Expect(conn.WriteMessage(websocket.BinaryMessage, []byte("audio"))).To(Succeed())
+			// The rest of the utterance is never sent, so the client has to close the connection.
+			_, _, err = conn.ReadMessage()
+			Expect(err).ToNot(BeNil())
+			close(closed)
+		}))
+		defer server.Close()
+		textToSpeech, err := texttospeechv1.NewTextToSpeechV1(&texttospeechv1.TextToSpeechV1Options{
+			URL:           server.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		synthesizer := voicebridge.NewTextToSpeechSynthesizer(textToSpeech, "en-US_AllisonV3Voice", "audio/wav")
+		synthesizer.Configure = func(options *texttospeechv1.SynthesizeUsingWebsocketOptions) {
+			options.SetCustomizationID("c1")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		audio := &interruptingWriter{cancel: cancel}
+		err = synthesizer.Synthesize(ctx, "<speak>Hello</speak>", audio)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(audio.String()).To(Equal("audio"))
+		Eventually(closed, time.Second).Should(BeClosed())
+	})
+})
+
+// interruptingWriter cancels playback after the first audio it receives, like a caller barging in.
+type interruptingWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (writer *interruptingWriter) Write(p []byte) (int, error) {
+	defer writer.cancel()
+	return writer.Buffer.Write(p)
+}
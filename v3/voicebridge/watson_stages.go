@@ -0,0 +1,253 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package voicebridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/watson-developer-cloud/go-sdk/v3/assistantv2"
+	"github.com/watson-developer-cloud/go-sdk/v3/speechtotextv1"
+	"github.com/watson-developer-cloud/go-sdk/v3/texttospeechv1"
+)
+
+// contextReader ends the audio stream when the context is done, which makes RecognizeUsingWebsocket stop.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (reader *contextReader) Read(p []byte) (int, error) {
+	if reader.ctx.Err() != nil {
+		return 0, io.EOF
+	}
+	return reader.reader.Read(p)
+}
+
+// firstError records the first error reported by a websocket callback.
+type firstError struct {
+	mutex sync.Mutex
+	err   error
+}
+
+func (e *firstError) set(err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *firstError) get() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.err
+}
+
+// SpeechToTextRecognizer : A Recognizer that streams audio to Speech to Text with RecognizeUsingWebsocket. Interim
+// results are requested so that barge-in is detected early, and the inactivity timeout is disabled so that silence
+// does not end the call.
+type SpeechToTextRecognizer struct {
+	SpeechToText *speechtotextv1.SpeechToTextV1
+
+	// The format of the audio, for example "audio/mulaw;rate=8000".
+	ContentType string
+
+	// Customizes the recognize options, for example to set the model or the end of phrase silence time.
+	Configure func(*speechtotextv1.RecognizeUsingWebsocketOptions)
+}
+
+// NewSpeechToTextRecognizer : Instantiate SpeechToTextRecognizer
+func NewSpeechToTextRecognizer(speechToText *speechtotextv1.SpeechToTextV1, contentType string) *SpeechToTextRecognizer {
+	return &SpeechToTextRecognizer{SpeechToText: speechToText, ContentType: contentType}
+}
+
+type recognizeCallback struct {
+	onTranscript func(Transcript)
+	err          firstError
+}
+
+func (callback *recognizeCallback) OnOpen()  {}
+func (callback *recognizeCallback) OnClose() {}
+
+func (callback *recognizeCallback) OnData(response *core.DetailedResponse) {
+	data, ok := response.GetResult().([]byte)
+	if !ok {
+		return
+	}
+	var results speechtotextv1.SpeechRecognitionResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		callback.err.set(err)
+		return
+	}
+	for _, result := range results.Results {
+		if len(result.Alternatives) == 0 || result.Alternatives[0].Transcript == nil {
+			continue
+		}
+		transcript := Transcript{
+			Text:  *result.Alternatives[0].Transcript,
+			Final: result.Final != nil && *result.Final,
+		}
+		if result.Alternatives[0].Confidence != nil {
+			transcript.Confidence = *result.Alternatives[0].Confidence
+		}
+		callback.onTranscript(transcript)
+	}
+}
+
+func (callback *recognizeCallback) OnError(err error) {
+	callback.err.set(err)
+}
+
+// Recognize streams audio to Speech to Text until the audio ends or ctx is done. Because the audio is read
+// synchronously, a source that blocks must return when the call ends.
+func (recognizer *SpeechToTextRecognizer) Recognize(ctx context.Context, audio io.Reader, onTranscript func(Transcript)) (err error) {
+	options := recognizer.SpeechToText.NewRecognizeUsingWebsocketOptions(ioutil.NopCloser(&contextReader{ctx: ctx, reader: audio}), recognizer.ContentType)
+	options.SetInterimResults(true)
+	options.SetInactivityTimeout(-1)
+	if recognizer.Configure != nil {
+		recognizer.Configure(options)
+	}
+
+	// RecognizeUsingWebsocket panics on invalid options.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	callback := &recognizeCallback{onTranscript: onTranscript}
+	recognizer.SpeechToText.RecognizeUsingWebsocket(options, callback)
+	return callback.err.get()
+}
+
+// TextToSpeechSynthesizer : A Synthesizer that uses SynthesizeUsingWebsocketWithContext of Text to Speech, so that
+// audio is played as soon as it arrives.
+type TextToSpeechSynthesizer struct {
+	TextToSpeech *texttospeechv1.TextToSpeechV1
+
+	// The voice and the audio format, for example "audio/mulaw;rate=8000" for telephony. Optional.
+	Voice  string
+	Accept string
+
+	// Customizes the synthesize options, for example to set the customization ID or headers.
+	Configure func(*texttospeechv1.SynthesizeUsingWebsocketOptions)
+}
+
+// NewTextToSpeechSynthesizer : Instantiate TextToSpeechSynthesizer
+func NewTextToSpeechSynthesizer(textToSpeech *texttospeechv1.TextToSpeechV1, voice string, accept string) *TextToSpeechSynthesizer {
+	return &TextToSpeechSynthesizer{TextToSpeech: textToSpeech, Voice: voice, Accept: accept}
+}
+
+type synthesizeCallback struct {
+	audio  io.Writer
+	cancel context.CancelFunc
+	err    firstError
+}
+
+func (callback *synthesizeCallback) OnOpen()                                    {}
+func (callback *synthesizeCallback) OnClose()                                   {}
+func (callback *synthesizeCallback) OnContentType(string)                       {}
+func (callback *synthesizeCallback) OnTimingInformation(texttospeechv1.Timings) {}
+func (callback *synthesizeCallback) OnMarks(texttospeechv1.Marks)               {}
+func (callback *synthesizeCallback) OnData(*core.DetailedResponse)              {}
+
+func (callback *synthesizeCallback) OnAudioStream(data []byte) {
+	if _, err := callback.audio.Write(data); err != nil {
+		callback.err.set(err)
+		callback.cancel()
+	}
+}
+
+func (callback *synthesizeCallback) OnError(err error) {
+	callback.err.set(err)
+}
+
+// Synthesize streams the audio for an SSML document to audio over the synthesize websocket of Text to Speech. When
+// ctx is done the websocket is closed, so that an interrupted utterance does not keep its connection open until the
+// service has sent all of its audio.
+func (synthesizer *TextToSpeechSynthesizer) Synthesize(ctx context.Context, ssml string, audio io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	callback := &synthesizeCallback{audio: audio, cancel: cancel}
+	options := synthesizer.TextToSpeech.NewSynthesizeUsingWebsocketOptions(ssml, callback)
+	if synthesizer.Voice != "" {
+		options.SetVoice(synthesizer.Voice)
+	}
+	if synthesizer.Accept != "" {
+		options.SetAccept(synthesizer.Accept)
+	}
+	if synthesizer.Configure != nil {
+		synthesizer.Configure(options)
+	}
+	if err := synthesizer.TextToSpeech.SynthesizeUsingWebsocketWithContext(ctx, options); err != nil {
+		return err
+	}
+	return callback.err.get()
+}
+
+// AssistantConversation : A Conversation with an assistant that uses a stateful session, created on the first message.
+type AssistantConversation struct {
+	Assistant   *assistantv2.AssistantV2
+	AssistantID string
+	SessionID   string
+	UserID      string
+}
+
+// NewAssistantConversation : Instantiate AssistantConversation
+func NewAssistantConversation(assistant *assistantv2.AssistantV2, assistantID string) *AssistantConversation {
+	return &AssistantConversation{Assistant: assistant, AssistantID: assistantID}
+}
+
+// Message sends the text of an utterance to the assistant.
+func (conversation *AssistantConversation) Message(ctx context.Context, text string) (*assistantv2.MessageOutput, error) {
+	if conversation.SessionID == "" {
+		session, _, err := conversation.Assistant.CreateSessionWithContext(ctx, conversation.Assistant.NewCreateSessionOptions(conversation.AssistantID))
+		if err != nil {
+			return nil, err
+		}
+		conversation.SessionID = *session.SessionID
+	}
+
+	options := conversation.Assistant.NewMessageOptions(conversation.AssistantID, conversation.SessionID)
+	options.SetInput(&assistantv2.MessageInput{
+		MessageType: core.StringPtr(assistantv2.MessageInputMessageTypeTextConst),
+		Text:        core.StringPtr(text),
+	})
+	if conversation.UserID != "" {
+		options.SetUserID(conversation.UserID)
+	}
+	response, _, err := conversation.Assistant.MessageWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return response.Output, nil
+}
+
+// Close deletes the session, if one was created.
+func (conversation *AssistantConversation) Close(ctx context.Context) error {
+	if conversation.SessionID == "" {
+		return nil
+	}
+	_, err := conversation.Assistant.DeleteSessionWithContext(ctx, conversation.Assistant.NewDeleteSessionOptions(conversation.AssistantID, conversation.SessionID))
+	conversation.SessionID = ""
+	return err
+}